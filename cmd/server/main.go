@@ -1,30 +1,89 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"flag"
+	"fmt"
 	"log"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"time"
 
+	"phobos/internal/features/backups"
+	"phobos/internal/features/calendar"
+	"phobos/internal/features/coaching"
 	"phobos/internal/features/exercises"
 	"phobos/internal/features/home"
+	"phobos/internal/features/jobs"
+	"phobos/internal/features/metrics"
+	"phobos/internal/features/notifications"
+	"phobos/internal/features/plates"
+	"phobos/internal/features/reports"
 	"phobos/internal/features/routines"
+	"phobos/internal/features/search"
+	"phobos/internal/features/shares"
+	"phobos/internal/features/strength"
 	"phobos/internal/features/templates"
+	"phobos/internal/features/webhooks"
 	"phobos/internal/features/workouts"
+	"phobos/internal/shared/config"
 	"phobos/internal/shared/db"
 	"phobos/internal/shared/middleware"
+	"phobos/internal/shared/pubsub"
+	"phobos/internal/ui/layouts"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
 )
 
 func main() {
-	// Parse flags
+	// Parse flags and load settings
 	migrateOnly := flag.Bool("migrate", false, "Run migrations and exit")
-	dbPath := flag.String("db", "phobos.db", "Database file path")
-	port := flag.String("port", "3000", "Server port")
-	flag.Parse()
+	restore := flag.String("restore", "", "Restore the database from a backup file or name in -backup-dir, then exit")
+	printConfig := flag.Bool("print-config", false, "Print the settings in effect as a config file and exit")
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:], os.LookupEnv)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if err := setupLogging(cfg.Log); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	if *printConfig {
+		if err := cfg.Write(os.Stdout); err != nil {
+			log.Fatalf("Failed to print configuration: %v", err)
+		}
+		os.Exit(0)
+	}
+
+	backupConfig := backups.Config{
+		Dir:      cfg.Backup.Dir,
+		Keep:     cfg.Backup.Keep,
+		Gzip:     cfg.Backup.Gzip,
+		Schedule: cfg.Backup.Schedule,
+	}
+
+	// Restore before anything opens the database
+	if *restore != "" {
+		src := *restore
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			src = filepath.Join(backupConfig.Dir, src)
+		}
+		previous, err := backups.Restore(src, cfg.DB.Path, time.Now())
+		if err != nil {
+			log.Fatalf("Failed to restore backup: %v", err)
+		}
+		if previous != "" {
+			log.Printf("Previous database kept as %s", previous)
+		}
+		log.Printf("Restored %s from %s, exiting", cfg.DB.Path, src)
+		os.Exit(0)
+	}
 
 	// Open database
-	database, err := db.Open(*dbPath)
+	database, err := db.Open(cfg.DB.Path, cfg.DB.Pragmas()...)
 	if err != nil {
 		log.Fatalf("Failed to open database: %v", err)
 	}
@@ -44,21 +103,33 @@ func main() {
 
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			code := fiber.StatusInternalServerError
-			if e, ok := err.(*fiber.Error); ok {
-				code = e.Code
-			}
-			return c.Status(code).SendString(err.Error())
-		},
+		ErrorHandler: errorHandler(cfg.Server.ShowErrors),
 	})
 
 	// Setup middleware
-	middleware.Setup(app, database)
+	middleware.Setup(app, database, pubsub.NewBroker())
 
 	// Serve static files
-	app.Static("/static", "./static")
-	app.Static("/assets", "./assets")
+	app.Static("/static", cfg.Static.Dir)
+	app.Static("/assets", cfg.Static.AssetsDir)
+
+	// The service worker must be served from the root to control every page
+	app.Static("/sw.js", filepath.Join(cfg.Static.Dir, "sw.js"))
+
+	// Leave features that are turned off out of the navigation
+	var hidden []string
+	if !cfg.Features.Coaching {
+		hidden = append(hidden, "/coaches")
+	}
+	if !cfg.Features.Webhooks {
+		hidden = append(hidden, "/webhooks")
+	}
+	if len(hidden) > 0 {
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals(layouts.HiddenLinksKey, hidden)
+			return c.Next()
+		})
+	}
 
 	// Register routes
 	home.RegisterRoutes(app)
@@ -66,10 +137,148 @@ func main() {
 	templates.RegisterRoutes(app)
 	workouts.RegisterRoutes(app)
 	routines.RegisterRoutes(app)
+	reports.RegisterRoutes(app)
+	calendar.RegisterRoutes(app)
+	search.RegisterRoutes(app)
+	metrics.RegisterRoutes(app)
+	strength.RegisterRoutes(app)
+	plates.RegisterRoutes(app)
+	notifications.RegisterRoutes(app)
+	if cfg.Features.Coaching {
+		coaching.RegisterRoutes(app)
+	}
+	if cfg.Features.Shares {
+		shares.RegisterRoutes(app)
+	}
+	if cfg.Features.Webhooks {
+		webhooks.RegisterRoutes(app)
+	}
+	webhooks.SetEnabled(cfg.Features.Webhooks)
+	webhooks.SetAllowedNetworks(cfg.Webhooks.Networks())
+	jobs.RegisterRoutes(app)
+	backups.RegisterRoutes(app, backupConfig)
+
+	// Run background jobs
+	runner := jobs.NewRunner(database)
+	if err := registerJobs(runner, database, cfg, backupConfig); err != nil {
+		log.Fatalf("Failed to register jobs: %v", err)
+	}
+	if err := runner.Start(context.Background()); err != nil {
+		log.Fatalf("Failed to start jobs: %v", err)
+	}
 
 	// Start server
-	log.Printf("Starting server on http://localhost:%s", *port)
-	if err := app.Listen(":" + *port); err != nil {
+	log.Printf("Starting server on %s", cfg.Server.Addr)
+	if err := app.Listen(cfg.Server.Addr); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// setupLogging sends every log line, including those written with the log
+// package, through slog at the configured level and in the configured format
+func setupLogging(cfg config.Log) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return fmt.Errorf("log.level: %w", err)
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler = slog.NewTextHandler(os.Stderr, opts)
+	if cfg.Format == "json" {
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// errorHandler answers requests whose handler returned an error. Server
+// errors are logged, and their messages are only sent when showErrors is set.
+func errorHandler(showErrors bool) fiber.ErrorHandler {
+	return func(c *fiber.Ctx, err error) error {
+		code := fiber.StatusInternalServerError
+		if e, ok := err.(*fiber.Error); ok {
+			code = e.Code
+		}
+		if code >= fiber.StatusInternalServerError {
+			slog.Error("Request failed", "method", c.Method(), "path", c.Path(), "error", err)
+			if !showErrors {
+				return c.Status(code).SendString(utils.StatusMessage(code))
+			}
+		}
+		return c.Status(code).SendString(err.Error())
+	}
+}
+
+const (
+	// deliveryRetention is how long the webhook delivery log is kept
+	deliveryRetention = 30 * 24 * time.Hour
+	// tombstoneRetention is how long deleted sets are remembered so replayed
+	// offline changes can't bring them back
+	tombstoneRetention = 90 * 24 * time.Hour
+	// jobRunRetention is how long the job run history is kept
+	jobRunRetention = 30 * 24 * time.Hour
+)
+
+// registerJobs sets up the periodic work done in the background
+func registerJobs(runner *jobs.Runner, database *sql.DB, cfg *config.Config, backupConfig backups.Config) error {
+	all := []jobs.Job{
+		{
+			Name:     "webhooks.prune",
+			Schedule: "15 3 * * *",
+			Retries:  3,
+			Run: func(ctx context.Context) error {
+				_, err := webhooks.PruneDeliveries(database, time.Now().Add(-deliveryRetention))
+				return err
+			},
+		},
+		{
+			Name:     "workouts.purge-deleted-sets",
+			Schedule: "30 3 * * *",
+			Retries:  3,
+			Run: func(ctx context.Context) error {
+				_, err := workouts.PurgeDeletedSets(database, time.Now().Add(-tombstoneRetention))
+				return err
+			},
+		},
+		{
+			Name:     "jobs.prune",
+			Schedule: "45 3 * * *",
+			Retries:  3,
+			Run: func(ctx context.Context) error {
+				_, err := jobs.PruneRuns(database, time.Now().Add(-jobRunRetention))
+				return err
+			},
+		},
+	}
+
+	if cfg.Features.Webhooks {
+		dispatcher := webhooks.NewDispatcher(database)
+		all = append(all, jobs.Job{
+			Name:     "webhooks.deliver",
+			Schedule: "@every 10s",
+			Run: func(ctx context.Context) error {
+				_, err := dispatcher.DeliverDue(ctx)
+				return err
+			},
+		})
+	}
+
+	if cfg.Backup.Enabled {
+		all = append(all, jobs.Job{
+			Name:     backups.JobName,
+			Schedule: backupConfig.Schedule,
+			Retries:  2,
+			Run: func(ctx context.Context) error {
+				_, err := backups.Create(ctx, database, backupConfig, time.Now())
+				return err
+			},
+		})
+	}
+
+	for _, job := range all {
+		if err := runner.Register(job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
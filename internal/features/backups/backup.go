@@ -0,0 +1,283 @@
+package backups
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrInvalidName is returned for file names that aren't backups
+var ErrInvalidName = errors.New("not a backup file name")
+
+const nameLayout = "20060102-150405"
+
+// namePattern matches the files Create writes, so nothing else in the
+// directory is ever listed, served or rotated away
+var namePattern = regexp.MustCompile(`^phobos-(\d{8}-\d{6})\.db(\.gz)?$`)
+
+// fileName returns the name of a backup taken at t
+func fileName(t time.Time, compressed bool) string {
+	name := "phobos-" + t.UTC().Format(nameLayout) + ".db"
+	if compressed {
+		name += ".gz"
+	}
+	return name
+}
+
+// Path returns where a backup with the given name lives in dir
+func Path(dir, name string) (string, error) {
+	if !namePattern.MatchString(name) {
+		return "", ErrInvalidName
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// Create takes a consistent copy of the live database with VACUUM INTO,
+// which doesn't block writers for longer than a normal read. The copy is
+// written beside its final name and renamed into place so a crash never
+// leaves a partial backup behind. Older backups beyond cfg.Keep are then
+// removed.
+func Create(ctx context.Context, db *sql.DB, cfg Config, now time.Time) (*Backup, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	name := fileName(now, cfg.Gzip)
+	final := filepath.Join(cfg.Dir, name)
+	snapshot := filepath.Join(cfg.Dir, fileName(now, false)+".tmp")
+
+	// VACUUM INTO refuses to overwrite, so clear out any earlier attempt
+	os.Remove(snapshot)
+	if _, err := db.ExecContext(ctx, "VACUUM INTO ?", snapshot); err != nil {
+		os.Remove(snapshot)
+		return nil, fmt.Errorf("failed to copy database: %w", err)
+	}
+
+	if cfg.Gzip {
+		tmp := final + ".tmp"
+		err := compress(snapshot, tmp)
+		os.Remove(snapshot)
+		if err != nil {
+			os.Remove(tmp)
+			return nil, err
+		}
+		snapshot = tmp
+	}
+
+	if err := os.Rename(snapshot, final); err != nil {
+		os.Remove(snapshot)
+		return nil, fmt.Errorf("failed to save backup: %w", err)
+	}
+
+	info, err := os.Stat(final)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat backup: %w", err)
+	}
+
+	if _, err := Prune(cfg.Dir, cfg.Keep); err != nil {
+		return nil, err
+	}
+
+	return &Backup{Name: name, Size: info.Size(), CreatedAt: now.UTC().Truncate(time.Second), Compressed: cfg.Gzip}, nil
+}
+
+// compress gzips src into dst
+func compress(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open database copy: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer out.Close()
+
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		return fmt.Errorf("failed to compress backup: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress backup: %w", err)
+	}
+	if err := out.Sync(); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return nil
+}
+
+// List returns the backups in dir, newest first. A missing directory has no
+// backups.
+func List(dir string) ([]Backup, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	var backups []Backup
+	for _, e := range entries {
+		m := namePattern.FindStringSubmatch(e.Name())
+		if m == nil || !e.Type().IsRegular() {
+			continue
+		}
+		createdAt, err := time.Parse(nameLayout, m[1])
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat backup: %w", err)
+		}
+		backups = append(backups, Backup{
+			Name:       e.Name(),
+			Size:       info.Size(),
+			CreatedAt:  createdAt,
+			Compressed: m[2] != "",
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// Prune removes all but the newest keep backups in dir and returns how many
+// it removed. A keep of 0 or less keeps everything.
+func Prune(dir string, keep int) (int, error) {
+	if keep <= 0 {
+		return 0, nil
+	}
+
+	backups, err := List(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, b := range backups[min(keep, len(backups)):] {
+		if err := os.Remove(filepath.Join(dir, b.Name)); err != nil {
+			return removed, fmt.Errorf("failed to remove old backup: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Restore replaces the database at dbPath with the backup at src, which may
+// be gzipped. The backup is checked before anything is touched, and the
+// database it replaces is kept beside it; Restore returns that file's path,
+// or "" if there was no database yet. The server must not be running.
+func Restore(src, dbPath string, now time.Time) (string, error) {
+	tmp := dbPath + ".restore.tmp"
+	defer os.Remove(tmp)
+
+	if err := extract(src, tmp); err != nil {
+		return "", err
+	}
+	if err := check(tmp); err != nil {
+		return "", err
+	}
+
+	var previous string
+	if _, err := os.Stat(dbPath); err == nil {
+		previous = dbPath + ".before-restore-" + now.UTC().Format(nameLayout)
+		if err := os.Rename(dbPath, previous); err != nil {
+			return "", fmt.Errorf("failed to move current database aside: %w", err)
+		}
+	}
+
+	// The journal and WAL files hold writes not yet in the database file, so
+	// they go with the database they belong to; left in place they would be
+	// replayed onto the restored one. Without a database they're stale.
+	for _, suffix := range []string{"-journal", "-wal", "-shm"} {
+		if _, err := os.Stat(dbPath + suffix); err != nil {
+			continue
+		}
+		if previous == "" {
+			os.Remove(dbPath + suffix)
+			continue
+		}
+		if err := os.Rename(dbPath+suffix, previous+suffix); err != nil {
+			return previous, fmt.Errorf("failed to move current database aside: %w", err)
+		}
+	}
+
+	if err := os.Rename(tmp, dbPath); err != nil {
+		return previous, fmt.Errorf("failed to move backup into place: %w", err)
+	}
+	return previous, nil
+}
+
+// extract copies a backup to dst, decompressing it if it's gzipped
+func extract(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer in.Close()
+
+	var r io.Reader = in
+	if strings.HasSuffix(src, ".gz") {
+		zr, err := gzip.NewReader(in)
+		if err != nil {
+			return fmt.Errorf("failed to decompress backup: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create database file: %w", err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, r); err != nil {
+		return fmt.Errorf("failed to copy backup: %w", err)
+	}
+	if err := out.Sync(); err != nil {
+		return fmt.Errorf("failed to write database file: %w", err)
+	}
+	return nil
+}
+
+// check makes sure path is an intact SQLite database of this app
+func check(path string) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return fmt.Errorf("failed to check backup: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("backup is corrupt: %s", result)
+	}
+
+	var tables int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'workouts'`).Scan(&tables); err != nil {
+		return fmt.Errorf("failed to check backup: %w", err)
+	}
+	if tables == 0 {
+		return fmt.Errorf("backup has no workouts table")
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package backups
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"phobos/internal/features/jobs"
+	"phobos/internal/shared/htmx"
+	"phobos/internal/shared/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HandleIndex lists the backups with the state of the backup job
+func HandleIndex(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+	cfg := getConfig(c)
+
+	backups, err := List(cfg.Dir)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to list backups")
+	}
+
+	job, err := jobs.GetJob(db, JobName)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load backup job")
+	}
+
+	return htmx.Render(c, BackupsPage(cfg, job, backups))
+}
+
+// HandleCreate asks the job runner to take a backup now, so manual backups
+// never overlap scheduled ones
+func HandleCreate(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	err := jobs.Trigger(db, JobName, time.Now())
+	if errors.Is(err, jobs.ErrJobNotFound) {
+		return c.Status(fiber.StatusNotFound).SendString("Backups are not enabled")
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to start backup")
+	}
+
+	return htmx.Refresh(c)
+}
+
+// HandleDownload sends a backup file
+func HandleDownload(c *fiber.Ctx) error {
+	cfg := getConfig(c)
+
+	path, err := Path(cfg.Dir, c.Params("name"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).SendString("Backup not found")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return c.Status(fiber.StatusNotFound).SendString("Backup not found")
+	}
+
+	return c.Download(path)
+}
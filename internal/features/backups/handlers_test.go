@@ -0,0 +1,279 @@
+package backups_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"phobos/internal/features/backups"
+	"phobos/internal/features/exercises"
+	"phobos/internal/features/jobs"
+	"phobos/internal/testutil"
+)
+
+func TestCreate(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	exercises.Create(app.DB, "Front Squat")
+
+	for _, gzip := range []bool{false, true} {
+		cfg := app.Backup
+		cfg.Gzip = gzip
+		b, err := backups.Create(context.Background(), app.DB, cfg, time.Date(2024, 3, 12, 2, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatalf("Create (gzip %v): %v", gzip, err)
+		}
+		if b.Compressed != gzip || b.Size == 0 {
+			t.Errorf("unexpected backup %+v", b)
+		}
+	}
+
+	list, err := backups.List(app.Backup.Dir)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("expected 2 backups, got %+v", list)
+	}
+
+	// Each backup restores to a working copy of the database
+	for _, b := range list {
+		dbPath := filepath.Join(t.TempDir(), "phobos.db")
+		if _, err := backups.Restore(filepath.Join(app.Backup.Dir, b.Name), dbPath, time.Now()); err != nil {
+			t.Fatalf("Restore %s: %v", b.Name, err)
+		}
+		if !hasExercise(t, dbPath, "Front Squat") {
+			t.Errorf("expected the exercise to be restored from %s", b.Name)
+		}
+	}
+}
+
+func TestCreate_Rotates(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	start := time.Date(2024, 3, 12, 2, 0, 0, 0, time.UTC)
+	for day := range 5 {
+		if _, err := backups.Create(context.Background(), app.DB, app.Backup, start.AddDate(0, 0, day)); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	// Other files in the directory are left alone
+	os.WriteFile(filepath.Join(app.Backup.Dir, "notes.txt"), []byte("keep me"), 0o644)
+
+	list, _ := backups.List(app.Backup.Dir)
+	if len(list) != 3 {
+		t.Fatalf("expected the newest 3 backups to be kept, got %+v", list)
+	}
+	if list[0].Name != "phobos-20240316-020000.db" || list[2].Name != "phobos-20240314-020000.db" {
+		t.Errorf("expected the newest backups first, got %s to %s", list[0].Name, list[2].Name)
+	}
+	if _, err := os.Stat(filepath.Join(app.Backup.Dir, "notes.txt")); err != nil {
+		t.Error("expected unrelated files to survive rotation")
+	}
+}
+
+func TestRestore_KeepsPreviousDatabase(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	exercises.Create(app.DB, "Zercher Squat")
+	b, _ := backups.Create(context.Background(), app.DB, app.Backup, time.Now())
+
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "phobos.db")
+	os.WriteFile(dbPath, []byte("current"), 0o644)
+	os.WriteFile(dbPath+"-journal", []byte("stale"), 0o644)
+
+	previous, err := backups.Restore(filepath.Join(app.Backup.Dir, b.Name), dbPath, time.Date(2024, 3, 12, 9, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if previous != dbPath+".before-restore-20240312-093000" {
+		t.Errorf("unexpected previous database path %q", previous)
+	}
+	if data, _ := os.ReadFile(previous); string(data) != "current" {
+		t.Error("expected the previous database to be kept")
+	}
+	if _, err := os.Stat(dbPath + "-journal"); !os.IsNotExist(err) {
+		t.Error("expected the journal to be moved off the restored database")
+	}
+	if data, _ := os.ReadFile(previous + "-journal"); string(data) != "stale" {
+		t.Error("expected the journal to be kept with the previous database")
+	}
+	if !hasExercise(t, dbPath, "Zercher Squat") {
+		t.Error("expected the exercise to be restored")
+	}
+}
+
+func TestRestore_KeepsUncheckpointedWrites(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	b, _ := backups.Create(context.Background(), app.DB, app.Backup, time.Now())
+
+	// Write to a WAL database without checkpointing, then copy its files
+	// while it's open, as a crash would leave them
+	live := filepath.Join(t.TempDir(), "live.db")
+	db, err := sql.Open("sqlite", live)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	for _, stmt := range []string{
+		"PRAGMA journal_mode=WAL",
+		"PRAGMA wal_autocheckpoint=0",
+		"CREATE TABLE exercises (name TEXT)",
+		"INSERT INTO exercises (name) VALUES ('Pendlay Row')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	dbPath := filepath.Join(t.TempDir(), "phobos.db")
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		data, err := os.ReadFile(live + suffix)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", live+suffix, err)
+		}
+		os.WriteFile(dbPath+suffix, data, 0o644)
+	}
+
+	previous, err := backups.Restore(filepath.Join(app.Backup.Dir, b.Name), dbPath, time.Date(2024, 3, 12, 9, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if _, err := os.Stat(previous + "-wal"); err != nil {
+		t.Fatalf("expected the WAL to be moved beside the previous database: %v", err)
+	}
+	if !hasExercise(t, previous, "Pendlay Row") {
+		t.Error("expected the previous database to keep its uncheckpointed writes")
+	}
+	if hasExercise(t, dbPath, "Pendlay Row") {
+		t.Error("expected the old WAL not to be replayed onto the restored database")
+	}
+}
+
+func TestRestore_RejectsBadBackup(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "phobos-20240312-020000.db")
+	os.WriteFile(src, []byte("not a database"), 0o644)
+	dbPath := filepath.Join(dir, "phobos.db")
+	os.WriteFile(dbPath, []byte("current"), 0o644)
+
+	if _, err := backups.Restore(src, dbPath, time.Now()); err == nil {
+		t.Fatal("expected an error restoring a file that isn't a database")
+	}
+	if data, _ := os.ReadFile(dbPath); string(data) != "current" {
+		t.Error("expected the current database to be untouched")
+	}
+	if _, err := os.Stat(dbPath + ".restore.tmp"); !os.IsNotExist(err) {
+		t.Error("expected the temporary file to be cleaned up")
+	}
+}
+
+func TestHandleIndex(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	backups.Create(context.Background(), app.DB, app.Backup, time.Date(2024, 3, 12, 2, 0, 0, 0, time.UTC))
+
+	resp := app.Request("GET", "/backups", "")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "phobos-20240312-020000.db") {
+		t.Error("expected the backup to be listed")
+	}
+	if !strings.Contains(body, "Backups are not enabled") {
+		t.Error("expected a note that no backup job is registered")
+	}
+}
+
+func TestHandleCreate(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	resp := app.HTMXRequest("POST", "/backups", "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404 without a backup job, got %d", resp.StatusCode)
+	}
+
+	runner := jobs.NewRunner(app.DB)
+	runner.Register(jobs.Job{Name: backups.JobName, Run: func(ctx context.Context) error {
+		_, err := backups.Create(ctx, app.DB, app.Backup, time.Now())
+		return err
+	}})
+
+	resp = app.HTMXRequest("POST", "/backups", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	runner.RunDue(context.Background())
+	if list, _ := backups.List(app.Backup.Dir); len(list) != 1 {
+		t.Errorf("expected the runner to take a backup, got %+v", list)
+	}
+}
+
+func TestHandleDownload(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	b, _ := backups.Create(context.Background(), app.DB, app.Backup, time.Now())
+
+	resp := app.Request("GET", "/backups/"+b.Name, "")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+	if !strings.Contains(resp.Header.Get("Content-Disposition"), b.Name) {
+		t.Errorf("expected an attachment, got %q", resp.Header.Get("Content-Disposition"))
+	}
+	if body := testutil.ReadBody(t, resp); !strings.HasPrefix(body, "SQLite format 3") {
+		t.Error("expected the database file")
+	}
+
+	for _, name := range []string{"phobos-20200101-000000.db", "..%2Fphobos.db", "notes.txt"} {
+		if resp := app.Request("GET", "/backups/"+name, ""); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s: expected status 404, got %d", name, resp.StatusCode)
+		}
+	}
+}
+
+// hasExercise reports whether the database at path has the named exercise
+func hasExercise(t *testing.T, path, name string) bool {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("failed to open restored database: %v", err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM exercises WHERE name = ?", name).Scan(&count); err != nil {
+		t.Fatalf("failed to read restored database: %v", err)
+	}
+	return count > 0
+}
@@ -0,0 +1,22 @@
+package backups
+
+import "time"
+
+// JobName is the background job that takes scheduled backups
+const JobName = "backups.create"
+
+// Config says where backups go and how many are kept
+type Config struct {
+	Dir      string
+	Keep     int    // Newest backups kept after each backup; 0 keeps them all
+	Gzip     bool   // Compress new backups
+	Schedule string // Cron expression for the backup job; empty to only back up on demand
+}
+
+// Backup is a backup file in the backup directory
+type Backup struct {
+	Name       string
+	Size       int64
+	CreatedAt  time.Time
+	Compressed bool
+}
@@ -0,0 +1,22 @@
+package backups
+
+import "github.com/gofiber/fiber/v2"
+
+// configKey is the fiber.Locals key holding the backup Config
+const configKey = "backupConfig"
+
+// RegisterRoutes sets up the backups admin page for backups kept as cfg says
+func RegisterRoutes(app *fiber.App, cfg Config) {
+	group := app.Group("/backups", func(c *fiber.Ctx) error {
+		c.Locals(configKey, cfg)
+		return c.Next()
+	})
+	group.Get("/", HandleIndex)
+	group.Post("/", HandleCreate)
+	group.Get("/:name", HandleDownload)
+}
+
+// getConfig returns the backup Config for a request
+func getConfig(c *fiber.Ctx) Config {
+	return c.Locals(configKey).(Config)
+}
@@ -0,0 +1,197 @@
+package calendar
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"phobos/internal/features/routines"
+	"phobos/internal/shared/dates"
+)
+
+const (
+	// feedHistoryDays is how far back the feed publishes finished workouts
+	feedHistoryDays = 365
+	// feedScheduleDays is how far ahead the feed publishes scheduled sessions
+	feedScheduleDays = 84
+)
+
+// GetFeedToken returns the secret token in the feed's URL
+func GetFeedToken(db *sql.DB) (string, error) {
+	var token string
+	if err := db.QueryRow(`SELECT feed_token FROM calendar_settings WHERE id = 1`).Scan(&token); err != nil {
+		return "", fmt.Errorf("failed to get feed token: %w", err)
+	}
+	return token, nil
+}
+
+// ResetFeedToken replaces the feed's token, so the old URL stops working
+func ResetFeedToken(db *sql.DB) error {
+	_, err := db.Exec(`UPDATE calendar_settings SET feed_token = lower(hex(randomblob(16))) WHERE id = 1`)
+	if err != nil {
+		return fmt.Errorf("failed to reset feed token: %w", err)
+	}
+	return nil
+}
+
+// ListFeedEvents returns the finished workouts of the past year and the
+// scheduled routine sessions of the coming weeks. Links are made absolute
+// with baseURL.
+func ListFeedEvents(db *sql.DB, now time.Time, baseURL string) ([]FeedEvent, error) {
+	events, err := finishedWorkoutEvents(db, dates.Day(now).AddDate(0, 0, -feedHistoryDays), baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled, err := routines.ListScheduled(db)
+	if err != nil {
+		return nil, err
+	}
+
+	today := dates.Day(now)
+	for _, r := range scheduled {
+		for _, s := range r.Schedule.Sessions(r.Templates, today, today.AddDate(0, 0, feedScheduleDays)) {
+			events = append(events, sessionEvent(r, s, baseURL))
+		}
+	}
+
+	return events, nil
+}
+
+// sessionEvent describes a scheduled session. Timed sessions use floating
+// times, so 7:00 stays 7:00 wherever the subscriber is.
+func sessionEvent(r routines.Routine, s routines.ScheduledSession, baseURL string) FeedEvent {
+	e := FeedEvent{
+		UID:         "routine-" + strconv.FormatInt(r.ID, 10) + "-" + s.Date.Format("20060102") + "@phobos",
+		Summary:     s.Template.Template.Name + " (" + r.Name + ")",
+		Description: "Scheduled session of " + r.Name,
+		URL:         baseURL + "/workouts/new?template_id=" + strconv.FormatInt(s.Template.TemplateID, 10),
+	}
+
+	if r.Schedule.IsAllDay() {
+		e.AllDay = true
+		e.Start = s.Date
+		e.End = s.Date.AddDate(0, 0, 1)
+		return e
+	}
+
+	clock, _ := time.Parse("15:04", r.Schedule.Time)
+	e.Floating = true
+	e.Start = s.Date.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute)
+	e.End = e.Start.Add(r.Schedule.Duration)
+	return e
+}
+
+// finishedWorkoutEvents returns an event per finished workout dated on or
+// after from, described by its totals, a line per exercise and its notes
+func finishedWorkoutEvents(db *sql.DB, from time.Time, baseURL string) ([]FeedEvent, error) {
+	rows, err := db.Query(`
+		SELECT w.id, w.name, w.date, COALESCE(w.notes, ''), w.started_at, w.ended_at,
+		       ws.total_volume, ws.set_count, ws.pr_count
+		FROM workouts w
+		LEFT JOIN workout_summaries ws ON ws.workout_id = w.id
+		WHERE w.status = 'finished' AND w.date >= ?
+		ORDER BY w.date, w.id
+	`, from.Format(dates.Layout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feed workouts: %w", err)
+	}
+	defer rows.Close()
+
+	var events []FeedEvent
+	var notes []string
+	index := make(map[int64]int)
+	for rows.Next() {
+		var id int64
+		var name, note string
+		var date time.Time
+		var startedAt, endedAt sql.NullTime
+		var volume sql.NullFloat64
+		var sets, prs sql.NullInt64
+		if err := rows.Scan(&id, &name, &date, &note, &startedAt, &endedAt, &volume, &sets, &prs); err != nil {
+			return nil, fmt.Errorf("failed to scan feed workout: %w", err)
+		}
+
+		e := FeedEvent{
+			UID:     "workout-" + strconv.FormatInt(id, 10) + "@phobos",
+			Summary: name,
+			URL:     baseURL + "/workouts/" + strconv.FormatInt(id, 10),
+		}
+		if startedAt.Valid && endedAt.Valid && endedAt.Time.After(startedAt.Time) {
+			e.Start, e.End = startedAt.Time, endedAt.Time
+		} else {
+			e.AllDay = true
+			e.Start, e.End = date, date.AddDate(0, 0, 1)
+		}
+
+		if sets.Valid {
+			e.Description = fmt.Sprintf("%d sets, %s lbs volume", sets.Int64, strconv.FormatFloat(volume.Float64, 'f', -1, 64))
+			if prs.Int64 > 0 {
+				e.Description += fmt.Sprintf(", %d PRs", prs.Int64)
+			}
+		}
+
+		index[id] = len(events)
+		events = append(events, e)
+		notes = append(notes, note)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := addExerciseLines(db, from, events, index); err != nil {
+		return nil, err
+	}
+	for i, note := range notes {
+		if note != "" {
+			events[i].Description = strings.TrimPrefix(events[i].Description+"\n\n"+note, "\n\n")
+		}
+	}
+	return events, nil
+}
+
+// addExerciseLines appends a line per exercise, such as "Squat: 3 sets, top
+// 225 lbs", to the description of each workout event
+func addExerciseLines(db *sql.DB, from time.Time, events []FeedEvent, index map[int64]int) error {
+	rows, err := db.Query(`
+		SELECT we.workout_id, e.name, COUNT(ls.id), COALESCE(MAX(ls.weight), 0)
+		FROM workout_exercises we
+		JOIN workouts w ON w.id = we.workout_id
+		JOIN exercises e ON e.id = we.exercise_id
+		LEFT JOIN logged_sets ls ON ls.workout_exercise_id = we.id AND ls.set_type = 'working'
+		WHERE w.status = 'finished' AND w.date >= ?
+		GROUP BY we.id
+		ORDER BY we.workout_id, we.position
+	`, from.Format(dates.Layout))
+	if err != nil {
+		return fmt.Errorf("failed to list feed exercises: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var workoutID int64
+		var name string
+		var sets int
+		var top float64
+		if err := rows.Scan(&workoutID, &name, &sets, &top); err != nil {
+			return fmt.Errorf("failed to scan feed exercise: %w", err)
+		}
+		i, ok := index[workoutID]
+		if !ok {
+			continue
+		}
+
+		line := fmt.Sprintf("%s: %d sets", name, sets)
+		if top > 0 {
+			line += ", top " + strconv.FormatFloat(top, 'f', -1, 64) + " lbs"
+		}
+		if events[i].Description != "" {
+			events[i].Description += "\n"
+		}
+		events[i].Description += line
+	}
+
+	return rows.Err()
+}
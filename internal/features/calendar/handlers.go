@@ -0,0 +1,133 @@
+package calendar
+
+import (
+	"crypto/subtle"
+	"strings"
+	"time"
+
+	"phobos/internal/features/workouts"
+	"phobos/internal/shared/dates"
+	"phobos/internal/shared/htmx"
+	"phobos/internal/shared/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HandleMonth displays a month of workouts with the weekly streaks
+func HandleMonth(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	now := time.Now()
+	start := now
+	if m := c.Query("month"); m != "" {
+		parsed, err := time.Parse("2006-01", m)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid month")
+		}
+		start = parsed
+	}
+
+	month, err := GetMonth(db, start, now)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load calendar")
+	}
+
+	streaks, err := GetStreaks(db, now)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load streaks")
+	}
+
+	return htmx.Render(c, MonthPage(month, streaks))
+}
+
+// HandleYear displays a year heatmap coloured by training volume
+func HandleYear(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	now := time.Now()
+	year := c.QueryInt("year", now.Year())
+	if year < 1 || year > 9999 {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid year")
+	}
+
+	heatmap, err := GetYear(db, year)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load calendar")
+	}
+
+	streaks, err := GetStreaks(db, now)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load streaks")
+	}
+
+	return htmx.Render(c, YearPage(heatmap, streaks))
+}
+
+// HandleDay lists the workouts on a single day, as a fragment for HTMX requests
+func HandleDay(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	date, err := time.Parse(dates.Layout, c.Params("date"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid date")
+	}
+
+	dayWorkouts, err := workouts.ListOnDate(db, date)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load workouts")
+	}
+
+	if htmx.IsHTMX(c) {
+		return htmx.Render(c, DayWorkouts(date, dayWorkouts))
+	}
+	return htmx.Render(c, DayPage(date, dayWorkouts))
+}
+
+// HandleSubscribe shows the secret URL of the iCalendar feed
+func HandleSubscribe(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	token, err := GetFeedToken(db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load feed")
+	}
+
+	feedURL := c.BaseURL() + feedPath(token)
+	webcalURL := "webcal://" + strings.TrimPrefix(strings.TrimPrefix(feedURL, "https://"), "http://")
+	return htmx.Render(c, SubscribePage(feedURL, webcalURL))
+}
+
+// HandleResetFeed gives the feed a new secret URL, cutting off anyone
+// subscribed to the old one
+func HandleResetFeed(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	if err := ResetFeedToken(db); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to reset feed")
+	}
+
+	return htmx.Refresh(c)
+}
+
+// HandleFeed serves finished workouts and scheduled routine sessions as an
+// iCalendar feed for calendar apps to subscribe to
+func HandleFeed(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	token, err := GetFeedToken(db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load feed")
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Params("token")), []byte(token)) != 1 {
+		return c.Status(fiber.StatusNotFound).SendString("Feed not found")
+	}
+
+	now := time.Now()
+	events, err := ListFeedEvents(db, now, c.BaseURL())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load feed")
+	}
+
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	return c.SendString(WriteICal(events, now))
+}
@@ -0,0 +1,295 @@
+package calendar_test
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"phobos/internal/features/calendar"
+	"phobos/internal/features/exercises"
+	"phobos/internal/features/routines"
+	"phobos/internal/features/templates"
+	"phobos/internal/features/workouts"
+	"phobos/internal/testutil"
+)
+
+func TestHandleMonth(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	date := time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC)
+	workoutID, _ := workouts.Create(app.DB, "Leg Day", date, nil)
+	workouts.Finish(app.DB, workoutID)
+
+	resp := app.Request("GET", "/calendar?month=2024-03", "")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "March 2024") {
+		t.Error("expected page to show the requested month")
+	}
+	if !strings.Contains(body, "/calendar/day/2024-03-12") {
+		t.Error("expected the workout day to link to its workouts")
+	}
+	if !strings.Contains(body, "/calendar?month=2024-02") || !strings.Contains(body, "/calendar?month=2024-04") {
+		t.Error("expected links to the previous and next months")
+	}
+}
+
+func TestHandleMonth_InvalidMonth(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	resp := app.Request("GET", "/calendar?month=march", "")
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestHandleDay_Fragment(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	date := time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC)
+	finishedID, _ := workouts.Create(app.DB, "Leg Day", date, nil)
+	workouts.Finish(app.DB, finishedID)
+	workouts.Create(app.DB, "Evening Arms", date, nil)
+	workouts.Create(app.DB, "Other Day", date.AddDate(0, 0, 1), nil)
+
+	resp := app.HTMXRequest("GET", "/calendar/day/2024-03-12", "")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	body := testutil.ReadBody(t, resp)
+	if strings.Contains(body, "<html") {
+		t.Error("expected a fragment for HTMX requests")
+	}
+	if !strings.Contains(body, "Leg Day") || !strings.Contains(body, "Evening Arms") {
+		t.Error("expected both workouts on the day")
+	}
+	if strings.Contains(body, "Other Day") {
+		t.Error("expected workouts from other days to be excluded")
+	}
+}
+
+func TestHandleYear(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	exerciseID, _ := exercises.Create(app.DB, "Squat")
+	date := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	workoutID, _ := workouts.Create(app.DB, "Legs", date, nil)
+	weID, _ := workouts.AddExercise(app.DB, workoutID, exerciseID)
+	workouts.AddSet(app.DB, weID, 5, 225)
+	workouts.Finish(app.DB, workoutID)
+
+	resp := app.Request("GET", "/calendar/year?year=2024", "")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "/calendar/day/2024-06-03") {
+		t.Error("expected the heatmap to link the workout day")
+	}
+	if !strings.Contains(body, "bg-green-800") {
+		t.Error("expected the only training day to have the highest level")
+	}
+}
+
+func TestComputeStreaks(t *testing.T) {
+	t.Parallel()
+
+	monday := func(offset int) time.Time {
+		return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, 7*offset)
+	}
+	now := monday(10).AddDate(0, 0, 2) // Wednesday of week 10
+
+	tests := []struct {
+		name    string
+		weeks   []time.Time
+		current int
+		longest int
+	}{
+		{"no workouts", nil, 0, 0},
+		{"ongoing this week", []time.Time{monday(8), monday(9), monday(10)}, 3, 3},
+		{"last week still counts", []time.Time{monday(8), monday(9)}, 2, 2},
+		{"broken streak", []time.Time{monday(1), monday(2), monday(3), monday(8)}, 0, 3},
+		{"gap resets run", []time.Time{monday(5), monday(7), monday(8), monday(9), monday(10)}, 4, 4},
+	}
+
+	for _, tt := range tests {
+		got := calendar.ComputeStreaks(tt.weeks, now)
+		if got.Current != tt.current || got.Longest != tt.longest {
+			t.Errorf("%s: expected current=%d longest=%d, got current=%d longest=%d",
+				tt.name, tt.current, tt.longest, got.Current, got.Longest)
+		}
+	}
+}
+
+func TestHandleFeed(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	exerciseID, _ := exercises.Create(app.DB, "Squat")
+	workoutID, _ := workouts.Create(app.DB, "Legs, heavy", time.Now(), nil)
+	weID, _ := workouts.AddExercise(app.DB, workoutID, exerciseID)
+	workouts.AddSet(app.DB, weID, 5, 225)
+	workouts.AddSet(app.DB, weID, 5, 235)
+	workouts.Finish(app.DB, workoutID)
+	workouts.Create(app.DB, "Unfinished", time.Now(), nil)
+
+	routineID, _ := routines.Create(app.DB, "Full Body")
+	templateID, _ := templates.Create(app.DB, "Day A")
+	routines.AddTemplate(app.DB, routineID, templateID)
+	start := time.Now().UTC()
+	routines.SaveSchedule(app.DB, routines.Schedule{
+		RoutineID: routineID,
+		StartDate: start,
+		Weekdays:  []time.Weekday{start.AddDate(0, 0, 1).Weekday()},
+		Time:      "07:30",
+		Duration:  time.Hour,
+	})
+
+	token, _ := calendar.GetFeedToken(app.DB)
+	resp := app.Request("GET", "/calendar/feed/"+token+".ics", "")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+		t.Errorf("expected a calendar content type, got %q", ct)
+	}
+
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "SUMMARY:Legs\\, heavy\r\n") {
+		t.Error("expected the finished workout with its summary escaped")
+	}
+	if !strings.Contains(body, "Squat: 2 sets\\, top 235 lbs") {
+		t.Error("expected the workout description to list its exercises")
+	}
+	if strings.Contains(body, "Unfinished") {
+		t.Error("expected unfinished workouts to be left out")
+	}
+	if !strings.Contains(body, "SUMMARY:Day A (Full Body)") {
+		t.Error("expected the scheduled session")
+	}
+	session := start.AddDate(0, 0, 1).Format("20060102")
+	if !strings.Contains(body, "DTSTART:"+session+"T073000\r\n") {
+		t.Error("expected the session at a floating 07:30")
+	}
+}
+
+func TestDeletingRoutineRemovesSchedule(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	routineID, _ := routines.Create(app.DB, "Full Body")
+	templateID, _ := templates.Create(app.DB, "Day A")
+	routines.AddTemplate(app.DB, routineID, templateID)
+	routines.SaveSchedule(app.DB, routines.Schedule{
+		RoutineID: routineID,
+		StartDate: time.Now().UTC(),
+		Weekdays:  []time.Weekday{time.Monday},
+		Duration:  time.Hour,
+	})
+
+	resp := app.HTMXRequest("DELETE", "/routines/"+strconv.FormatInt(routineID, 10), "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	if s, _ := routines.GetSchedule(app.DB, routineID); s != nil {
+		t.Error("expected the schedule to be deleted with its routine")
+	}
+	token, _ := calendar.GetFeedToken(app.DB)
+	if body := testutil.ReadBody(t, app.Request("GET", "/calendar/feed/"+token+".ics", "")); strings.Contains(body, "Day A") {
+		t.Error("expected the feed to drop the deleted routine's sessions")
+	}
+}
+
+func TestHandleFeed_WrongToken(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	resp := app.Request("GET", "/calendar/feed/not-the-token.ics", "")
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestHandleResetFeed(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	old, _ := calendar.GetFeedToken(app.DB)
+
+	resp := app.Request("GET", "/calendar/subscribe", "")
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "/calendar/feed/"+old+".ics") || !strings.Contains(body, "webcal://") {
+		t.Error("expected the subscribe page to show the feed URL")
+	}
+
+	resp = app.HTMXRequest("POST", "/calendar/subscribe/reset", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	if resp := app.Request("GET", "/calendar/feed/"+old+".ics", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected the old URL to stop working, got %d", resp.StatusCode)
+	}
+	token, _ := calendar.GetFeedToken(app.DB)
+	if resp := app.Request("GET", "/calendar/feed/"+token+".ics", ""); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected the new URL to work, got %d", resp.StatusCode)
+	}
+}
+
+func TestWriteICal_Folding(t *testing.T) {
+	t.Parallel()
+
+	events := []calendar.FeedEvent{{
+		UID:         "workout-1@phobos",
+		Summary:     "Legs",
+		Description: strings.Repeat("Überkopfdrücken; ", 10),
+		Start:       time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC),
+		End:         time.Date(2024, 3, 13, 0, 0, 0, 0, time.UTC),
+		AllDay:      true,
+	}}
+
+	out := calendar.WriteICal(events, time.Date(2024, 3, 12, 18, 0, 0, 0, time.UTC))
+
+	if !strings.Contains(out, "DTSTART;VALUE=DATE:20240312\r\n") {
+		t.Error("expected an all-day start date")
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("expected lines of at most 75 octets, got %d: %q", len(line), line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("expected lines to be split between characters: %q", line)
+		}
+	}
+	unfolded := strings.ReplaceAll(out, "\r\n ", "")
+	if !strings.Contains(unfolded, "DESCRIPTION:"+strings.Repeat("Überkopfdrücken\\; ", 10)) {
+		t.Error("expected the description to unfold to its escaped value")
+	}
+}
@@ -0,0 +1,84 @@
+package calendar
+
+import (
+	"strings"
+	"time"
+)
+
+// icalLineLimit is the longest a content line may be, in octets, before it
+// has to be folded (RFC 5545 section 3.1)
+const icalLineLimit = 75
+
+// WriteICal encodes events as an iCalendar document
+func WriteICal(events []FeedEvent, now time.Time) string {
+	var b strings.Builder
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:-//Phobos//Workouts//EN")
+	writeLine(&b, "CALSCALE:GREGORIAN")
+	writeLine(&b, "METHOD:PUBLISH")
+	writeLine(&b, "X-WR-CALNAME:Phobos workouts")
+
+	stamp := now.UTC().Format("20060102T150405Z")
+	for _, e := range events {
+		writeLine(&b, "BEGIN:VEVENT")
+		writeLine(&b, "UID:"+escapeText(e.UID))
+		writeLine(&b, "DTSTAMP:"+stamp)
+		switch {
+		case e.AllDay:
+			writeLine(&b, "DTSTART;VALUE=DATE:"+e.Start.Format("20060102"))
+			writeLine(&b, "DTEND;VALUE=DATE:"+e.End.Format("20060102"))
+		case e.Floating:
+			writeLine(&b, "DTSTART:"+e.Start.Format("20060102T150405"))
+			writeLine(&b, "DTEND:"+e.End.Format("20060102T150405"))
+		default:
+			writeLine(&b, "DTSTART:"+e.Start.UTC().Format("20060102T150405Z"))
+			writeLine(&b, "DTEND:"+e.End.UTC().Format("20060102T150405Z"))
+		}
+		writeLine(&b, "SUMMARY:"+escapeText(e.Summary))
+		if e.Description != "" {
+			writeLine(&b, "DESCRIPTION:"+escapeText(e.Description))
+		}
+		if e.URL != "" {
+			writeLine(&b, "URL:"+e.URL)
+		}
+		writeLine(&b, "END:VEVENT")
+	}
+
+	writeLine(&b, "END:VCALENDAR")
+	return b.String()
+}
+
+// writeLine writes a content line ending in CRLF, folding it onto
+// continuation lines that start with a space. Lines are only split between
+// UTF-8 characters.
+func writeLine(b *strings.Builder, line string) {
+	limit := icalLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = icalLineLimit - 1 // The leading space counts toward the limit
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+func isRuneStart(c byte) bool {
+	return c&0xC0 != 0x80
+}
+
+// escapeText escapes a TEXT value (RFC 5545 section 3.3.11)
+func escapeText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
@@ -0,0 +1,70 @@
+package calendar
+
+import "time"
+
+// DaySummary aggregates the workouts on one calendar day
+type DaySummary struct {
+	Date       time.Time
+	InProgress int
+	Finished   int
+	Volume     float64 // Sum of reps x weight over finished workouts
+}
+
+// HasWorkouts returns true if any workout took place on the day
+func (d DaySummary) HasWorkouts() bool {
+	return d.InProgress > 0 || d.Finished > 0
+}
+
+// CalendarDay is one cell of the month grid
+type CalendarDay struct {
+	DaySummary
+	InMonth bool
+	IsToday bool
+}
+
+// Month is a Monday-first grid of weeks covering a calendar month
+type Month struct {
+	Start time.Time // First day of the month
+	Weeks [][]CalendarDay
+}
+
+// Prev returns the first day of the previous month
+func (m Month) Prev() time.Time {
+	return m.Start.AddDate(0, -1, 0)
+}
+
+// Next returns the first day of the next month
+func (m Month) Next() time.Time {
+	return m.Start.AddDate(0, 1, 0)
+}
+
+// HeatmapCell is one day of the year heatmap
+type HeatmapCell struct {
+	DaySummary
+	InYear bool
+	Level  int // 0 (no training) to 4 (highest volume)
+}
+
+// Year is a heatmap of a calendar year as Monday-first week columns
+type Year struct {
+	Year  int
+	Weeks [][]HeatmapCell
+}
+
+// Streaks counts consecutive weeks with at least one finished workout
+type Streaks struct {
+	Current int
+	Longest int
+}
+
+// FeedEvent is one event in the iCalendar feed
+type FeedEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	URL         string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool // Start and End are dates; End is the day after the last day
+	Floating    bool // Start and End are wall-clock times in whatever zone the subscriber is in
+}
@@ -0,0 +1,197 @@
+package calendar
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"phobos/internal/shared/dates"
+)
+
+// heatmapLevels is the number of non-empty intensity levels on the year heatmap
+const heatmapLevels = 4
+
+// GetDaySummaries returns per-day workout counts and volume between from and to (inclusive)
+func GetDaySummaries(db *sql.DB, from, to time.Time) (map[string]DaySummary, error) {
+	rows, err := db.Query(`
+		SELECT w.date,
+		       SUM(CASE WHEN w.status = 'in_progress' THEN 1 ELSE 0 END),
+		       SUM(CASE WHEN w.status = 'finished' THEN 1 ELSE 0 END),
+		       COALESCE(SUM(CASE WHEN w.status = 'finished' THEN v.volume ELSE 0 END), 0)
+		FROM workouts w
+		LEFT JOIN (
+		    SELECT we.workout_id, SUM(ls.reps * ls.weight) AS volume
+		    FROM workout_exercises we
+		    JOIN logged_sets ls ON ls.workout_exercise_id = we.id
+		    WHERE ls.set_type = 'working'
+		    GROUP BY we.workout_id
+		) v ON v.workout_id = w.id
+		WHERE w.date BETWEEN ? AND ?
+		GROUP BY w.date
+	`, from.Format(dates.Layout), to.Format(dates.Layout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get day summaries: %w", err)
+	}
+	defer rows.Close()
+
+	days := make(map[string]DaySummary)
+	for rows.Next() {
+		var d DaySummary
+		if err := rows.Scan(&d.Date, &d.InProgress, &d.Finished, &d.Volume); err != nil {
+			return nil, fmt.Errorf("failed to scan day summary: %w", err)
+		}
+		days[d.Date.Format(dates.Layout)] = d
+	}
+
+	return days, rows.Err()
+}
+
+// GetMonth builds the month grid containing start, marking today
+func GetMonth(db *sql.DB, start, today time.Time) (*Month, error) {
+	first := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1)
+	gridStart := dates.WeekStart(first)
+	gridEnd := dates.WeekStart(last).AddDate(0, 0, 6)
+
+	days, err := GetDaySummaries(db, gridStart, gridEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	month := &Month{Start: first}
+	todayKey := dates.Day(today).Format(dates.Layout)
+	for weekStart := gridStart; !weekStart.After(gridEnd); weekStart = weekStart.AddDate(0, 0, 7) {
+		week := make([]CalendarDay, 7)
+		for i := range week {
+			date := weekStart.AddDate(0, 0, i)
+			key := date.Format(dates.Layout)
+			summary := days[key]
+			summary.Date = date
+			week[i] = CalendarDay{
+				DaySummary: summary,
+				InMonth:    date.Month() == first.Month(),
+				IsToday:    key == todayKey,
+			}
+		}
+		month.Weeks = append(month.Weeks, week)
+	}
+
+	return month, nil
+}
+
+// GetYear builds the volume heatmap for a calendar year
+func GetYear(db *sql.DB, year int) (*Year, error) {
+	first := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	last := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	days, err := GetDaySummaries(db, first, last)
+	if err != nil {
+		return nil, err
+	}
+
+	var maxVolume float64
+	for _, d := range days {
+		if d.Volume > maxVolume {
+			maxVolume = d.Volume
+		}
+	}
+
+	y := &Year{Year: year}
+	gridEnd := dates.WeekStart(last).AddDate(0, 0, 6)
+	for weekStart := dates.WeekStart(first); !weekStart.After(gridEnd); weekStart = weekStart.AddDate(0, 0, 7) {
+		week := make([]HeatmapCell, 7)
+		for i := range week {
+			date := weekStart.AddDate(0, 0, i)
+			summary := days[date.Format(dates.Layout)]
+			summary.Date = date
+			week[i] = HeatmapCell{
+				DaySummary: summary,
+				InYear:     date.Year() == year,
+				Level:      heatmapLevel(summary, maxVolume),
+			}
+		}
+		y.Weeks = append(y.Weeks, week)
+	}
+
+	return y, nil
+}
+
+// heatmapLevel buckets a day's volume relative to the busiest day of the year.
+// Finished workouts always get at least level 1, even with no weighted volume.
+func heatmapLevel(d DaySummary, maxVolume float64) int {
+	if d.Finished == 0 {
+		return 0
+	}
+	if maxVolume == 0 || d.Volume == 0 {
+		return 1
+	}
+	level := int(d.Volume / maxVolume * heatmapLevels)
+	if level < 1 {
+		level = 1
+	}
+	if level > heatmapLevels {
+		level = heatmapLevels
+	}
+	return level
+}
+
+// GetStreaks returns the current and longest runs of consecutive weeks with a finished workout
+func GetStreaks(db *sql.DB, now time.Time) (Streaks, error) {
+	rows, err := db.Query(`
+		SELECT DISTINCT date(date, 'weekday 0', '-6 days') AS week_start
+		FROM workouts
+		WHERE status = 'finished'
+		ORDER BY week_start ASC
+	`)
+	if err != nil {
+		return Streaks{}, fmt.Errorf("failed to get training weeks: %w", err)
+	}
+	defer rows.Close()
+
+	var weeks []time.Time
+	for rows.Next() {
+		var week string
+		if err := rows.Scan(&week); err != nil {
+			return Streaks{}, fmt.Errorf("failed to scan training week: %w", err)
+		}
+		start, err := time.Parse(dates.Layout, week)
+		if err != nil {
+			return Streaks{}, fmt.Errorf("failed to parse training week: %w", err)
+		}
+		weeks = append(weeks, start)
+	}
+	if err := rows.Err(); err != nil {
+		return Streaks{}, err
+	}
+
+	return ComputeStreaks(weeks, now), nil
+}
+
+// ComputeStreaks derives streaks from ascending, distinct Monday week starts.
+// The current streak stays alive through the current week even before its
+// first workout, so it only breaks once a whole week is missed.
+func ComputeStreaks(weeks []time.Time, now time.Time) Streaks {
+	var s Streaks
+	run := 0
+	for i, w := range weeks {
+		if i > 0 && w.Sub(weeks[i-1]) == 7*24*time.Hour {
+			run++
+		} else {
+			run = 1
+		}
+		if run > s.Longest {
+			s.Longest = run
+		}
+	}
+
+	if len(weeks) == 0 {
+		return s
+	}
+	thisWeek := dates.WeekStart(now)
+	lastWeek := weeks[len(weeks)-1]
+	if lastWeek.Equal(thisWeek) || lastWeek.Equal(thisWeek.AddDate(0, 0, -7)) {
+		s.Current = run
+	}
+
+	return s
+}
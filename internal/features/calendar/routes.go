@@ -0,0 +1,13 @@
+package calendar
+
+import "github.com/gofiber/fiber/v2"
+
+// RegisterRoutes sets up calendar routes
+func RegisterRoutes(app *fiber.App) {
+	app.Get("/calendar", HandleMonth)
+	app.Get("/calendar/year", HandleYear)
+	app.Get("/calendar/day/:date", HandleDay)
+	app.Get("/calendar/subscribe", HandleSubscribe)
+	app.Post("/calendar/subscribe/reset", HandleResetFeed)
+	app.Get("/calendar/feed/:token.ics", HandleFeed)
+}
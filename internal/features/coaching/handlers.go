@@ -0,0 +1,295 @@
+package coaching
+
+import (
+	"strconv"
+	"strings"
+
+	"phobos/internal/features/exercises"
+	"phobos/internal/features/plates"
+	"phobos/internal/features/templates"
+	"phobos/internal/features/workouts"
+	"phobos/internal/shared/htmx"
+	"phobos/internal/shared/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// portalWorkoutLimit is how many recent workouts the coach portal lists
+const portalWorkoutLimit = 30
+
+// HandleIndex lists the athlete's coaches with a form to invite another
+func HandleIndex(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	coaches, err := ListCoaches(db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load coaches")
+	}
+
+	return htmx.Render(c, CoachesPage(coaches))
+}
+
+// HandleCreate adds a coach and shows the link to send them. The link is
+// only shown once.
+func HandleCreate(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	name := strings.TrimSpace(c.FormValue("name"))
+	if name == "" {
+		return c.Status(fiber.StatusBadRequest).SendString("Name is required")
+	}
+
+	_, token, err := CreateCoach(db, name)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to add coach")
+	}
+
+	coaches, err := ListCoaches(db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load coaches")
+	}
+
+	return htmx.Render(c, CoachList(coaches, name, c.BaseURL()+portalPath(token)))
+}
+
+// HandleRevoke stops a coach's link from working
+func HandleRevoke(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	if err := RevokeCoach(db, id); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to revoke coach")
+	}
+
+	return htmx.Refresh(c)
+}
+
+// portalCoach returns the coach the link's token belongs to, or a 404 error
+// for unknown and revoked links
+func portalCoach(c *fiber.Ctx) (*Coach, error) {
+	coach, err := GetCoachByToken(middleware.GetDB(c), c.Params("token"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to load coach")
+	}
+	if coach == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Link not found")
+	}
+	return coach, nil
+}
+
+// HandlePortal shows a coach the athlete's recent workouts, what the coach
+// has assigned and forms to assign more
+func HandlePortal(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	coach, err := portalCoach(c)
+	if err != nil {
+		return err
+	}
+
+	recent, err := workouts.ListRecent(db, portalWorkoutLimit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load workouts")
+	}
+
+	assignments, err := ListAssignments(db, coach.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load assignments")
+	}
+
+	allExercises, err := exercises.ListAll(db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load exercises")
+	}
+
+	allTemplates, err := templates.ListAll(db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load templates")
+	}
+
+	token := c.Params("token")
+	return htmx.Render(c, PortalPage(coach, token, recent, assignments, allExercises, allTemplates))
+}
+
+// portalWorkout returns the finished workout named in the path. In-progress
+// workouts aren't shown to coaches.
+func portalWorkout(c *fiber.Ctx) (*workouts.Workout, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid ID")
+	}
+
+	workout, err := workouts.GetByID(middleware.GetDB(c), id)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to load workout")
+	}
+	if workout == nil || !workout.IsFinished() {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Workout not found")
+	}
+	return workout, nil
+}
+
+// HandlePortalWorkout shows a coach a finished workout, read-only, with its comments
+func HandlePortalWorkout(c *fiber.Ctx) error {
+	if _, err := portalCoach(c); err != nil {
+		return err
+	}
+
+	workout, err := portalWorkout(c)
+	if err != nil {
+		return err
+	}
+
+	kit, err := plates.GetKit(middleware.GetDB(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load plates")
+	}
+
+	return htmx.Render(c, PortalWorkoutPage(c.Params("token"), workout, kit))
+}
+
+// HandleComment adds a coach's comment to a workout and notifies the athlete
+func HandleComment(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	coach, err := portalCoach(c)
+	if err != nil {
+		return err
+	}
+
+	workout, err := portalWorkout(c)
+	if err != nil {
+		return err
+	}
+
+	body := strings.TrimSpace(c.FormValue("body"))
+	if body == "" {
+		return c.Status(fiber.StatusBadRequest).SendString("Comment is required")
+	}
+
+	if _, err := AddComment(db, coach, workout.ID, workout.Name, body); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to add comment")
+	}
+
+	comments, err := workouts.GetComments(db, workout.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load comments")
+	}
+
+	return htmx.Render(c, workouts.Comments(comments))
+}
+
+// HandleAssignTemplate creates a template the coach wrote in the athlete's
+// account. Exercises are submitted as rows of exercise_id, target_sets and
+// target_reps; rows without an exercise are skipped.
+func HandleAssignTemplate(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	coach, err := portalCoach(c)
+	if err != nil {
+		return err
+	}
+
+	name := strings.TrimSpace(c.FormValue("name"))
+	if name == "" {
+		return c.Status(fiber.StatusBadRequest).SendString("Name is required")
+	}
+
+	exerciseIDs := formValues(c, "exercise_id")
+	sets := formValues(c, "target_sets")
+	reps := formValues(c, "target_reps")
+	if len(sets) != len(exerciseIDs) || len(reps) != len(exerciseIDs) {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid exercises")
+	}
+
+	var program []ProgramExercise
+	for i, raw := range exerciseIDs {
+		if raw == "" {
+			continue
+		}
+		exerciseID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid exercise ID")
+		}
+		exercise, err := exercises.GetByID(db, exerciseID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString("Failed to load exercise")
+		}
+		if exercise == nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid exercise ID")
+		}
+		targetSets, err := strconv.Atoi(sets[i])
+		if err != nil || targetSets < 1 {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid target sets")
+		}
+		targetReps, err := strconv.Atoi(reps[i])
+		if err != nil || targetReps < 1 {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid target reps")
+		}
+		program = append(program, ProgramExercise{ExerciseID: exerciseID, TargetSets: targetSets, TargetReps: targetReps})
+	}
+	if len(program) == 0 {
+		return c.Status(fiber.StatusBadRequest).SendString("Add at least one exercise")
+	}
+
+	if _, err := AssignTemplate(db, coach, name, strings.TrimSpace(c.FormValue("note")), program); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to assign template")
+	}
+
+	return htmx.Refresh(c)
+}
+
+// HandleAssignRoutine creates a routine of existing templates in the
+// athlete's account, in the order the templates were submitted, which is the
+// order the portal lists them in
+func HandleAssignRoutine(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	coach, err := portalCoach(c)
+	if err != nil {
+		return err
+	}
+
+	name := strings.TrimSpace(c.FormValue("name"))
+	if name == "" {
+		return c.Status(fiber.StatusBadRequest).SendString("Name is required")
+	}
+
+	var templateIDs []int64
+	for _, raw := range formValues(c, "template_id") {
+		templateID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid template ID")
+		}
+		t, err := templates.GetByID(db, templateID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString("Failed to load template")
+		}
+		if t == nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid template ID")
+		}
+		templateIDs = append(templateIDs, templateID)
+	}
+	if len(templateIDs) == 0 {
+		return c.Status(fiber.StatusBadRequest).SendString("Choose at least one template")
+	}
+
+	if _, err := AssignRoutine(db, coach, name, strings.TrimSpace(c.FormValue("note")), templateIDs); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to assign routine")
+	}
+
+	return htmx.Refresh(c)
+}
+
+// formValues returns every submitted value of a repeated form field
+func formValues(c *fiber.Ctx, key string) []string {
+	var values []string
+	for _, v := range c.Request().PostArgs().PeekMulti(key) {
+		values = append(values, string(v))
+	}
+	return values
+}
@@ -0,0 +1,262 @@
+package coaching_test
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"phobos/internal/features/coaching"
+	"phobos/internal/features/exercises"
+	"phobos/internal/features/notifications"
+	"phobos/internal/features/routines"
+	"phobos/internal/features/templates"
+	"phobos/internal/features/workouts"
+	"phobos/internal/testutil"
+)
+
+func TestHandleCreate_ShowsWorkingLink(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	resp := app.HTMXRequest("POST", "/coaches", "name=Sam")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	body := testutil.ReadBody(t, resp)
+	match := regexp.MustCompile(`/coach/([0-9a-f]{64})`).FindStringSubmatch(body)
+	if match == nil {
+		t.Fatalf("expected a coach link, got %s", body)
+	}
+
+	resp = app.Request("GET", "/coach/"+match[1], "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	if !strings.Contains(testutil.ReadBody(t, resp), "Hi, Sam") {
+		t.Error("expected the portal to greet the coach")
+	}
+
+	// The link is only shown once
+	if strings.Contains(testutil.ReadBody(t, app.Request("GET", "/coaches", "")), match[1]) {
+		t.Error("expected the coaches page not to show the link again")
+	}
+}
+
+func TestPortal_UnknownAndRevokedLinks(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	if resp := app.Request("GET", "/coach/not-a-token", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown link, got %d", resp.StatusCode)
+	}
+
+	id, token, _ := coaching.CreateCoach(app.DB, "Sam")
+	app.HTMXRequest("DELETE", "/coaches/"+strconv.FormatInt(id, 10), "")
+
+	if resp := app.Request("GET", "/coach/"+token, ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404 for a revoked link, got %d", resp.StatusCode)
+	}
+	if resp := app.HTMXRequest("POST", "/coach/"+token+"/routines", "name=Block"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404 assigning with a revoked link, got %d", resp.StatusCode)
+	}
+}
+
+func TestHandleAssignTemplate(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	_, token, _ := coaching.CreateCoach(app.DB, "Sam")
+	squatID, _ := exercises.Create(app.DB, "Squat")
+	benchID, _ := exercises.Create(app.DB, "Bench Press")
+
+	form := "name=Strength+A&note=Go+heavy" +
+		"&exercise_id=" + strconv.FormatInt(squatID, 10) + "&target_sets=5&target_reps=5" +
+		"&exercise_id=&target_sets=3&target_reps=10" +
+		"&exercise_id=" + strconv.FormatInt(benchID, 10) + "&target_sets=3&target_reps=8"
+	resp := app.HTMXRequest("POST", "/coach/"+token+"/templates", form)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", resp.StatusCode, testutil.ReadBody(t, resp))
+	}
+
+	list, _ := templates.ListAll(app.DB)
+	if len(list) != 1 || list[0].Name != "Strength A" {
+		t.Fatalf("expected template Strength A, got %+v", list)
+	}
+	tes, _ := templates.GetTemplateExercises(app.DB, list[0].ID)
+	if len(tes) != 2 || tes[0].ExerciseID != squatID || tes[0].TargetSets != 5 || tes[1].ExerciseID != benchID {
+		t.Errorf("expected squat then bench, got %+v", tes)
+	}
+
+	notes, _ := notifications.ListRecent(app.DB, 10)
+	if len(notes) != 1 || notes[0].Message != "Sam assigned you the template Strength A" || !notes[0].Unread() {
+		t.Errorf("expected an unread notification, got %+v", notes)
+	}
+
+	body := testutil.ReadBody(t, app.Request("GET", "/coach/"+token, ""))
+	if !strings.Contains(body, "Strength A") || !strings.Contains(body, "Go heavy") {
+		t.Error("expected the portal to list the assignment")
+	}
+}
+
+func TestHandleAssignTemplate_Invalid(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	_, token, _ := coaching.CreateCoach(app.DB, "Sam")
+	squatID, _ := exercises.Create(app.DB, "Squat")
+	squat := strconv.FormatInt(squatID, 10)
+
+	for _, form := range []string{
+		"exercise_id=" + squat + "&target_sets=5&target_reps=5",
+		"name=A&exercise_id=&target_sets=5&target_reps=5",
+		"name=A&exercise_id=999&target_sets=5&target_reps=5",
+		"name=A&exercise_id=" + squat + "&target_sets=0&target_reps=5",
+	} {
+		resp := app.HTMXRequest("POST", "/coach/"+token+"/templates", form)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%q: expected status 400, got %d", form, resp.StatusCode)
+		}
+	}
+
+	if list, _ := templates.ListAll(app.DB); len(list) != 0 {
+		t.Errorf("expected no templates, got %d", len(list))
+	}
+}
+
+func TestHandleAssignRoutine(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	_, token, _ := coaching.CreateCoach(app.DB, "Sam")
+	pushID, _ := templates.Create(app.DB, "Push")
+	pullID, _ := templates.Create(app.DB, "Pull")
+
+	form := "name=PPL&template_id=" + strconv.FormatInt(pullID, 10) + "&template_id=" + strconv.FormatInt(pushID, 10)
+	resp := app.HTMXRequest("POST", "/coach/"+token+"/routines", form)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	list, _ := routines.ListAll(app.DB)
+	if len(list) != 1 {
+		t.Fatalf("expected 1 routine, got %d", len(list))
+	}
+	rts, _ := routines.GetRoutineTemplates(app.DB, list[0].ID)
+	if len(rts) != 2 || rts[0].TemplateID != pullID || rts[1].TemplateID != pushID {
+		t.Errorf("expected pull then push, got %+v", rts)
+	}
+
+	notes, _ := notifications.ListRecent(app.DB, 10)
+	if len(notes) != 1 || notes[0].Link != "/routines/"+strconv.FormatInt(list[0].ID, 10) {
+		t.Errorf("expected a notification linking to the routine, got %+v", notes)
+	}
+}
+
+func TestHandleComment(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	_, token, _ := coaching.CreateCoach(app.DB, "Sam")
+	workoutID, _ := workouts.Create(app.DB, "Leg Day", time.Now(), nil)
+	path := "/coach/" + token + "/workouts/" + strconv.FormatInt(workoutID, 10)
+
+	// Workouts still in progress aren't shown to coaches
+	if resp := app.Request("GET", path, ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404 for an in-progress workout, got %d", resp.StatusCode)
+	}
+
+	workouts.Finish(app.DB, workoutID)
+	if resp := app.Request("GET", path, ""); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	resp := app.HTMXRequest("POST", path+"/comments", "body=Great+depth+on+squats")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	if !strings.Contains(testutil.ReadBody(t, resp), "Great depth on squats") {
+		t.Error("expected the comment in the response")
+	}
+
+	body := testutil.ReadBody(t, app.Request("GET", "/workouts/"+strconv.FormatInt(workoutID, 10), ""))
+	if !strings.Contains(body, "Coach Comments") || !strings.Contains(body, "Great depth on squats") {
+		t.Error("expected the athlete's workout page to show the comment")
+	}
+
+	if unread, _ := notifications.CountUnread(app.DB); unread != 1 {
+		t.Errorf("expected 1 unread notification, got %d", unread)
+	}
+
+	if resp := app.HTMXRequest("POST", path+"/comments", "body=+"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an empty comment, got %d", resp.StatusCode)
+	}
+
+	// Comments go with their workout
+	workouts.Delete(app.DB, workoutID)
+	var count int
+	app.DB.QueryRow(`SELECT COUNT(*) FROM workout_comments WHERE workout_id = ?`, workoutID).Scan(&count)
+	if count != 0 {
+		t.Errorf("expected the comments to be deleted with the workout, got %d", count)
+	}
+}
+
+func TestAssignTemplate_AllOrNothing(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	_, token, _ := coaching.CreateCoach(app.DB, "Sam")
+	coach, _ := coaching.GetCoachByToken(app.DB, token)
+	squatID, _ := exercises.Create(app.DB, "Squat")
+
+	// The second entry breaks the target check, so nothing is kept
+	_, err := coaching.AssignTemplate(app.DB, coach, "Strength A", "", []coaching.ProgramExercise{
+		{ExerciseID: squatID, TargetSets: 5, TargetReps: 5},
+		{ExerciseID: squatID, TargetSets: 0, TargetReps: 5},
+	})
+	if err == nil {
+		t.Fatal("expected an error for an invalid target")
+	}
+
+	if list, _ := templates.ListAll(app.DB); len(list) != 0 {
+		t.Errorf("expected no templates, got %d", len(list))
+	}
+	if notes, _ := notifications.ListRecent(app.DB, 10); len(notes) != 0 {
+		t.Errorf("expected no notifications, got %d", len(notes))
+	}
+}
+
+func TestDeletingTemplateRemovesAssignment(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	coachID, token, _ := coaching.CreateCoach(app.DB, "Sam")
+	coach, _ := coaching.GetCoachByToken(app.DB, token)
+	squatID, _ := exercises.Create(app.DB, "Squat")
+	templateID, _ := coaching.AssignTemplate(app.DB, coach, "Strength A", "", []coaching.ProgramExercise{
+		{ExerciseID: squatID, TargetSets: 5, TargetReps: 5},
+	})
+
+	resp := app.HTMXRequest("DELETE", "/templates/"+strconv.FormatInt(templateID, 10), "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	if list, _ := coaching.ListAssignments(app.DB, coachID); len(list) != 0 {
+		t.Errorf("expected the assignment to be deleted with its template, got %+v", list)
+	}
+	if tes, _ := templates.GetTemplateExercises(app.DB, templateID); len(tes) != 0 {
+		t.Errorf("expected the template's exercises to be deleted, got %d", len(tes))
+	}
+}
@@ -0,0 +1,50 @@
+package coaching
+
+import "time"
+
+// Coach is someone given access to this account through a private link
+type Coach struct {
+	ID        int64
+	Name      string
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// IsRevoked returns true once the coach's link no longer works
+func (c Coach) IsRevoked() bool {
+	return c.RevokedAt != nil
+}
+
+// Assignment is a template or routine a coach wrote for the athlete
+type Assignment struct {
+	ID         int64
+	CoachID    int64
+	TemplateID *int64
+	RoutineID  *int64
+	Name       string // Name of the template or routine; empty once it has been deleted
+	Note       string
+	AssignedAt time.Time
+}
+
+// Kind describes what was assigned
+func (a Assignment) Kind() string {
+	if a.RoutineID != nil {
+		return "Routine"
+	}
+	return "Template"
+}
+
+// Path returns the athlete's page for the assignment
+func (a Assignment) Path() string {
+	if a.RoutineID != nil {
+		return routinePath(*a.RoutineID)
+	}
+	return templatePath(*a.TemplateID)
+}
+
+// ProgramExercise is an exercise with targets in a template a coach writes
+type ProgramExercise struct {
+	ExerciseID int64
+	TargetSets int
+	TargetReps int
+}
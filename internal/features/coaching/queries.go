@@ -0,0 +1,242 @@
+package coaching
+
+import (
+	"database/sql"
+	"fmt"
+
+	"phobos/internal/features/notifications"
+	"phobos/internal/features/routines"
+	"phobos/internal/features/templates"
+	"phobos/internal/shared/tokens"
+)
+
+// CreateCoach adds a coach and returns its ID and the token for its link.
+// The token can't be recovered later.
+func CreateCoach(db *sql.DB, name string) (int64, string, error) {
+	token, err := tokens.New()
+	if err != nil {
+		return 0, "", err
+	}
+
+	result, err := db.Exec(`INSERT INTO coaches (name, token_hash) VALUES (?, ?)`, name, tokens.Hash(token))
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create coach: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, "", err
+	}
+	return id, token, nil
+}
+
+// ListCoaches returns every coach, active ones first
+func ListCoaches(db *sql.DB) ([]Coach, error) {
+	rows, err := db.Query(`
+		SELECT id, name, created_at, revoked_at
+		FROM coaches
+		ORDER BY revoked_at IS NOT NULL, name COLLATE NOCASE ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list coaches: %w", err)
+	}
+	defer rows.Close()
+
+	var coaches []Coach
+	for rows.Next() {
+		c, err := scanCoach(rows)
+		if err != nil {
+			return nil, err
+		}
+		coaches = append(coaches, c)
+	}
+
+	return coaches, rows.Err()
+}
+
+// GetCoachByToken returns the coach a link belongs to, or nil if the token is
+// unknown or the coach was revoked
+func GetCoachByToken(db *sql.DB, token string) (*Coach, error) {
+	c, err := scanCoach(db.QueryRow(`
+		SELECT id, name, created_at, revoked_at
+		FROM coaches
+		WHERE token_hash = ? AND revoked_at IS NULL
+	`, tokens.Hash(token)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func scanCoach(row interface{ Scan(...any) error }) (Coach, error) {
+	var c Coach
+	var revokedAt sql.NullTime
+	if err := row.Scan(&c.ID, &c.Name, &c.CreatedAt, &revokedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return c, err
+		}
+		return c, fmt.Errorf("failed to scan coach: %w", err)
+	}
+	if revokedAt.Valid {
+		c.RevokedAt = &revokedAt.Time
+	}
+	return c, nil
+}
+
+// RevokeCoach stops a coach's link from working. Their comments and
+// assignments are kept.
+func RevokeCoach(db *sql.DB, id int64) error {
+	_, err := db.Exec(`UPDATE coaches SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND revoked_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke coach: %w", err)
+	}
+	return nil
+}
+
+// ListAssignments returns what a coach has assigned, newest first
+func ListAssignments(db *sql.DB, coachID int64) ([]Assignment, error) {
+	rows, err := db.Query(`
+		SELECT ca.id, ca.coach_id, ca.template_id, ca.routine_id,
+		       COALESCE(wt.name, r.name, ''), COALESCE(ca.note, ''), ca.assigned_at
+		FROM coach_assignments ca
+		LEFT JOIN workout_templates wt ON ca.template_id = wt.id
+		LEFT JOIN routines r ON ca.routine_id = r.id
+		WHERE ca.coach_id = ?
+		ORDER BY ca.assigned_at DESC, ca.id DESC
+	`, coachID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list assignments: %w", err)
+	}
+	defer rows.Close()
+
+	var assignments []Assignment
+	for rows.Next() {
+		var a Assignment
+		var templateID, routineID sql.NullInt64
+		if err := rows.Scan(&a.ID, &a.CoachID, &templateID, &routineID, &a.Name, &a.Note, &a.AssignedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan assignment: %w", err)
+		}
+		if templateID.Valid {
+			a.TemplateID = &templateID.Int64
+		}
+		if routineID.Valid {
+			a.RoutineID = &routineID.Int64
+		}
+		assignments = append(assignments, a)
+	}
+
+	return assignments, rows.Err()
+}
+
+// AssignTemplate creates a template in the athlete's account on behalf of a
+// coach and notifies the athlete. It returns the new template's ID.
+func AssignTemplate(db *sql.DB, coach *Coach, name, note string, program []ProgramExercise) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	templateID, err := templates.CreateTx(tx, name)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, pe := range program {
+		if _, err := templates.AddExerciseTx(tx, templateID, pe.ExerciseID, pe.TargetSets, pe.TargetReps); err != nil {
+			return 0, err
+		}
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO coach_assignments (coach_id, template_id, note) VALUES (?, ?, NULLIF(?, ''))
+	`, coach.ID, templateID, note)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record assignment: %w", err)
+	}
+
+	message := coach.Name + " assigned you the template " + name
+	if _, err := notifications.CreateTx(tx, message, templatePath(templateID)); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return templateID, nil
+}
+
+// AssignRoutine creates a routine of existing templates in the athlete's
+// account on behalf of a coach and notifies the athlete. It returns the new
+// routine's ID.
+func AssignRoutine(db *sql.DB, coach *Coach, name, note string, templateIDs []int64) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	routineID, err := routines.CreateTx(tx, name)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, templateID := range templateIDs {
+		if _, err := routines.AddTemplateTx(tx, routineID, templateID); err != nil {
+			return 0, err
+		}
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO coach_assignments (coach_id, routine_id, note) VALUES (?, ?, NULLIF(?, ''))
+	`, coach.ID, routineID, note)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record assignment: %w", err)
+	}
+
+	message := coach.Name + " assigned you the routine " + name
+	if _, err := notifications.CreateTx(tx, message, routinePath(routineID)); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return routineID, nil
+}
+
+// AddComment records a coach's comment on a workout and notifies the athlete
+func AddComment(db *sql.DB, coach *Coach, workoutID int64, workoutName, body string) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO workout_comments (workout_id, coach_id, body) VALUES (?, ?, ?)
+	`, workoutID, coach.ID, body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add comment: %w", err)
+	}
+	commentID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	message := coach.Name + " commented on " + workoutName
+	if _, err := notifications.CreateTx(tx, message, workoutPath(workoutID)); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return commentID, nil
+}
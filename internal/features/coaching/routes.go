@@ -0,0 +1,17 @@
+package coaching
+
+import "github.com/gofiber/fiber/v2"
+
+// RegisterRoutes sets up coach management routes and the coach portal
+func RegisterRoutes(app *fiber.App) {
+	app.Get("/coaches", HandleIndex)
+	app.Post("/coaches", HandleCreate)
+	app.Delete("/coaches/:id", HandleRevoke)
+
+	// Coach portal, authorised by the token in the link
+	app.Get("/coach/:token", HandlePortal)
+	app.Get("/coach/:token/workouts/:id", HandlePortalWorkout)
+	app.Post("/coach/:token/workouts/:id/comments", HandleComment)
+	app.Post("/coach/:token/templates", HandleAssignTemplate)
+	app.Post("/coach/:token/routines", HandleAssignRoutine)
+}
@@ -1,7 +1,10 @@
 package exercises
 
 import (
+	"errors"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"phobos/internal/shared/htmx"
 	"phobos/internal/shared/middleware"
@@ -9,28 +12,37 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-// HandleList displays all exercises
+// HandleList displays all exercises, optionally filtered by name and tags
 func HandleList(c *fiber.Ctx) error {
 	db := middleware.GetDB(c)
 
-	exercises, err := ListAll(db)
+	filter := filterFromQuery(c)
+	exercises, err := Search(db, filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load exercises")
 	}
 
-	return htmx.Render(c, ExercisesPage(exercises))
+	archived, err := ListArchived(db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load archived exercises")
+	}
+
+	return htmx.Render(c, ExercisesPage(exercises, archived, filter))
 }
 
 // HandleCreate creates a new exercise
 func HandleCreate(c *fiber.Ctx) error {
 	db := middleware.GetDB(c)
 
-	name := c.FormValue("name")
+	name := strings.TrimSpace(c.FormValue("name"))
 	if name == "" {
 		return c.Status(fiber.StatusBadRequest).SendString("Name is required")
 	}
 
 	id, err := Create(db, name)
+	if errors.Is(err, ErrDuplicateName) {
+		return c.Status(fiber.StatusBadRequest).SendString("An exercise with this name already exists")
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).SendString("Failed to create exercise")
 	}
@@ -39,7 +51,137 @@ func HandleCreate(c *fiber.Ctx) error {
 	return htmx.Render(c, ExerciseRow(exercise))
 }
 
-// HandleDelete removes an exercise
+// HandleShow displays the exercise detail page
+func HandleShow(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	exercise, err := GetByID(db, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load exercise")
+	}
+	if exercise == nil {
+		return c.Status(fiber.StatusNotFound).SendString("Exercise not found")
+	}
+
+	usage, err := GetUsage(db, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load exercise usage")
+	}
+
+	return htmx.Render(c, ExerciseDetailPage(exercise, newExerciseForm(exercise), *usage))
+}
+
+// HandleUpdate renames an exercise and saves its notes and defaults.
+// Validation errors are rendered back into the form.
+func HandleUpdate(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	existing, err := GetByID(db, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load exercise")
+	}
+	if existing == nil {
+		return c.Status(fiber.StatusNotFound).SendString("Exercise not found")
+	}
+
+	form := ExerciseForm{
+		ID:          id,
+		Name:        strings.TrimSpace(c.FormValue("name")),
+		Notes:       strings.TrimSpace(c.FormValue("notes")),
+		RestSeconds: strings.TrimSpace(c.FormValue("rest_seconds")),
+		Increment:   strings.TrimSpace(c.FormValue("increment")),
+		MediaURL:    strings.TrimSpace(c.FormValue("media_url")),
+		Primary:     formValues(c, "primary_muscles"),
+		Secondary:   formValues(c, "secondary_muscles"),
+		Equipment:   formValues(c, "equipment"),
+		Errors:      map[string]string{},
+	}
+
+	exercise, ok := parseExerciseForm(&form)
+	if !ok {
+		return htmx.Render(c, ExerciseEditForm(form))
+	}
+
+	err = UpdateWithTags(db, exercise, form.Primary, form.Secondary, form.Equipment)
+	if errors.Is(err, ErrDuplicateName) {
+		form.Errors["name"] = "An exercise with this name already exists"
+		return htmx.Render(c, ExerciseEditForm(form))
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to update exercise")
+	}
+
+	form.Saved = true
+	return htmx.Render(c, ExerciseEditForm(form))
+}
+
+// newExerciseForm fills the edit form from a stored exercise
+func newExerciseForm(e *Exercise) ExerciseForm {
+	form := ExerciseForm{
+		ID:        e.ID,
+		Name:      e.Name,
+		Notes:     e.Notes,
+		MediaURL:  e.MediaURL,
+		Primary:   e.PrimaryMuscles,
+		Secondary: e.SecondaryMuscles,
+		Equipment: e.Equipment,
+	}
+	if e.DefaultRestSeconds != nil {
+		form.RestSeconds = strconv.Itoa(*e.DefaultRestSeconds)
+	}
+	if e.DefaultIncrement != nil {
+		form.Increment = strconv.FormatFloat(*e.DefaultIncrement, 'f', -1, 64)
+	}
+	return form
+}
+
+// parseExerciseForm validates the form, recording errors on it, and returns the exercise it describes
+func parseExerciseForm(form *ExerciseForm) (Exercise, bool) {
+	e := Exercise{ID: form.ID, Name: form.Name, Notes: form.Notes, MediaURL: form.MediaURL}
+
+	if form.Name == "" {
+		form.Errors["name"] = "Name is required"
+	}
+
+	if form.RestSeconds != "" {
+		rest, err := strconv.Atoi(form.RestSeconds)
+		if err != nil || rest < 0 {
+			form.Errors["rest_seconds"] = "Rest time must be a whole number of seconds"
+		} else {
+			e.DefaultRestSeconds = &rest
+		}
+	}
+
+	if form.Increment != "" {
+		increment, err := strconv.ParseFloat(form.Increment, 64)
+		if err != nil || increment <= 0 {
+			form.Errors["increment"] = "Increment must be a positive number"
+		} else {
+			e.DefaultIncrement = &increment
+		}
+	}
+
+	if form.MediaURL != "" {
+		u, err := url.Parse(form.MediaURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			form.Errors["media_url"] = "Enter a full http(s) URL"
+		}
+	}
+
+	return e, len(form.Errors) == 0
+}
+
+// HandleDelete removes an exercise, or shows where it is used if it cannot be deleted
 func HandleDelete(c *fiber.Ctx) error {
 	db := middleware.GetDB(c)
 
@@ -48,22 +190,141 @@ func HandleDelete(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
 	}
 
-	if err := Delete(db, id); err != nil {
+	err = Delete(db, id)
+	if errors.Is(err, ErrInUse) {
+		return renderInUse(c, id)
+	}
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).SendString("Failed to delete exercise")
 	}
 
 	return c.SendString("") // Return empty to remove the element
 }
 
-// HandleSearch searches exercises by name
+// renderInUse replaces an exercise row with its usage and the archive/merge alternatives
+func renderInUse(c *fiber.Ctx, id int64) error {
+	db := middleware.GetDB(c)
+
+	exercise, err := GetByID(db, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load exercise")
+	}
+	if exercise == nil {
+		return c.Status(fiber.StatusNotFound).SendString("Exercise not found")
+	}
+
+	usage, err := GetUsage(db, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load exercise usage")
+	}
+
+	allExercises, err := ListAll(db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load exercises")
+	}
+
+	return htmx.Render(c, ExerciseInUseRow(*exercise, *usage, allExercises))
+}
+
+// HandleArchive hides an exercise from pickers
+func HandleArchive(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	if err := Archive(db, id); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to archive exercise")
+	}
+
+	return htmx.Refresh(c)
+}
+
+// HandleUnarchive restores an archived exercise
+func HandleUnarchive(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	if err := Unarchive(db, id); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to restore exercise")
+	}
+
+	return htmx.Refresh(c)
+}
+
+// HandleMerge folds an exercise into another one
+func HandleMerge(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	targetID, err := strconv.ParseInt(c.FormValue("target_id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid target exercise")
+	}
+
+	if id == targetID {
+		return c.Status(fiber.StatusBadRequest).SendString("Cannot merge an exercise into itself")
+	}
+
+	err = Merge(db, id, targetID)
+	if errors.Is(err, ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).SendString("Exercise not found")
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to merge exercise")
+	}
+
+	return c.SendString("")
+}
+
+// HandleSearch searches exercises by name and tags
 func HandleSearch(c *fiber.Ctx) error {
 	db := middleware.GetDB(c)
 
-	query := c.Query("q")
-	exercises, err := Search(db, query)
+	exercises, err := Search(db, filterFromQuery(c))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).SendString("Failed to search exercises")
 	}
 
 	return htmx.Render(c, ExerciseListFragment(exercises))
 }
+
+// HandleOptions returns the <option> list for exercise pickers, filtered by name and tags
+func HandleOptions(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	exercises, err := Search(db, filterFromQuery(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to search exercises")
+	}
+
+	return htmx.Render(c, ExerciseOptions(exercises))
+}
+
+// filterFromQuery reads an exercise filter from the q, muscle and equipment query parameters
+func filterFromQuery(c *fiber.Ctx) Filter {
+	return Filter{
+		Query:       strings.TrimSpace(c.Query("q")),
+		MuscleGroup: c.Query("muscle"),
+		Equipment:   c.Query("equipment"),
+	}
+}
+
+// formValues returns every submitted value of a repeated form field
+func formValues(c *fiber.Ctx, key string) []string {
+	var values []string
+	for _, v := range c.Request().PostArgs().PeekMulti(key) {
+		values = append(values, string(v))
+	}
+	return values
+}
@@ -5,8 +5,11 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"phobos/internal/features/exercises"
+	"phobos/internal/features/templates"
+	"phobos/internal/features/workouts"
 	"phobos/internal/testutil"
 )
 
@@ -72,6 +75,24 @@ func TestHandleCreate_EmptyName(t *testing.T) {
 	}
 }
 
+func TestHandleCreate_TrimsName(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	exercises.Create(app.DB, "Bench")
+
+	resp := app.HTMXRequest("POST", "/exercises", "name=Bench+")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a name that differs only by spaces, got %d", resp.StatusCode)
+	}
+
+	resp = app.HTMXRequest("POST", "/exercises", "name=+")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a blank name, got %d", resp.StatusCode)
+	}
+}
+
 func TestHandleList_WithExercises(t *testing.T) {
 	t.Parallel()
 	app := testutil.NewTestApp(t)
@@ -110,6 +131,7 @@ func TestHandleDelete(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create exercise: %v", err)
 	}
+	exercises.SetTags(app.DB, id, []string{"quads"}, nil, []string{"barbell"})
 
 	// Delete it
 	resp := app.HTMXRequest("DELETE", "/exercises/"+itoa(id), "")
@@ -126,6 +148,382 @@ func TestHandleDelete(t *testing.T) {
 	if exercise != nil {
 		t.Error("expected exercise to be deleted")
 	}
+
+	// Its tags go with it
+	var tags int
+	app.DB.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM exercise_muscle_groups WHERE exercise_id = ?) +
+		       (SELECT COUNT(*) FROM exercise_equipment WHERE exercise_id = ?)
+	`, id, id).Scan(&tags)
+	if tags != 0 {
+		t.Errorf("expected the exercise's tags to be deleted, %d remain", tags)
+	}
+}
+
+func TestHandleDelete_InUse(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	id, _ := exercises.Create(app.DB, "Squat")
+	templateID, _ := templates.Create(app.DB, "Leg Day")
+	templates.AddExercise(app.DB, templateID, id, 3, 5)
+	workoutID, _ := workouts.Create(app.DB, "Monday Legs", time.Now(), nil)
+	workouts.AddExercise(app.DB, workoutID, id)
+
+	resp := app.HTMXRequest("DELETE", "/exercises/"+itoa(id), "")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "Leg Day") {
+		t.Error("expected response to list the template using the exercise")
+	}
+	if !strings.Contains(body, "Monday Legs") {
+		t.Error("expected response to list the workout using the exercise")
+	}
+	if !strings.Contains(body, "Archive Instead") {
+		t.Error("expected response to offer archiving")
+	}
+
+	// Verify it was not deleted
+	exercise, _ := exercises.GetByID(app.DB, id)
+	if exercise == nil {
+		t.Error("expected exercise in use to NOT be deleted")
+	}
+}
+
+func TestHandleArchive(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	id, _ := exercises.Create(app.DB, "Good Morning")
+	exercises.Create(app.DB, "Squat")
+
+	resp := app.HTMXRequest("POST", "/exercises/"+itoa(id)+"/archive", "")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	// Archived exercises are hidden from the active list
+	active, _ := exercises.ListAll(app.DB)
+	if len(active) != 1 || active[0].Name != "Squat" {
+		t.Errorf("expected only 'Squat' to be active, got %v", active)
+	}
+
+	archived, _ := exercises.ListArchived(app.DB)
+	if len(archived) != 1 || archived[0].ID != id {
+		t.Errorf("expected 'Good Morning' to be archived, got %v", archived)
+	}
+
+	// Restore it
+	app.HTMXRequest("POST", "/exercises/"+itoa(id)+"/unarchive", "")
+
+	exercise, _ := exercises.GetByID(app.DB, id)
+	if exercise.IsArchived() {
+		t.Error("expected exercise to be restored")
+	}
+}
+
+func TestHandleMerge(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	sourceID, _ := exercises.Create(app.DB, "Bench")
+	targetID, _ := exercises.Create(app.DB, "Bench Press")
+	templateID, _ := templates.Create(app.DB, "Push Day")
+	templates.AddExercise(app.DB, templateID, sourceID, 3, 8)
+	workoutID, _ := workouts.Create(app.DB, "Monday Push", time.Now(), nil)
+	workouts.AddExercise(app.DB, workoutID, sourceID)
+
+	resp := app.HTMXRequest("POST", "/exercises/"+itoa(sourceID)+"/merge", "target_id="+itoa(targetID))
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	// Source is gone
+	source, _ := exercises.GetByID(app.DB, sourceID)
+	if source != nil {
+		t.Error("expected merged exercise to be deleted")
+	}
+
+	// Workout and template now point at the target
+	workout, _ := workouts.GetByID(app.DB, workoutID)
+	if len(workout.Exercises) != 1 || workout.Exercises[0].ExerciseID != targetID {
+		t.Error("expected workout exercise to be repointed to target")
+	}
+	template, _ := templates.GetByID(app.DB, templateID)
+	if len(template.Exercises) != 1 || template.Exercises[0].ExerciseID != targetID {
+		t.Error("expected template exercise to be repointed to target")
+	}
+}
+
+func TestHandleMerge_IntoItself(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	id, _ := exercises.Create(app.DB, "Squat")
+
+	resp := app.HTMXRequest("POST", "/exercises/"+itoa(id)+"/merge", "target_id="+itoa(id))
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestHandleMerge_Missing(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	id, _ := exercises.Create(app.DB, "Squat")
+
+	resp := app.HTMXRequest("POST", "/exercises/999/merge", "target_id="+itoa(id))
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404 for a missing source, got %d", resp.StatusCode)
+	}
+
+	resp = app.HTMXRequest("POST", "/exercises/"+itoa(id)+"/merge", "target_id=999")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404 for a missing target, got %d", resp.StatusCode)
+	}
+
+	if exercise, _ := exercises.GetByID(app.DB, id); exercise == nil {
+		t.Error("expected the exercise to survive a failed merge")
+	}
+}
+
+func TestHandleShow(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	id, _ := exercises.Create(app.DB, "Deadlift")
+
+	resp := app.Request("GET", "/exercises/"+itoa(id), "")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "Deadlift") {
+		t.Error("expected page to contain exercise name")
+	}
+	if !strings.Contains(body, "Edit Exercise") {
+		t.Error("expected page to contain the edit form")
+	}
+}
+
+func TestHandleShow_NotFound(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	resp := app.Request("GET", "/exercises/999", "")
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestHandleUpdate(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	id, _ := exercises.Create(app.DB, "Bench")
+
+	resp := app.HTMXRequest("PUT", "/exercises/"+itoa(id),
+		"name=Bench+Press&notes=Tuck+elbows&rest_seconds=180&increment=5&media_url=https%3A%2F%2Fexample.com%2Fbench.mp4")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "Exercise saved") {
+		t.Error("expected response to confirm the save")
+	}
+
+	exercise, _ := exercises.GetByID(app.DB, id)
+	if exercise.Name != "Bench Press" {
+		t.Errorf("expected name 'Bench Press', got '%s'", exercise.Name)
+	}
+	if exercise.Notes != "Tuck elbows" {
+		t.Errorf("expected notes 'Tuck elbows', got '%s'", exercise.Notes)
+	}
+	if exercise.DefaultRestSeconds == nil || *exercise.DefaultRestSeconds != 180 {
+		t.Error("expected default rest of 180 seconds")
+	}
+	if exercise.DefaultIncrement == nil || *exercise.DefaultIncrement != 5 {
+		t.Error("expected default increment of 5")
+	}
+	if exercise.MediaURL != "https://example.com/bench.mp4" {
+		t.Errorf("expected media URL to be saved, got '%s'", exercise.MediaURL)
+	}
+}
+
+func TestHandleUpdate_DuplicateName(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	exercises.Create(app.DB, "Squat")
+	id, _ := exercises.Create(app.DB, "Front Squat")
+
+	resp := app.HTMXRequest("PUT", "/exercises/"+itoa(id), "name=squat")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "already exists") {
+		t.Error("expected form to show duplicate name error")
+	}
+
+	exercise, _ := exercises.GetByID(app.DB, id)
+	if exercise.Name != "Front Squat" {
+		t.Errorf("expected name to be unchanged, got '%s'", exercise.Name)
+	}
+}
+
+func TestHandleUpdate_InvalidFields(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	id, _ := exercises.Create(app.DB, "Row")
+
+	resp := app.HTMXRequest("PUT", "/exercises/"+itoa(id), "name=Row&rest_seconds=-5&media_url=not-a-url")
+
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "Rest time must be") {
+		t.Error("expected form to show rest time error")
+	}
+	if !strings.Contains(body, "Enter a full http(s) URL") {
+		t.Error("expected form to show URL error")
+	}
+
+	exercise, _ := exercises.GetByID(app.DB, id)
+	if exercise.DefaultRestSeconds != nil {
+		t.Error("expected invalid update to not be saved")
+	}
+}
+
+func TestHandleCreate_DuplicateName(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	exercises.Create(app.DB, "Squat")
+
+	resp := app.HTMXRequest("POST", "/exercises", "name=Squat")
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestHandleUpdate_Tags(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	id, _ := exercises.Create(app.DB, "Bench Press")
+
+	resp := app.HTMXRequest("PUT", "/exercises/"+itoa(id),
+		"name=Bench+Press&primary_muscles=chest&secondary_muscles=triceps&secondary_muscles=shoulders&secondary_muscles=chest&equipment=barbell")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	exercise, _ := exercises.GetByID(app.DB, id)
+	if len(exercise.PrimaryMuscles) != 1 || exercise.PrimaryMuscles[0] != "chest" {
+		t.Errorf("expected primary muscles [chest], got %v", exercise.PrimaryMuscles)
+	}
+	// Chest is primary, so it is not repeated as secondary
+	if len(exercise.SecondaryMuscles) != 2 {
+		t.Errorf("expected 2 secondary muscles, got %v", exercise.SecondaryMuscles)
+	}
+	if len(exercise.Equipment) != 1 || exercise.Equipment[0] != "barbell" {
+		t.Errorf("expected equipment [barbell], got %v", exercise.Equipment)
+	}
+}
+
+func TestHandleSearch_ByTag(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	benchID, _ := exercises.Create(app.DB, "Bench Press")
+	exercises.SetTags(app.DB, benchID, []string{"chest"}, []string{"triceps"}, []string{"barbell"})
+	flyID, _ := exercises.Create(app.DB, "Cable Fly")
+	exercises.SetTags(app.DB, flyID, []string{"chest"}, nil, []string{"cable"})
+	squatID, _ := exercises.Create(app.DB, "Squat")
+	exercises.SetTags(app.DB, squatID, []string{"quads"}, nil, []string{"barbell"})
+
+	resp := app.HTMXRequest("GET", "/exercises/search?muscle=chest", "")
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "Bench Press") || !strings.Contains(body, "Cable Fly") {
+		t.Error("expected chest results to contain 'Bench Press' and 'Cable Fly'")
+	}
+	if strings.Contains(body, "Squat") {
+		t.Error("expected chest results to NOT contain 'Squat'")
+	}
+
+	// Secondary muscles match too
+	resp = app.HTMXRequest("GET", "/exercises/search?muscle=triceps", "")
+	body = testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "Bench Press") {
+		t.Error("expected triceps results to contain 'Bench Press'")
+	}
+
+	// Tags combine with each other and with the name query
+	resp = app.HTMXRequest("GET", "/exercises/search?muscle=chest&equipment=barbell", "")
+	body = testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "Bench Press") || strings.Contains(body, "Cable Fly") {
+		t.Error("expected chest+barbell results to contain only 'Bench Press'")
+	}
+
+	resp = app.HTMXRequest("GET", "/exercises/search?q=fly&muscle=chest", "")
+	body = testutil.ReadBody(t, resp)
+	if strings.Contains(body, "Bench Press") || !strings.Contains(body, "Cable Fly") {
+		t.Error("expected 'fly' chest results to contain only 'Cable Fly'")
+	}
+}
+
+func TestHandleOptions(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	squatID, _ := exercises.Create(app.DB, "Squat")
+	exercises.SetTags(app.DB, squatID, []string{"quads"}, nil, nil)
+	exercises.Create(app.DB, "Curl")
+
+	resp := app.HTMXRequest("GET", "/exercises/options?muscle=quads", "")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "<option value=\""+itoa(squatID)+"\">Squat</option>") {
+		t.Error("expected options to contain 'Squat'")
+	}
+	if strings.Contains(body, "Curl") {
+		t.Error("expected options to NOT contain 'Curl'")
+	}
 }
 
 func TestHandleDelete_InvalidID(t *testing.T) {
@@ -1,10 +1,106 @@
 package exercises
 
-import "time"
+import (
+	"path"
+	"strings"
+	"time"
+)
 
 // Exercise represents a named movement
 type Exercise struct {
-	ID        int64
-	Name      string
-	CreatedAt time.Time
+	ID                 int64
+	Name               string
+	CreatedAt          time.Time
+	ArchivedAt         *time.Time
+	Notes              string   // Coaching cues
+	DefaultRestSeconds *int     // Rest between sets
+	DefaultIncrement   *float64 // Weight added when progressing
+	MediaURL           string   // Optional demo video or image
+	PrimaryMuscles     []string
+	SecondaryMuscles   []string
+	Equipment          []string
+}
+
+// Muscle group roles
+const (
+	RolePrimary   = "primary"
+	RoleSecondary = "secondary"
+)
+
+// MuscleGroups lists the muscle groups an exercise can be tagged with
+var MuscleGroups = []string{
+	"chest", "back", "traps", "shoulders", "biceps", "triceps",
+	"forearms", "abs", "quads", "hamstrings", "glutes", "calves",
+}
+
+// EquipmentTypes lists the equipment an exercise can be tagged with
+var EquipmentTypes = []string{
+	"barbell", "dumbbell", "kettlebell", "machine", "cable", "bodyweight", "band", "other",
+}
+
+// TagLabel returns the display label for a muscle group or equipment tag
+func TagLabel(tag string) string {
+	if tag == "" {
+		return ""
+	}
+	return strings.ToUpper(tag[:1]) + tag[1:]
+}
+
+// Filter narrows an exercise search by name, muscle group and equipment
+type Filter struct {
+	Query       string
+	MuscleGroup string // Matches primary or secondary muscles
+	Equipment   string
+}
+
+// IsEmpty returns true if the filter matches every exercise
+func (f Filter) IsEmpty() bool {
+	return f.Query == "" && f.MuscleGroup == "" && f.Equipment == ""
+}
+
+// HasImage returns true if the media URL points at an image file
+func (e *Exercise) HasImage() bool {
+	switch strings.ToLower(path.Ext(e.MediaURL)) {
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp":
+		return true
+	}
+	return false
+}
+
+// IsArchived returns true if the exercise has been archived
+func (e *Exercise) IsArchived() bool {
+	return e.ArchivedAt != nil
+}
+
+// UsageRef identifies a workout or template that references an exercise
+type UsageRef struct {
+	ID   int64
+	Name string
+}
+
+// ExerciseUsage describes where an exercise is referenced
+type ExerciseUsage struct {
+	WorkoutCount int
+	Workouts     []UsageRef // Most recent workouts only
+	Templates    []UsageRef
+}
+
+// InUse returns true if any workout or template references the exercise
+func (u *ExerciseUsage) InUse() bool {
+	return u.WorkoutCount > 0 || len(u.Templates) > 0
+}
+
+// ExerciseForm holds the raw values and validation errors of the exercise edit form
+type ExerciseForm struct {
+	ID          int64
+	Name        string
+	Notes       string
+	RestSeconds string
+	Increment   string
+	MediaURL    string
+	Primary     []string
+	Secondary   []string
+	Equipment   []string
+	Errors      map[string]string
+	Saved       bool
 }
@@ -2,41 +2,74 @@ package exercises
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+
+	"phobos/internal/features/strength"
 )
 
-// ListAll returns all exercises ordered by name
+// ErrInUse is returned when deleting an exercise that workouts or templates still reference
+var ErrInUse = errors.New("exercise is in use")
+
+// ErrDuplicateName is returned when another exercise already has the name
+var ErrDuplicateName = errors.New("an exercise with this name already exists")
+
+// ErrNotFound is returned when merging an exercise that doesn't exist, or
+// into one that doesn't
+var ErrNotFound = errors.New("exercise not found")
+
+// ErrSameExercise is returned when merging an exercise into itself
+var ErrSameExercise = errors.New("cannot merge an exercise into itself")
+
+// recentUsageLimit caps how many workouts GetUsage lists by name
+const recentUsageLimit = 5
+
+// ListAll returns all active (non-archived) exercises ordered by name
 func ListAll(db *sql.DB) ([]Exercise, error) {
-	rows, err := db.Query(`
-		SELECT id, name, created_at
+	exercises, err := queryExercises(db, `
+		SELECT id, name, created_at, archived_at
 		FROM exercises
+		WHERE archived_at IS NULL
 		ORDER BY name ASC
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list exercises: %w", err)
 	}
-	defer rows.Close()
+	return exercises, nil
+}
 
-	var exercises []Exercise
-	for rows.Next() {
-		var e Exercise
-		if err := rows.Scan(&e.ID, &e.Name, &e.CreatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan exercise: %w", err)
-		}
-		exercises = append(exercises, e)
+// ListArchived returns all archived exercises ordered by name
+func ListArchived(db *sql.DB) ([]Exercise, error) {
+	exercises, err := queryExercises(db, `
+		SELECT id, name, created_at, archived_at
+		FROM exercises
+		WHERE archived_at IS NOT NULL
+		ORDER BY name ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archived exercises: %w", err)
 	}
-
-	return exercises, rows.Err()
+	return exercises, nil
 }
 
 // GetByID returns a single exercise by ID
 func GetByID(db *sql.DB, id int64) (*Exercise, error) {
 	var e Exercise
+	var archivedAt sql.NullTime
+	var notes, mediaURL sql.NullString
+	var restSeconds sql.NullInt64
+	var increment sql.NullFloat64
 	err := db.QueryRow(`
-		SELECT id, name, created_at
+		SELECT id, name, created_at, archived_at,
+		       notes, default_rest_seconds, default_increment, media_url
 		FROM exercises
 		WHERE id = ?
-	`, id).Scan(&e.ID, &e.Name, &e.CreatedAt)
+	`, id).Scan(
+		&e.ID, &e.Name, &e.CreatedAt, &archivedAt,
+		&notes, &restSeconds, &increment, &mediaURL,
+	)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -45,14 +78,63 @@ func GetByID(db *sql.DB, id int64) (*Exercise, error) {
 		return nil, fmt.Errorf("failed to get exercise: %w", err)
 	}
 
-	return &e, nil
+	if archivedAt.Valid {
+		e.ArchivedAt = &archivedAt.Time
+	}
+	e.Notes = notes.String
+	e.MediaURL = mediaURL.String
+	if restSeconds.Valid {
+		rest := int(restSeconds.Int64)
+		e.DefaultRestSeconds = &rest
+	}
+	if increment.Valid {
+		e.DefaultIncrement = &increment.Float64
+	}
+
+	tagged := []Exercise{e}
+	if err := attachTags(db, tagged); err != nil {
+		return nil, err
+	}
+
+	return &tagged[0], nil
 }
 
 // Create inserts a new exercise and returns its ID
 func Create(db *sql.DB, name string) (int64, error) {
-	result, err := db.Exec(`
+	exists, err := NameExists(db, name, 0)
+	if err != nil {
+		return 0, err
+	}
+	if exists {
+		return 0, ErrDuplicateName
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	id, err := CreateTx(tx, name)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return id, nil
+}
+
+// CreateTx inserts a new exercise as part of a caller's transaction
+func CreateTx(tx *sql.Tx, name string) (int64, error) {
+	result, err := tx.Exec(`
 		INSERT INTO exercises (name) VALUES (?)
 	`, name)
+	if isUniqueViolation(err) {
+		return 0, ErrDuplicateName
+	}
 	if err != nil {
 		return 0, fmt.Errorf("failed to create exercise: %w", err)
 	}
@@ -60,36 +142,458 @@ func Create(db *sql.DB, name string) (int64, error) {
 	return result.LastInsertId()
 }
 
-// Delete removes an exercise by ID
+// Update renames an exercise and sets its notes and defaults
+func Update(db *sql.DB, e Exercise) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := UpdateTx(tx, e); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateWithTags saves an exercise and replaces its tags in one transaction
+func UpdateWithTags(db *sql.DB, e Exercise, primary, secondary, equipment []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := UpdateTx(tx, e); err != nil {
+		return err
+	}
+	if err := SetTagsTx(tx, e.ID, primary, secondary, equipment); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTx renames an exercise and sets its notes and defaults as part of a
+// caller's transaction
+func UpdateTx(tx *sql.Tx, e Exercise) error {
+	exists, err := NameExists(tx, e.Name, e.ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrDuplicateName
+	}
+
+	_, err = tx.Exec(`
+		UPDATE exercises
+		SET name = ?, notes = ?, default_rest_seconds = ?, default_increment = ?, media_url = ?
+		WHERE id = ?
+	`, e.Name, nullString(e.Notes), e.DefaultRestSeconds, e.DefaultIncrement, nullString(e.MediaURL), e.ID)
+	if isUniqueViolation(err) {
+		return ErrDuplicateName
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update exercise: %w", err)
+	}
+	return nil
+}
+
+// rowQuerier is a *sql.DB or a *sql.Tx
+type rowQuerier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// NameExists reports whether another exercise already uses the name (ignoring case)
+func NameExists(db rowQuerier, name string, excludeID int64) (bool, error) {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM exercises WHERE LOWER(name) = LOWER(?) AND id != ?
+	`, name, excludeID).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check exercise name: %w", err)
+	}
+	return count > 0, nil
+}
+
+// Delete removes an exercise by ID. It returns ErrInUse if any workout or
+// template still references the exercise.
 func Delete(db *sql.DB, id int64) error {
-	_, err := db.Exec(`DELETE FROM exercises WHERE id = ?`, id)
+	usage, err := GetUsage(db, id)
 	if err != nil {
+		return err
+	}
+	if usage.InUse() {
+		return ErrInUse
+	}
+
+	// Tags go with the exercise; lift records and template history keep
+	// their rows with the exercise unlinked
+	if _, err := db.Exec(`DELETE FROM exercises WHERE id = ?`, id); err != nil {
 		return fmt.Errorf("failed to delete exercise: %w", err)
 	}
 	return nil
 }
 
-// Search returns exercises matching the query
-func Search(db *sql.DB, query string) ([]Exercise, error) {
-	rows, err := db.Query(`
-		SELECT id, name, created_at
-		FROM exercises
-		WHERE name LIKE ?
-		ORDER BY name ASC
-	`, "%"+query+"%")
+// SetTags replaces an exercise's muscle groups and equipment. A muscle listed
+// as both primary and secondary is stored as primary.
+func SetTags(db *sql.DB, id int64, primary, secondary, equipment []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := SetTagsTx(tx, id, primary, secondary, equipment); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// SetTagsTx replaces an exercise's tags as part of a caller's transaction
+func SetTagsTx(tx *sql.Tx, id int64, primary, secondary, equipment []string) error {
+	if err := deleteTags(tx, id); err != nil {
+		return err
+	}
+
+	roles := make(map[string]string)
+	for _, m := range secondary {
+		roles[m] = RoleSecondary
+	}
+	for _, m := range primary {
+		roles[m] = RolePrimary
+	}
+
+	for _, m := range MuscleGroups {
+		role, ok := roles[m]
+		if !ok {
+			continue
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO exercise_muscle_groups (exercise_id, muscle_group, role)
+			VALUES (?, ?, ?)
+		`, id, m, role); err != nil {
+			return fmt.Errorf("failed to tag muscle group: %w", err)
+		}
+	}
+
+	for _, eq := range EquipmentTypes {
+		if !slices.Contains(equipment, eq) {
+			continue
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO exercise_equipment (exercise_id, equipment)
+			VALUES (?, ?)
+		`, id, eq); err != nil {
+			return fmt.Errorf("failed to tag equipment: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// deleteTags removes all muscle group and equipment tags of an exercise
+func deleteTags(tx *sql.Tx, id int64) error {
+	if _, err := tx.Exec(`DELETE FROM exercise_muscle_groups WHERE exercise_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete muscle groups: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM exercise_equipment WHERE exercise_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete equipment: %w", err)
+	}
+	return nil
+}
+
+// Archive hides an exercise from pickers while keeping its history
+func Archive(db *sql.DB, id int64) error {
+	_, err := db.Exec(`
+		UPDATE exercises
+		SET archived_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND archived_at IS NULL
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to archive exercise: %w", err)
+	}
+	return nil
+}
+
+// Unarchive restores an archived exercise
+func Unarchive(db *sql.DB, id int64) error {
+	_, err := db.Exec(`UPDATE exercises SET archived_at = NULL WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to unarchive exercise: %w", err)
+	}
+	return nil
+}
+
+// GetUsage returns the workouts and templates that reference an exercise
+func GetUsage(db *sql.DB, id int64) (*ExerciseUsage, error) {
+	var usage ExerciseUsage
+
+	err := db.QueryRow(`
+		SELECT COUNT(DISTINCT workout_id)
+		FROM workout_exercises
+		WHERE exercise_id = ?
+	`, id).Scan(&usage.WorkoutCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count exercise workouts: %w", err)
+	}
+
+	usage.Workouts, err = queryUsageRefs(db, `
+		SELECT w.id, w.name
+		FROM workouts w
+		WHERE w.id IN (SELECT workout_id FROM workout_exercises WHERE exercise_id = ?)
+		ORDER BY w.date DESC, w.created_at DESC
+		LIMIT ?
+	`, id, recentUsageLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	usage.Templates, err = queryUsageRefs(db, `
+		SELECT wt.id, wt.name
+		FROM workout_templates wt
+		WHERE wt.id IN (SELECT template_id FROM template_exercises WHERE exercise_id = ?)
+		ORDER BY wt.name ASC
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &usage, nil
+}
+
+// Merge folds the source exercise into the target: every workout and template
+// entry is repointed at the target and the source is deleted. It returns
+// ErrNotFound if either exercise is missing.
+func Merge(db *sql.DB, sourceID, targetID int64) error {
+	if sourceID == targetID {
+		return ErrSameExercise
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var found int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM exercises WHERE id IN (?, ?)`, sourceID, targetID).Scan(&found)
+	if err != nil {
+		return fmt.Errorf("failed to get exercises: %w", err)
+	}
+	if found != 2 {
+		return ErrNotFound
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE workout_exercises SET exercise_id = ? WHERE exercise_id = ?
+	`, targetID, sourceID); err != nil {
+		return fmt.Errorf("failed to merge workout exercises: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE template_exercises SET exercise_id = ? WHERE exercise_id = ?
+	`, targetID, sourceID); err != nil {
+		return fmt.Errorf("failed to merge template exercises: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE strength_lifts SET exercise_id = ? WHERE exercise_id = ?
+	`, targetID, sourceID); err != nil {
+		return fmt.Errorf("failed to merge strength lifts: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE template_version_exercises SET exercise_id = ? WHERE exercise_id = ?
+	`, targetID, sourceID); err != nil {
+		return fmt.Errorf("failed to merge template history: %w", err)
+	}
+
+	// The source's tags are deleted with it
+	if _, err := tx.Exec(`DELETE FROM exercises WHERE id = ?`, sourceID); err != nil {
+		return fmt.Errorf("failed to delete merged exercise: %w", err)
+	}
+
+	// The source's sets now count towards any lift done with the target
+	if err := strength.RefreshForExerciseTx(tx, targetID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Search returns active exercises whose name contains the filter query and
+// that carry the filter's muscle group and equipment tags
+func Search(db *sql.DB, f Filter) ([]Exercise, error) {
+	exercises, err := queryExercises(db, `
+		SELECT e.id, e.name, e.created_at, e.archived_at
+		FROM exercises e
+		WHERE e.name LIKE ? AND e.archived_at IS NULL
+		  AND (? = '' OR EXISTS (
+		    SELECT 1 FROM exercise_muscle_groups m
+		    WHERE m.exercise_id = e.id AND m.muscle_group = ?
+		  ))
+		  AND (? = '' OR EXISTS (
+		    SELECT 1 FROM exercise_equipment q
+		    WHERE q.exercise_id = e.id AND q.equipment = ?
+		  ))
+		ORDER BY e.name ASC
+	`, "%"+f.Query+"%", f.MuscleGroup, f.MuscleGroup, f.Equipment, f.Equipment)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search exercises: %w", err)
 	}
+	return exercises, nil
+}
+
+// queryExercises runs a query returning id, name, created_at, archived_at rows
+// and loads the tags of every exercise found
+func queryExercises(db *sql.DB, query string, args ...interface{}) ([]Exercise, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	exercises, err := scanExercises(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := attachTags(db, exercises); err != nil {
+		return nil, err
+	}
+
+	return exercises, nil
+}
+
+// attachTags batch loads muscle groups and equipment for the exercises
+func attachTags(db *sql.DB, exercises []Exercise) error {
+	if len(exercises) == 0 {
+		return nil
+	}
+
+	index := make(map[int64]int, len(exercises))
+	placeholders := make([]string, len(exercises))
+	args := make([]interface{}, len(exercises))
+	for i, e := range exercises {
+		index[e.ID] = i
+		placeholders[i] = "?"
+		args[i] = e.ID
+	}
+	in := strings.Join(placeholders, ",")
+
+	rows, err := db.Query(fmt.Sprintf(`
+		SELECT exercise_id, muscle_group, role
+		FROM exercise_muscle_groups
+		WHERE exercise_id IN (%s)
+		ORDER BY muscle_group ASC
+	`, in), args...)
+	if err != nil {
+		return fmt.Errorf("failed to get muscle groups: %w", err)
+	}
+	for rows.Next() {
+		var id int64
+		var muscle, role string
+		if err := rows.Scan(&id, &muscle, &role); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan muscle group: %w", err)
+		}
+		e := &exercises[index[id]]
+		if role == RolePrimary {
+			e.PrimaryMuscles = append(e.PrimaryMuscles, muscle)
+		} else {
+			e.SecondaryMuscles = append(e.SecondaryMuscles, muscle)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = db.Query(fmt.Sprintf(`
+		SELECT exercise_id, equipment
+		FROM exercise_equipment
+		WHERE exercise_id IN (%s)
+		ORDER BY equipment ASC
+	`, in), args...)
+	if err != nil {
+		return fmt.Errorf("failed to get equipment: %w", err)
+	}
 	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var equipment string
+		if err := rows.Scan(&id, &equipment); err != nil {
+			return fmt.Errorf("failed to scan equipment: %w", err)
+		}
+		e := &exercises[index[id]]
+		e.Equipment = append(e.Equipment, equipment)
+	}
+
+	return rows.Err()
+}
 
+// scanExercises reads id, name, created_at, archived_at rows into exercises
+func scanExercises(rows *sql.Rows) ([]Exercise, error) {
 	var exercises []Exercise
 	for rows.Next() {
 		var e Exercise
-		if err := rows.Scan(&e.ID, &e.Name, &e.CreatedAt); err != nil {
+		var archivedAt sql.NullTime
+		if err := rows.Scan(&e.ID, &e.Name, &e.CreatedAt, &archivedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan exercise: %w", err)
 		}
+		if archivedAt.Valid {
+			e.ArchivedAt = &archivedAt.Time
+		}
 		exercises = append(exercises, e)
 	}
 
 	return exercises, rows.Err()
 }
+
+// queryUsageRefs runs a query returning (id, name) pairs
+func queryUsageRefs(db *sql.DB, query string, args ...interface{}) ([]UsageRef, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exercise usage: %w", err)
+	}
+	defer rows.Close()
+
+	var refs []UsageRef
+	for rows.Next() {
+		var r UsageRef
+		if err := rows.Scan(&r.ID, &r.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan exercise usage: %w", err)
+		}
+		refs = append(refs, r)
+	}
+
+	return refs, rows.Err()
+}
+
+// isUniqueViolation reports whether err is a SQLite UNIQUE constraint failure
+func isUniqueViolation(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+// nullString stores empty strings as NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -8,4 +8,10 @@ func RegisterRoutes(app *fiber.App) {
 	app.Post("/exercises", HandleCreate)
 	app.Delete("/exercises/:id", HandleDelete)
 	app.Get("/exercises/search", HandleSearch)
+	app.Get("/exercises/options", HandleOptions)
+	app.Get("/exercises/:id", HandleShow)
+	app.Put("/exercises/:id", HandleUpdate)
+	app.Post("/exercises/:id/archive", HandleArchive)
+	app.Post("/exercises/:id/unarchive", HandleUnarchive)
+	app.Post("/exercises/:id/merge", HandleMerge)
 }
@@ -10,6 +10,9 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+// recentWorkoutsLimit is how many finished workouts the dashboard shows
+const recentWorkoutsLimit = 5
+
 // DashboardData holds all data for the home page
 type DashboardData struct {
 	ActiveWorkouts []workouts.WorkoutSummary
@@ -28,14 +31,11 @@ func HandleHome(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load active workouts")
 	}
 
-	// Get recent finished workouts (limit to 5)
-	recentWorkouts, err := workouts.ListFinished(db)
+	// Get recent finished workouts
+	recentWorkouts, err := workouts.ListRecent(db, recentWorkoutsLimit)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load recent workouts")
 	}
-	if len(recentWorkouts) > 5 {
-		recentWorkouts = recentWorkouts[:5]
-	}
 
 	// Get all templates
 	allTemplates, err := templates.ListAll(db)
@@ -0,0 +1,54 @@
+package jobs
+
+import (
+	"errors"
+	"time"
+
+	"phobos/internal/shared/htmx"
+	"phobos/internal/shared/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// runLogLimit is how many runs the admin page lists
+const runLogLimit = 100
+
+// HandleIndex lists the background jobs with their recent runs, optionally
+// only those of one job or only failures
+func HandleIndex(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	jobs, err := ListJobs(db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load jobs")
+	}
+
+	holder, err := GetLockHolder(db, time.Now())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load job lock")
+	}
+
+	filter := RunFilter{Job: c.Query("job"), FailedOnly: c.Query("failed") == "1"}
+	runs, err := ListRuns(db, filter.Job, filter.FailedOnly, runLogLimit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load job runs")
+	}
+
+	return htmx.Render(c, JobsPage(jobs, holder, filter, runs))
+}
+
+// HandleRunNow makes a job due immediately. The runner picks it up within a
+// few seconds.
+func HandleRunNow(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	err := Trigger(db, c.Params("name"), time.Now())
+	if errors.Is(err, ErrJobNotFound) {
+		return c.Status(fiber.StatusNotFound).SendString("Job not found")
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to trigger job")
+	}
+
+	return htmx.Refresh(c)
+}
@@ -0,0 +1,253 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"phobos/internal/features/jobs"
+	"phobos/internal/shared/dates"
+	"phobos/internal/testutil"
+)
+
+func TestRunner_RunsDueJobs(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	calls := 0
+	runner := jobs.NewRunner(app.DB)
+	runner.Register(jobs.Job{Name: "count", Schedule: "@every 1h", Run: func(ctx context.Context) error {
+		calls++
+		return nil
+	}})
+
+	// Not due until an hour after registering
+	if ran, _ := runner.RunDue(context.Background()); ran != 0 {
+		t.Fatalf("expected nothing due yet, ran %d", ran)
+	}
+
+	jobs.Trigger(app.DB, "count", time.Now())
+	ran, err := runner.RunDue(context.Background())
+	if err != nil {
+		t.Fatalf("RunDue: %v", err)
+	}
+	if ran != 1 || calls != 1 {
+		t.Fatalf("expected the triggered job to run once, ran %d with %d calls", ran, calls)
+	}
+
+	job, _ := jobs.GetJob(app.DB, "count")
+	if job.LastStatus != jobs.RunSucceeded {
+		t.Errorf("expected last status succeeded, got %q", job.LastStatus)
+	}
+	if job.NextRunAt == nil || time.Until(*job.NextRunAt) < 59*time.Minute {
+		t.Errorf("expected the next run in an hour, got %v", job.NextRunAt)
+	}
+
+	runs, _ := jobs.ListRuns(app.DB, "count", false, 10)
+	if len(runs) != 1 || runs[0].Status != jobs.RunSucceeded || runs[0].FinishedAt == nil {
+		t.Errorf("expected a finished run to be recorded, got %+v", runs)
+	}
+}
+
+func TestRunner_KeepsNextRunAcrossRestarts(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	noop := func(ctx context.Context) error { return nil }
+	jobs.NewRunner(app.DB).Register(jobs.Job{Name: "daily", Schedule: "@every 24h", Run: noop})
+	first, _ := jobs.GetJob(app.DB, "daily")
+
+	time.Sleep(1100 * time.Millisecond)
+	jobs.NewRunner(app.DB).Register(jobs.Job{Name: "daily", Schedule: "@every 24h", Run: noop})
+	second, _ := jobs.GetJob(app.DB, "daily")
+	if !second.NextRunAt.Equal(*first.NextRunAt) {
+		t.Errorf("expected the next run to be kept, got %v then %v", first.NextRunAt, second.NextRunAt)
+	}
+
+	jobs.NewRunner(app.DB).Register(jobs.Job{Name: "daily", Schedule: "@every 1h", Run: noop})
+	third, _ := jobs.GetJob(app.DB, "daily")
+	if !third.NextRunAt.Before(*first.NextRunAt) {
+		t.Errorf("expected a new schedule to reset the next run, got %v", third.NextRunAt)
+	}
+}
+
+func TestRunner_RetriesWithBackoff(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	runner := jobs.NewRunner(app.DB)
+	runner.Register(jobs.Job{Name: "flaky", Retries: 2, Run: func(ctx context.Context) error {
+		return errors.New("disk full")
+	}})
+
+	jobs.Trigger(app.DB, "flaky", time.Now())
+	runner.RunDue(context.Background())
+
+	job, _ := jobs.GetJob(app.DB, "flaky")
+	if job.Failures != 1 || job.NextRunAt == nil {
+		t.Fatalf("expected a retry to be scheduled, got %+v", job)
+	}
+	if wait := time.Until(*job.NextRunAt); wait < 25*time.Second || wait > 35*time.Second {
+		t.Errorf("expected the first retry in 30s, got %v", wait)
+	}
+
+	// Bring the retries forward rather than wait for them
+	for range 2 {
+		jobs.Trigger(app.DB, "flaky", time.Now())
+		runner.RunDue(context.Background())
+	}
+
+	job, _ = jobs.GetJob(app.DB, "flaky")
+	if job.Failures != 0 || job.NextRunAt != nil || job.LastStatus != jobs.RunFailed {
+		t.Errorf("expected the on-demand job to give up after its retries, got %+v", job)
+	}
+
+	runs, _ := jobs.ListRuns(app.DB, "", true, 10)
+	if len(runs) != 3 || runs[0].Attempt != 3 || runs[0].Error != "disk full" {
+		t.Errorf("expected 3 failed attempts, got %+v", runs)
+	}
+}
+
+func TestRunner_RecoversFromPanics(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	runner := jobs.NewRunner(app.DB)
+	runner.Register(jobs.Job{Name: "broken", Run: func(ctx context.Context) error {
+		panic("nil map")
+	}})
+
+	jobs.Trigger(app.DB, "broken", time.Now())
+	if _, err := runner.RunDue(context.Background()); err != nil {
+		t.Fatalf("RunDue: %v", err)
+	}
+
+	runs, _ := jobs.ListRuns(app.DB, "broken", false, 10)
+	if len(runs) != 1 || runs[0].Status != jobs.RunFailed || !strings.Contains(runs[0].Error, "nil map") {
+		t.Errorf("expected the panic to be recorded as a failure, got %+v", runs)
+	}
+}
+
+func TestRunner_SingleRunnerLock(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	calls := 0
+	job := jobs.Job{Name: "exclusive", Run: func(ctx context.Context) error {
+		calls++
+		return nil
+	}}
+	first := jobs.NewRunner(app.DB)
+	first.Register(job)
+	second := jobs.NewRunner(app.DB)
+	second.Register(job)
+
+	first.RunDue(context.Background())
+	jobs.Trigger(app.DB, "exclusive", time.Now())
+
+	if ran, _ := second.RunDue(context.Background()); ran != 0 {
+		t.Errorf("expected the second runner to wait for the lease, ran %d", ran)
+	}
+	if ran, _ := first.RunDue(context.Background()); ran != 1 {
+		t.Errorf("expected the lease holder to run the job, ran %d", ran)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+}
+
+func TestRunner_StopsWhenLeaseIsLost(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	calls := 0
+	runner := jobs.NewRunner(app.DB)
+	// The first job runs long enough for another process to take the lease
+	runner.Register(jobs.Job{Name: "a-slow", Run: func(ctx context.Context) error {
+		calls++
+		_, err := app.DB.Exec(`UPDATE job_lock SET owner = 'other', expires_at = ?`,
+			time.Now().Add(time.Hour).UTC().Format(dates.TimestampLayout))
+		return err
+	}})
+	runner.Register(jobs.Job{Name: "b-next", Run: func(ctx context.Context) error {
+		calls++
+		return nil
+	}})
+
+	jobs.Trigger(app.DB, "a-slow", time.Now())
+	jobs.Trigger(app.DB, "b-next", time.Now())
+
+	ran, err := runner.RunDue(context.Background())
+	if err != nil {
+		t.Fatalf("RunDue: %v", err)
+	}
+	if ran != 1 || calls != 1 {
+		t.Errorf("expected the runner to stop after losing the lease, ran %d with %d calls", ran, calls)
+	}
+	if job, _ := jobs.GetJob(app.DB, "b-next"); job.LastRunAt != nil {
+		t.Errorf("expected the second job not to run, last ran %v", job.LastRunAt)
+	}
+}
+
+func TestHandleIndex(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	runner := jobs.NewRunner(app.DB)
+	runner.Register(jobs.Job{Name: "nightly.report", Schedule: "30 3 * * *", Run: func(ctx context.Context) error {
+		return errors.New("mail server unreachable")
+	}})
+	runner.Register(jobs.Job{Name: "cleanup", Run: func(ctx context.Context) error { return nil }})
+	jobs.Trigger(app.DB, "nightly.report", time.Now())
+	jobs.Trigger(app.DB, "cleanup", time.Now())
+	runner.RunDue(context.Background())
+
+	resp := app.Request("GET", "/jobs?failed=1", "")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "30 3 * * *") || !strings.Contains(body, "On demand") {
+		t.Error("expected the jobs with their schedules")
+	}
+	if !strings.Contains(body, "mail server unreachable") {
+		t.Error("expected the failed run with its error")
+	}
+	if strings.Count(body, "bg-green-100") != 1 {
+		t.Error("expected successful runs to be filtered out of the log")
+	}
+}
+
+func TestHandleRunNow(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	jobs.NewRunner(app.DB).Register(jobs.Job{Name: "backup", Run: func(ctx context.Context) error { return nil }})
+
+	resp := app.HTMXRequest("POST", "/jobs/backup/run", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+	job, _ := jobs.GetJob(app.DB, "backup")
+	if job.NextRunAt == nil {
+		t.Error("expected the job to be due")
+	}
+
+	resp = app.HTMXRequest("POST", "/jobs/missing/run", "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,68 @@
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+// Func does a job's work. It should return promptly once ctx is done.
+type Func func(ctx context.Context) error
+
+// Job is a unit of background work registered with a Runner
+type Job struct {
+	Name     string
+	Schedule string        // Cron expression or "@every <duration>"; empty for jobs that only run when triggered
+	Retries  int           // Failed runs retried with backoff before waiting for the next scheduled time
+	Timeout  time.Duration // How long a run may take; defaults to DefaultTimeout
+	Run      Func
+}
+
+// RunStatus is how a job run went
+type RunStatus string
+
+const (
+	RunRunning   RunStatus = "running"
+	RunSucceeded RunStatus = "succeeded"
+	RunFailed    RunStatus = "failed"
+)
+
+// JobState is a registered job as recorded in the database
+type JobState struct {
+	Name       string
+	Schedule   string
+	NextRunAt  *time.Time // nil for triggered jobs that aren't due
+	Failures   int        // Consecutive failed runs
+	LastRunAt  *time.Time
+	LastStatus RunStatus // Empty until the job has run
+}
+
+// Run is one run of a job
+type Run struct {
+	ID         int64
+	JobName    string
+	Attempt    int // 1 for the scheduled run, higher for retries
+	Status     RunStatus
+	StartedAt  time.Time
+	FinishedAt *time.Time
+	Error      string
+}
+
+// Duration returns how long a finished run took
+func (r Run) Duration() time.Duration {
+	if r.FinishedAt == nil {
+		return 0
+	}
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// LockHolder is the process allowed to run jobs
+type LockHolder struct {
+	Owner     string
+	ExpiresAt time.Time
+}
+
+// RunFilter narrows the run log. Zero values mean "any".
+type RunFilter struct {
+	Job        string
+	FailedOnly bool
+}
@@ -0,0 +1,304 @@
+package jobs
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"phobos/internal/shared/dates"
+)
+
+// ErrJobNotFound is returned when triggering a job that isn't registered
+var ErrJobNotFound = errors.New("job not found")
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(dates.TimestampLayout)
+}
+
+// nullTime returns a stored time, or nil for the zero time
+func nullTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return formatTime(t)
+}
+
+// saveJob records a registered job. A job whose schedule hasn't changed
+// keeps its next run time, so restarts don't reset it; next applies otherwise.
+func saveJob(db *sql.DB, name, schedule string, next time.Time) error {
+	_, err := db.Exec(`
+		INSERT INTO jobs (name, schedule, next_run_at) VALUES (?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET
+			schedule = excluded.schedule,
+			next_run_at = CASE
+				WHEN jobs.schedule = excluded.schedule AND jobs.next_run_at IS NOT NULL THEN jobs.next_run_at
+				ELSE excluded.next_run_at
+			END
+	`, name, schedule, nullTime(next))
+	if err != nil {
+		return fmt.Errorf("failed to save job: %w", err)
+	}
+	return nil
+}
+
+// removeUnregistered deletes the records of jobs no longer registered. Their
+// run history is kept until pruned.
+func removeUnregistered(db *sql.DB, names []string) error {
+	args := make([]any, len(names))
+	for i, name := range names {
+		args[i] = name
+	}
+
+	query := `DELETE FROM jobs`
+	if len(names) > 0 {
+		query += ` WHERE name NOT IN (?` + strings.Repeat(", ?", len(names)-1) + `)`
+	}
+	if _, err := db.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to remove old jobs: %w", err)
+	}
+	return nil
+}
+
+// ListJobs returns every registered job by name
+func ListJobs(db *sql.DB) ([]JobState, error) {
+	rows, err := db.Query(`
+		SELECT name, schedule, next_run_at, failures, last_run_at, COALESCE(last_status, '')
+		FROM jobs
+		ORDER BY name ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []JobState
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+
+	return jobs, rows.Err()
+}
+
+// GetJob returns a registered job, or nil if there is none by that name
+func GetJob(db *sql.DB, name string) (*JobState, error) {
+	j, err := scanJob(db.QueryRow(`
+		SELECT name, schedule, next_run_at, failures, last_run_at, COALESCE(last_status, '')
+		FROM jobs
+		WHERE name = ?
+	`, name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+func scanJob(row interface{ Scan(...any) error }) (JobState, error) {
+	var j JobState
+	var nextRunAt, lastRunAt sql.NullTime
+	if err := row.Scan(&j.Name, &j.Schedule, &nextRunAt, &j.Failures, &lastRunAt, &j.LastStatus); err != nil {
+		if err == sql.ErrNoRows {
+			return j, err
+		}
+		return j, fmt.Errorf("failed to scan job: %w", err)
+	}
+	if nextRunAt.Valid {
+		j.NextRunAt = &nextRunAt.Time
+	}
+	if lastRunAt.Valid {
+		j.LastRunAt = &lastRunAt.Time
+	}
+	return j, nil
+}
+
+// Trigger makes a job due at the given time, bringing forward its next
+// scheduled run. It is how deferred work is queued, and how "Run now" works.
+func Trigger(db *sql.DB, name string, at time.Time) error {
+	result, err := db.Exec(`
+		UPDATE jobs
+		SET next_run_at = CASE WHEN next_run_at IS NULL OR next_run_at > ? THEN ? ELSE next_run_at END
+		WHERE name = ?
+	`, formatTime(at), formatTime(at), name)
+	if err != nil {
+		return fmt.Errorf("failed to trigger job: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrJobNotFound
+	}
+	return nil
+}
+
+// dueJobs returns the names of jobs whose next run is due, most overdue first
+func dueJobs(db *sql.DB, now time.Time) ([]string, error) {
+	rows, err := db.Query(`
+		SELECT name FROM jobs
+		WHERE next_run_at IS NOT NULL AND next_run_at <= ?
+		ORDER BY next_run_at ASC, name ASC
+	`, formatTime(now))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan due job: %w", err)
+		}
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
+// startRun records the start of a run and returns its ID
+func startRun(db *sql.DB, name string, attempt int, now time.Time) (int64, error) {
+	result, err := db.Exec(`
+		INSERT INTO job_runs (job_name, attempt, status, started_at) VALUES (?, ?, ?, ?)
+	`, name, attempt, RunRunning, formatTime(now))
+	if err != nil {
+		return 0, fmt.Errorf("failed to record job run: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// finishRun records the outcome of a run and when the job runs next. A zero
+// next leaves a triggered job idle until it is triggered again.
+func finishRun(db *sql.DB, runID int64, name string, status RunStatus, errMsg string, failures int, now, next time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE job_runs SET status = ?, finished_at = ?, error = NULLIF(?, '') WHERE id = ?
+	`, status, formatTime(now), errMsg, runID)
+	if err != nil {
+		return fmt.Errorf("failed to finish job run: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE jobs SET next_run_at = ?, failures = ?, last_run_at = ?, last_status = ? WHERE name = ?
+	`, nullTime(next), failures, formatTime(now), status, name)
+	if err != nil {
+		return fmt.Errorf("failed to update job: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// markInterrupted fails runs left running by a process that stopped
+// without finishing them
+func markInterrupted(db *sql.DB, now time.Time) error {
+	_, err := db.Exec(`
+		UPDATE job_runs SET status = ?, finished_at = ?, error = 'interrupted: the server stopped during the run'
+		WHERE status = ?
+	`, RunFailed, formatTime(now), RunRunning)
+	if err != nil {
+		return fmt.Errorf("failed to mark interrupted job runs: %w", err)
+	}
+	return nil
+}
+
+// ListRuns returns the most recent runs, newest first, optionally only of one
+// job or only failures
+func ListRuns(db *sql.DB, name string, failedOnly bool, limit int) ([]Run, error) {
+	status := ""
+	if failedOnly {
+		status = string(RunFailed)
+	}
+
+	rows, err := db.Query(`
+		SELECT id, job_name, attempt, status, started_at, finished_at, COALESCE(error, '')
+		FROM job_runs
+		WHERE (? = '' OR job_name = ?) AND (? = '' OR status = ?)
+		ORDER BY id DESC
+		LIMIT ?
+	`, name, name, status, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []Run
+	for rows.Next() {
+		var r Run
+		var finishedAt sql.NullTime
+		if err := rows.Scan(&r.ID, &r.JobName, &r.Attempt, &r.Status, &r.StartedAt, &finishedAt, &r.Error); err != nil {
+			return nil, fmt.Errorf("failed to scan job run: %w", err)
+		}
+		if finishedAt.Valid {
+			r.FinishedAt = &finishedAt.Time
+		}
+		runs = append(runs, r)
+	}
+
+	return runs, rows.Err()
+}
+
+// PruneRuns deletes finished runs started before the given time and returns
+// how many were deleted
+func PruneRuns(db *sql.DB, before time.Time) (int64, error) {
+	result, err := db.Exec(`
+		DELETE FROM job_runs WHERE status != ? AND started_at < ?
+	`, RunRunning, formatTime(before))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune job runs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// acquireLock takes or renews the lease on running jobs. It reports false
+// while another process holds an unexpired lease.
+func acquireLock(db *sql.DB, owner string, now time.Time, ttl time.Duration) (bool, error) {
+	result, err := db.Exec(`
+		INSERT INTO job_lock (id, owner, expires_at) VALUES (1, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET owner = excluded.owner, expires_at = excluded.expires_at
+		WHERE job_lock.owner = excluded.owner OR job_lock.expires_at <= ?
+	`, owner, formatTime(now.Add(ttl)), formatTime(now))
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire job lock: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+// releaseLock gives up the lease so another process can take over at once
+func releaseLock(db *sql.DB, owner string) error {
+	if _, err := db.Exec(`DELETE FROM job_lock WHERE owner = ?`, owner); err != nil {
+		return fmt.Errorf("failed to release job lock: %w", err)
+	}
+	return nil
+}
+
+// GetLockHolder returns the process holding the lease, or nil if none does
+func GetLockHolder(db *sql.DB, now time.Time) (*LockHolder, error) {
+	var h LockHolder
+	err := db.QueryRow(`
+		SELECT owner, expires_at FROM job_lock WHERE id = 1 AND expires_at > ?
+	`, formatTime(now)).Scan(&h.Owner, &h.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job lock: %w", err)
+	}
+	return &h, nil
+}
@@ -0,0 +1,9 @@
+package jobs
+
+import "github.com/gofiber/fiber/v2"
+
+// RegisterRoutes sets up the background jobs admin page
+func RegisterRoutes(app *fiber.App) {
+	app.Get("/jobs", HandleIndex)
+	app.Post("/jobs/:name/run", HandleRunNow)
+}
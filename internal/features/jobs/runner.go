@@ -0,0 +1,232 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"phobos/internal/shared/backoff"
+	"phobos/internal/shared/cron"
+)
+
+const (
+	// DefaultTimeout is how long a run may take unless its job says otherwise
+	DefaultTimeout = 4 * time.Minute
+
+	// tickInterval is how often the runner looks for due jobs. Schedules
+	// finer than this run at most once a tick.
+	tickInterval = 5 * time.Second
+	// lockTTL is how long the lease lasts without renewal. It outlasts
+	// DefaultTimeout so a run can't lose the lease partway through.
+	lockTTL = 5 * time.Minute
+
+	firstRetry = 30 * time.Second
+	maxRetry   = time.Hour
+)
+
+// RetryDelay returns how long to wait before retrying a job that has failed
+// the given number of times in a row: 30 seconds, doubling up to an hour
+func RetryDelay(failures int) time.Duration {
+	return backoff.Delay(failures, firstRetry, maxRetry)
+}
+
+// registered is a job with its parsed schedule
+type registered struct {
+	Job
+	schedule cron.Schedule // nil for triggered jobs
+}
+
+// Runner runs registered jobs on their schedules. Any number of server
+// processes may share a database; a lease in job_lock makes sure only one of
+// them runs jobs at a time.
+type Runner struct {
+	db      *sql.DB
+	owner   string
+	jobs    map[string]*registered
+	names   []string
+	holding bool
+}
+
+// NewRunner returns a runner for jobs recorded in db
+func NewRunner(db *sql.DB) *Runner {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	rand.Read(b)
+
+	return &Runner{
+		db:    db,
+		owner: fmt.Sprintf("%s:%d:%s", host, os.Getpid(), hex.EncodeToString(b)),
+		jobs:  make(map[string]*registered),
+	}
+}
+
+// Register adds a job and records it in the database. A new or rescheduled
+// job first runs at its next scheduled time; jobs without a schedule wait to
+// be triggered.
+func (r *Runner) Register(job Job) error {
+	if job.Name == "" || job.Run == nil {
+		return fmt.Errorf("job needs a name and a function")
+	}
+	if _, ok := r.jobs[job.Name]; ok {
+		return fmt.Errorf("job %s is already registered", job.Name)
+	}
+	if job.Timeout == 0 {
+		job.Timeout = DefaultTimeout
+	}
+
+	reg := &registered{Job: job}
+	var next time.Time
+	if job.Schedule != "" {
+		s, err := cron.Parse(job.Schedule)
+		if err != nil {
+			return fmt.Errorf("job %s: %w", job.Name, err)
+		}
+		reg.schedule = s
+		next = s.Next(time.Now())
+	}
+
+	if err := saveJob(r.db, job.Name, job.Schedule, next); err != nil {
+		return err
+	}
+
+	r.jobs[job.Name] = reg
+	r.names = append(r.names, job.Name)
+	return nil
+}
+
+// Start forgets jobs that are no longer registered, then runs due jobs every
+// few seconds until ctx is cancelled, when it gives up the lease
+func (r *Runner) Start(ctx context.Context) error {
+	if err := removeUnregistered(r.db, r.names); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(tickInterval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := r.RunDue(ctx); err != nil && ctx.Err() == nil {
+				slog.Error("Job run failed", "error", err)
+			}
+			select {
+			case <-ctx.Done():
+				releaseLock(r.db, r.owner)
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// RunDue runs every job that is due, one at a time, if this runner holds or
+// can take the lease. It returns how many jobs it ran.
+func (r *Runner) RunDue(ctx context.Context) (int, error) {
+	held, err := acquireLock(r.db, r.owner, time.Now(), lockTTL)
+	if err != nil {
+		return 0, err
+	}
+	if !held {
+		r.holding = false
+		return 0, nil
+	}
+	if !r.holding {
+		// Runs still marked running belong to a process that lost the lease
+		if err := markInterrupted(r.db, time.Now()); err != nil {
+			return 0, err
+		}
+		r.holding = true
+	}
+
+	names, err := dueJobs(r.db, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	ran := 0
+	for _, name := range names {
+		job, ok := r.jobs[name]
+		if !ok {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ran, ctx.Err()
+		}
+		// A long job may have outlasted the lease; stop if another process
+		// has taken it, or the next job could run twice
+		held, err := acquireLock(r.db, r.owner, time.Now(), lockTTL)
+		if err != nil {
+			return ran, err
+		}
+		if !held {
+			r.holding = false
+			return ran, nil
+		}
+		if err := r.run(ctx, job); err != nil {
+			return ran, err
+		}
+		ran++
+	}
+	return ran, nil
+}
+
+// run runs a job once and schedules its next run: a retry after a failure
+// with retries left, otherwise its next scheduled time
+func (r *Runner) run(ctx context.Context, job *registered) error {
+	state, err := GetJob(r.db, job.Name)
+	if err != nil {
+		return err
+	}
+	// Skip a job that is no longer due, such as one that ran elsewhere
+	// since dueJobs listed it
+	if state == nil || state.NextRunAt == nil || state.NextRunAt.After(time.Now()) {
+		return nil
+	}
+
+	runID, err := startRun(r.db, job.Name, state.Failures+1, time.Now())
+	if err != nil {
+		return err
+	}
+
+	runErr := call(ctx, job)
+	now := time.Now()
+
+	var next time.Time
+	if job.schedule != nil {
+		next = job.schedule.Next(now)
+	}
+
+	if runErr == nil {
+		return finishRun(r.db, runID, job.Name, RunSucceeded, "", 0, now, next)
+	}
+
+	failures := state.Failures + 1
+	if failures <= job.Retries {
+		if retry := now.Add(RetryDelay(failures)); next.IsZero() || retry.Before(next) {
+			next = retry
+		}
+	} else {
+		failures = 0
+	}
+	return finishRun(r.db, runID, job.Name, RunFailed, runErr.Error(), failures, now, next)
+}
+
+// call runs a job's function with its timeout, turning a panic into an error
+// so one broken job can't stop the rest
+func call(ctx context.Context, job *registered) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, job.Timeout)
+	defer cancel()
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return job.Run(ctx)
+}
@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"phobos/internal/shared/dates"
+	"phobos/internal/shared/htmx"
+	"phobos/internal/shared/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Trend ranges offered on the metrics page, in days
+var trendRanges = []int{30, 90, 365}
+
+// recentEntriesLimit is how many logged entries the metrics page lists
+const recentEntriesLimit = 20
+
+// HandleIndex displays the log form, trend charts and recent entries
+func HandleIndex(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	days := c.QueryInt("days", 90)
+	if days < 1 {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid range")
+	}
+
+	today := time.Now()
+	trends, err := GetTrends(db, today.AddDate(0, 0, -days))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load trends")
+	}
+
+	recent, err := ListRecent(db, recentEntriesLimit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load entries")
+	}
+
+	return htmx.Render(c, MetricsPage(today, days, trends, recent))
+}
+
+// HandleCreate logs any metrics filled in on the form for the given date
+func HandleCreate(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	date := time.Now()
+	if d := c.FormValue("date"); d != "" {
+		parsed, err := time.Parse(dates.Layout, d)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid date")
+		}
+		date = parsed
+	}
+
+	values := make(map[string]float64)
+	for _, metric := range MetricTypes {
+		raw := strings.TrimSpace(c.FormValue("value_" + metric))
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || value <= 0 || (metric == MetricBodyFat && value >= 100) {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid value for " + MetricLabel(metric))
+		}
+		values[metric] = value
+	}
+	if len(values) == 0 {
+		return c.Status(fiber.StatusBadRequest).SendString("Enter at least one measurement")
+	}
+
+	if err := SaveEntries(db, date, values); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to save measurements")
+	}
+
+	return htmx.Refresh(c)
+}
+
+// HandleDelete removes a logged entry
+func HandleDelete(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	if err := Delete(db, id); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to delete entry")
+	}
+
+	return c.SendString("")
+}
@@ -0,0 +1,168 @@
+package metrics_test
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"phobos/internal/features/exercises"
+	"phobos/internal/features/metrics"
+	"phobos/internal/features/workouts"
+	"phobos/internal/testutil"
+)
+
+func TestHandleIndex_Empty(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	resp := app.Request("GET", "/metrics", "")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "Body Metrics") {
+		t.Error("expected page to contain 'Body Metrics'")
+	}
+	if !strings.Contains(body, "No measurements") {
+		t.Error("expected empty state")
+	}
+}
+
+func TestHandleCreate(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	resp := app.HTMXRequest("POST", "/metrics", "date=2024-05-01&value_bodyweight=181.5&value_waist=33&value_chest=")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	entries, _ := metrics.ListRecent(app.DB, 10)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	// Logging the same metric on the same day replaces it
+	app.HTMXRequest("POST", "/metrics", "date=2024-05-01&value_bodyweight=180")
+	weight, _ := metrics.BodyweightOn(app.DB, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	if weight == nil || *weight != 180 {
+		t.Errorf("expected bodyweight 180, got %v", weight)
+	}
+}
+
+func TestHandleCreate_Invalid(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	for _, body := range []string{"", "value_body_fat=120", "value_bodyweight=-5", "date=yesterday&value_bodyweight=180"} {
+		resp := app.HTMXRequest("POST", "/metrics", body)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%q: expected status 400, got %d", body, resp.StatusCode)
+		}
+	}
+}
+
+func TestHandleDelete(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	metrics.SaveEntries(app.DB, time.Now(), map[string]float64{metrics.MetricBodyweight: 180})
+	entries, _ := metrics.ListRecent(app.DB, 10)
+
+	resp := app.HTMXRequest("DELETE", "/metrics/"+strconv.FormatInt(entries[0].ID, 10), "")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+	if entries, _ := metrics.ListRecent(app.DB, 10); len(entries) != 0 {
+		t.Errorf("expected entry to be deleted, got %d", len(entries))
+	}
+}
+
+func TestMovingAverage(t *testing.T) {
+	t.Parallel()
+
+	day := func(n int) time.Time { return time.Date(2024, 1, n, 0, 0, 0, 0, time.UTC) }
+	entries := []metrics.Entry{
+		{Date: day(1), Value: 180},
+		{Date: day(3), Value: 182},
+		{Date: day(7), Value: 184},
+		{Date: day(8), Value: 186}, // Day 1 leaves the 7-day window
+	}
+
+	points := metrics.MovingAverage(entries)
+
+	want := []float64{180, 181, 182, 184}
+	for i, p := range points {
+		if p.Average != want[i] {
+			t.Errorf("point %d: expected average %v, got %v", i, want[i], p.Average)
+		}
+	}
+}
+
+func TestGetTrends(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	now := time.Now()
+	metrics.SaveEntries(app.DB, now.AddDate(0, 0, -40), map[string]float64{metrics.MetricBodyweight: 190})
+	metrics.SaveEntries(app.DB, now.AddDate(0, 0, -2), map[string]float64{metrics.MetricBodyweight: 180, metrics.MetricBodyFat: 15})
+	metrics.SaveEntries(app.DB, now, map[string]float64{metrics.MetricBodyweight: 182})
+
+	trends, err := metrics.GetTrends(app.DB, now.AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatalf("failed to get trends: %v", err)
+	}
+	if len(trends) != 2 || trends[0].Metric != metrics.MetricBodyweight || trends[1].Metric != metrics.MetricBodyFat {
+		t.Fatalf("expected bodyweight then body fat trends, got %v", trends)
+	}
+	if len(trends[0].Points) != 2 {
+		t.Errorf("expected entries outside the range to be left out, got %d points", len(trends[0].Points))
+	}
+	if latest := trends[0].Latest(); latest == nil || latest.Value != 182 {
+		t.Errorf("expected latest bodyweight 182, got %v", latest)
+	}
+}
+
+func TestWorkoutShowsRelativeStrength(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	date := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	metrics.SaveEntries(app.DB, date.AddDate(0, 0, -1), map[string]float64{metrics.MetricBodyweight: 200})
+	metrics.SaveEntries(app.DB, date.AddDate(0, 0, 1), map[string]float64{metrics.MetricBodyweight: 150}) // After the workout
+
+	squatID, _ := exercises.Create(app.DB, "Squat")
+	pullupID, _ := exercises.Create(app.DB, "Pull-up")
+	exercises.SetTags(app.DB, pullupID, []string{"back"}, nil, []string{"bodyweight"})
+
+	workoutID, _ := workouts.Create(app.DB, "Session", date, nil)
+	squatWeID, _ := workouts.AddExercise(app.DB, workoutID, squatID)
+	workouts.AddSet(app.DB, squatWeID, 5, 300)
+	workouts.AddExercise(app.DB, workoutID, pullupID)
+
+	w, _ := workouts.GetByID(app.DB, workoutID)
+	if w.Bodyweight == nil || *w.Bodyweight != 200 {
+		t.Fatalf("expected bodyweight as of the workout date, got %v", w.Bodyweight)
+	}
+
+	resp := app.Request("GET", "/workouts/"+strconv.FormatInt(workoutID, 10), "")
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "1.50") {
+		t.Error("expected squat top set relative to bodyweight")
+	}
+	if !strings.Contains(body, "Bodyweight: 200.0 lbs") {
+		t.Error("expected bodyweight shown for the bodyweight exercise")
+	}
+}
@@ -0,0 +1,111 @@
+package metrics
+
+import "time"
+
+// Metric keys
+const (
+	MetricBodyweight = "bodyweight"
+	MetricBodyFat    = "body_fat"
+)
+
+// MetricTypes lists the metrics that can be logged, in display order
+var MetricTypes = []string{
+	MetricBodyweight, MetricBodyFat,
+	"neck", "chest", "waist", "hips", "arms", "thighs", "calves",
+}
+
+var metricLabels = map[string]string{
+	MetricBodyweight: "Bodyweight",
+	MetricBodyFat:    "Body fat",
+	"neck":           "Neck",
+	"chest":          "Chest",
+	"waist":          "Waist",
+	"hips":           "Hips",
+	"arms":           "Arms",
+	"thighs":         "Thighs",
+	"calves":         "Calves",
+}
+
+// MetricLabel returns the display name of a metric
+func MetricLabel(metric string) string {
+	if label, ok := metricLabels[metric]; ok {
+		return label
+	}
+	return metric
+}
+
+// MetricUnit returns the unit a metric is logged in. Circumferences are in inches.
+func MetricUnit(metric string) string {
+	switch metric {
+	case MetricBodyweight:
+		return "lbs"
+	case MetricBodyFat:
+		return "%"
+	default:
+		return "in"
+	}
+}
+
+// IsMetric reports whether metric is one of MetricTypes
+func IsMetric(metric string) bool {
+	_, ok := metricLabels[metric]
+	return ok
+}
+
+// MovingAverageDays is the trailing window used to smooth trends
+const MovingAverageDays = 7
+
+// Entry is a single logged measurement
+type Entry struct {
+	ID     int64
+	Date   time.Time
+	Metric string
+	Value  float64
+}
+
+// TrendPoint is a logged value with the moving average up to that date
+type TrendPoint struct {
+	Date    time.Time
+	Value   float64
+	Average float64
+}
+
+// Trend is the history of one metric, oldest first
+type Trend struct {
+	Metric string
+	Points []TrendPoint
+}
+
+// Latest returns the most recent point, or nil if nothing was logged
+func (t Trend) Latest() *TrendPoint {
+	if len(t.Points) == 0 {
+		return nil
+	}
+	return &t.Points[len(t.Points)-1]
+}
+
+// Change returns how much the moving average moved across the trend
+func (t Trend) Change() float64 {
+	if len(t.Points) < 2 {
+		return 0
+	}
+	return t.Points[len(t.Points)-1].Average - t.Points[0].Average
+}
+
+// MovingAverage pairs each entry (oldest first) with the mean of the entries
+// logged within the MovingAverageDays ending on its date
+func MovingAverage(entries []Entry) []TrendPoint {
+	points := make([]TrendPoint, len(entries))
+	start := 0
+	sum := 0.0
+	for i, e := range entries {
+		sum += e.Value
+		windowStart := e.Date.AddDate(0, 0, -(MovingAverageDays - 1))
+		for entries[start].Date.Before(windowStart) {
+			sum -= entries[start].Value
+			start++
+		}
+		points[i] = TrendPoint{Date: e.Date, Value: e.Value, Average: sum / float64(i-start+1)}
+	}
+	return points
+}
@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"phobos/internal/shared/dates"
+)
+
+// SaveEntries logs values for several metrics on one date, replacing any
+// values already logged for those metrics that day
+func SaveEntries(db *sql.DB, date time.Time, values map[string]float64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for metric, value := range values {
+		_, err := tx.Exec(`
+			INSERT INTO body_metrics (date, metric, value)
+			VALUES (?, ?, ?)
+			ON CONFLICT (date, metric) DO UPDATE SET value = excluded.value
+		`, date.Format(dates.Layout), metric, value)
+		if err != nil {
+			return fmt.Errorf("failed to save %s: %w", metric, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a logged entry by ID
+func Delete(db *sql.DB, id int64) error {
+	_, err := db.Exec(`DELETE FROM body_metrics WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete metric entry: %w", err)
+	}
+	return nil
+}
+
+// ListRecent returns the most recently dated entries across all metrics
+func ListRecent(db *sql.DB, limit int) ([]Entry, error) {
+	return queryEntries(db, `
+		SELECT id, date, metric, value
+		FROM body_metrics
+		ORDER BY date DESC, id DESC
+		LIMIT ?
+	`, limit)
+}
+
+// GetTrends returns the trend of every metric logged on or after from,
+// in MetricTypes order. Metrics with no entries are left out.
+func GetTrends(db *sql.DB, from time.Time) ([]Trend, error) {
+	// Start the query a window early so the first averages are not cut short
+	entries, err := queryEntries(db, `
+		SELECT id, date, metric, value
+		FROM body_metrics
+		WHERE date >= ?
+		ORDER BY date ASC
+	`, from.AddDate(0, 0, -(MovingAverageDays-1)).Format(dates.Layout))
+	if err != nil {
+		return nil, err
+	}
+
+	byMetric := make(map[string][]Entry)
+	for _, e := range entries {
+		byMetric[e.Metric] = append(byMetric[e.Metric], e)
+	}
+
+	var trends []Trend
+	for _, metric := range MetricTypes {
+		points := MovingAverage(byMetric[metric])
+		for len(points) > 0 && points[0].Date.Before(from) {
+			points = points[1:]
+		}
+		if len(points) > 0 {
+			trends = append(trends, Trend{Metric: metric, Points: points})
+		}
+	}
+
+	return trends, nil
+}
+
+// rowQuerier is a *sql.DB or a *sql.Tx
+type rowQuerier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// BodyweightOn returns the latest bodyweight logged on or before date, or nil
+// if none was. It can run inside a caller's transaction.
+func BodyweightOn(db rowQuerier, date time.Time) (*float64, error) {
+	var weight float64
+	err := db.QueryRow(`
+		SELECT value
+		FROM body_metrics
+		WHERE metric = ? AND date <= ?
+		ORDER BY date DESC
+		LIMIT 1
+	`, MetricBodyweight, date.Format(dates.Layout)).Scan(&weight)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bodyweight: %w", err)
+	}
+
+	return &weight, nil
+}
+
+func queryEntries(db *sql.DB, query string, args ...interface{}) ([]Entry, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list metric entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(&e.ID, &e.Date, &e.Metric, &e.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan metric entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,10 @@
+package metrics
+
+import "github.com/gofiber/fiber/v2"
+
+// RegisterRoutes sets up body metrics routes
+func RegisterRoutes(app *fiber.App) {
+	app.Get("/metrics", HandleIndex)
+	app.Post("/metrics", HandleCreate)
+	app.Delete("/metrics/:id", HandleDelete)
+}
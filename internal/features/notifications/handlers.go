@@ -0,0 +1,51 @@
+package notifications
+
+import (
+	"phobos/internal/shared/htmx"
+	"phobos/internal/shared/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// recentLimit is how many notifications the notifications page shows
+const recentLimit = 50
+
+// HandleIndex lists recent notifications, highlighting unread ones
+func HandleIndex(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	notifications, err := ListRecent(db, recentLimit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load notifications")
+	}
+
+	unread, err := CountUnread(db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load notifications")
+	}
+
+	return htmx.Render(c, NotificationsPage(notifications, unread))
+}
+
+// HandleBadge renders the navigation bell with the unread count
+func HandleBadge(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	unread, err := CountUnread(db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to count notifications")
+	}
+
+	return htmx.Render(c, Badge(unread))
+}
+
+// HandleMarkRead marks every notification as read
+func HandleMarkRead(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	if err := MarkAllRead(db); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to mark notifications read")
+	}
+
+	return htmx.Refresh(c)
+}
@@ -0,0 +1,76 @@
+package notifications_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"phobos/internal/features/notifications"
+	"phobos/internal/testutil"
+)
+
+func TestHandleBadge(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	body := testutil.ReadBody(t, app.HTMXRequest("GET", "/notifications/badge", ""))
+	if strings.Contains(body, "bg-red-600") {
+		t.Error("expected no count without notifications")
+	}
+
+	notifications.Create(app.DB, "Coach assigned you the template Push", "/templates/1")
+	notifications.Create(app.DB, "Coach commented on Leg Day", "")
+
+	body = testutil.ReadBody(t, app.HTMXRequest("GET", "/notifications/badge", ""))
+	if !strings.Contains(body, "justify-center\">2</span>") {
+		t.Errorf("expected unread count 2, got %s", body)
+	}
+}
+
+func TestHandleIndex(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	notifications.Create(app.DB, "Coach assigned you the template Push", "/templates/1")
+
+	resp := app.Request("GET", "/notifications", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "Coach assigned you the template Push") {
+		t.Error("expected notification message")
+	}
+	if !strings.Contains(body, `href="/templates/1"`) {
+		t.Error("expected notification to link to the template")
+	}
+	if !strings.Contains(body, "Mark all as read") {
+		t.Error("expected mark as read button for unread notifications")
+	}
+}
+
+func TestHandleMarkRead(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	notifications.Create(app.DB, "Coach commented on Leg Day", "")
+
+	resp := app.HTMXRequest("POST", "/notifications/read", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	unread, _ := notifications.CountUnread(app.DB)
+	if unread != 0 {
+		t.Errorf("expected no unread notifications, got %d", unread)
+	}
+
+	list, _ := notifications.ListRecent(app.DB, 10)
+	if len(list) != 1 || list[0].Unread() {
+		t.Errorf("expected the notification to be kept and read, got %+v", list)
+	}
+}
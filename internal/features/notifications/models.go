@@ -0,0 +1,17 @@
+package notifications
+
+import "time"
+
+// Notification is a message for the athlete, such as a coach assigning a program
+type Notification struct {
+	ID        int64
+	Message   string
+	Link      string // Page the notification is about; may be empty
+	CreatedAt time.Time
+	ReadAt    *time.Time
+}
+
+// Unread reports whether the notification hasn't been seen yet
+func (n Notification) Unread() bool {
+	return n.ReadAt == nil
+}
@@ -0,0 +1,85 @@
+package notifications
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Create adds an unread notification. link may be empty.
+func Create(db *sql.DB, message, link string) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	id, err := CreateTx(tx, message, link)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return id, nil
+}
+
+// CreateTx adds an unread notification as part of a caller's transaction
+func CreateTx(tx *sql.Tx, message, link string) (int64, error) {
+	result, err := tx.Exec(`
+		INSERT INTO notifications (message, link) VALUES (?, NULLIF(?, ''))
+	`, message, link)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create notification: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// ListRecent returns the newest notifications, read or not
+func ListRecent(db *sql.DB, limit int) ([]Notification, error) {
+	rows, err := db.Query(`
+		SELECT id, message, COALESCE(link, ''), created_at, read_at
+		FROM notifications
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var notifications []Notification
+	for rows.Next() {
+		var n Notification
+		var readAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.Message, &n.Link, &n.CreatedAt, &readAt); err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		if readAt.Valid {
+			n.ReadAt = &readAt.Time
+		}
+		notifications = append(notifications, n)
+	}
+
+	return notifications, rows.Err()
+}
+
+// CountUnread returns how many notifications haven't been read
+func CountUnread(db *sql.DB) (int, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE read_at IS NULL`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// MarkAllRead marks every unread notification as read
+func MarkAllRead(db *sql.DB) error {
+	_, err := db.Exec(`UPDATE notifications SET read_at = CURRENT_TIMESTAMP WHERE read_at IS NULL`)
+	if err != nil {
+		return fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	return nil
+}
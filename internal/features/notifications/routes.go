@@ -0,0 +1,10 @@
+package notifications
+
+import "github.com/gofiber/fiber/v2"
+
+// RegisterRoutes sets up notification routes
+func RegisterRoutes(app *fiber.App) {
+	app.Get("/notifications", HandleIndex)
+	app.Get("/notifications/badge", HandleBadge)
+	app.Post("/notifications/read", HandleMarkRead)
+}
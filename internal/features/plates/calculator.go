@@ -0,0 +1,66 @@
+package plates
+
+import "math"
+
+// epsilon absorbs float error when subtracting fractional plates
+const epsilon = 1e-9
+
+// warmupRamp is each warm-up step after the empty bar, as a fraction of the
+// working weight and the reps to do at it
+var warmupRamp = []struct {
+	fraction float64
+	reps     int
+}{
+	{0.4, 5},
+	{0.6, 3},
+	{0.8, 2},
+}
+
+// emptyBarReps is the reps for the first warm-up set with the empty bar
+const emptyBarReps = 10
+
+// Calculate works out the plates to load on each side for a target weight,
+// heaviest first, using no more plates than the kit has
+func (k Kit) Calculate(target float64) Breakdown {
+	if target < k.BarWeight-epsilon {
+		return Breakdown{BelowBar: true}
+	}
+
+	var b Breakdown
+	side := (target - k.BarWeight) / 2
+	for _, p := range k.Plates {
+		for n := 0; n < p.Pairs && side >= p.Weight-epsilon; n++ {
+			b.PerSide = append(b.PerSide, p.Weight)
+			side -= p.Weight
+		}
+	}
+	if side > epsilon {
+		b.Remainder = math.Round(side*2*100) / 100
+	}
+	return b
+}
+
+// Loadable returns the heaviest weight at or below target the kit can load
+func (k Kit) Loadable(target float64) float64 {
+	return k.Calculate(target).Loaded(k)
+}
+
+// Warmups ramps from the empty bar up to a working weight. Each step is
+// rounded down to a loadable weight, and steps that would repeat a weight or
+// reach the working weight are dropped.
+func (k Kit) Warmups(working float64) []WarmupSet {
+	if working <= k.BarWeight+epsilon {
+		return nil
+	}
+
+	sets := []WarmupSet{{Reps: emptyBarReps, Weight: k.BarWeight}}
+	for _, step := range warmupRamp {
+		weight := k.Loadable(working * step.fraction)
+		last := sets[len(sets)-1].Weight
+		if weight <= last+epsilon || weight >= working-epsilon {
+			continue
+		}
+		sets = append(sets, WarmupSet{Reps: step.reps, Weight: weight})
+	}
+	return sets
+}
@@ -0,0 +1,68 @@
+package plates
+
+import (
+	"strconv"
+
+	"phobos/internal/shared/htmx"
+	"phobos/internal/shared/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HandleSettings displays the bar weight and plate inventory
+func HandleSettings(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	kit, err := GetKit(db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load plates")
+	}
+
+	return htmx.Render(c, SettingsPage(kit))
+}
+
+// HandleUpdateSettings saves the bar weight, the pair count for each plate
+// size and an optional new plate size
+func HandleUpdateSettings(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	kit, err := GetKit(db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load plates")
+	}
+
+	kit.BarWeight, err = strconv.ParseFloat(c.FormValue("bar_weight"), 64)
+	if err != nil || kit.BarWeight < 0 {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid bar weight")
+	}
+
+	for i, p := range kit.Plates {
+		pairs, err := strconv.Atoi(c.FormValue(pairsField(p.Weight)))
+		if err != nil || pairs < 0 {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid pairs for " + FormatWeight(p.Weight) + " lb plates")
+		}
+		kit.Plates[i].Pairs = pairs
+	}
+
+	if raw := c.FormValue("new_weight"); raw != "" {
+		weight, err := strconv.ParseFloat(raw, 64)
+		if err != nil || weight <= 0 {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid plate weight")
+		}
+		pairs, err := strconv.Atoi(c.FormValue("new_pairs"))
+		if err != nil || pairs < 1 {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid pairs")
+		}
+		kit.Plates = append(kit.Plates, Plate{Weight: weight, Pairs: pairs})
+	}
+
+	if err := SaveKit(db, kit); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to save plates")
+	}
+
+	return htmx.Refresh(c)
+}
+
+func pairsField(weight float64) string {
+	return "pairs_" + FormatWeight(weight)
+}
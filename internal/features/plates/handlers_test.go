@@ -0,0 +1,129 @@
+package plates_test
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"phobos/internal/features/plates"
+	"phobos/internal/testutil"
+)
+
+var testKit = plates.Kit{
+	BarWeight: 45,
+	Plates: []plates.Plate{
+		{Weight: 45, Pairs: 2},
+		{Weight: 25, Pairs: 1},
+		{Weight: 10, Pairs: 1},
+		{Weight: 5, Pairs: 1},
+		{Weight: 2.5, Pairs: 1},
+	},
+}
+
+func TestCalculate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		target    float64
+		perSide   []float64
+		remainder float64
+		below     bool
+	}{
+		{45, nil, 0, false},
+		{225, []float64{45, 45}, 0, false},
+		{185, []float64{45, 25}, 0, false},
+		{200, []float64{45, 25, 5, 2.5}, 0, false},
+		{310, []float64{45, 45, 25, 10, 5, 2.5}, 0, false},
+		{320, []float64{45, 45, 25, 10, 5, 2.5}, 10, false},
+		{137, []float64{45}, 2, false},
+		{30, nil, 0, true},
+	}
+	for _, tt := range tests {
+		b := testKit.Calculate(tt.target)
+		if !reflect.DeepEqual(b.PerSide, tt.perSide) || b.Remainder != tt.remainder || b.BelowBar != tt.below {
+			t.Errorf("%v: expected %v remainder %v below %v, got %v remainder %v below %v",
+				tt.target, tt.perSide, tt.remainder, tt.below, b.PerSide, b.Remainder, b.BelowBar)
+		}
+	}
+
+	if got := testKit.Calculate(200).String(); got != "45 + 25 + 5 + 2.5" {
+		t.Errorf("expected plate list, got %q", got)
+	}
+}
+
+func TestWarmups(t *testing.T) {
+	t.Parallel()
+
+	// Each step rounds down to what the kit can load: 90 becomes 80 with a
+	// single pair of 10s, and 180 becomes 170
+	got := testKit.Warmups(225)
+	want := []plates.WarmupSet{
+		{Reps: 10, Weight: 45},
+		{Reps: 5, Weight: 80},
+		{Reps: 3, Weight: 135},
+		{Reps: 2, Weight: 170},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// Steps that round to the same weight are dropped
+	got = testKit.Warmups(65)
+	want = []plates.WarmupSet{{Reps: 10, Weight: 45}, {Reps: 2, Weight: 50}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if got := testKit.Warmups(45); got != nil {
+		t.Errorf("expected no warm-ups for the empty bar, got %v", got)
+	}
+}
+
+func TestHandleUpdateSettings(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	form := "bar_weight=35&pairs_45=2&pairs_35=0&pairs_25=1&pairs_10=2&pairs_5=1&pairs_2.5=1&new_weight=1.25&new_pairs=2"
+	resp := app.HTMXRequest(http.MethodPut, "/plates", form)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	kit, err := plates.GetKit(app.DB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kit.BarWeight != 35 {
+		t.Errorf("expected bar weight 35, got %v", kit.BarWeight)
+	}
+	want := []plates.Plate{
+		{Weight: 45, Pairs: 2},
+		{Weight: 25, Pairs: 1},
+		{Weight: 10, Pairs: 2},
+		{Weight: 5, Pairs: 1},
+		{Weight: 2.5, Pairs: 1},
+		{Weight: 1.25, Pairs: 2},
+	}
+	if !reflect.DeepEqual(kit.Plates, want) {
+		t.Errorf("expected %v, got %v", want, kit.Plates)
+	}
+
+	resp = app.Request(http.MethodGet, "/plates", "")
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, `name="pairs_1.25"`) {
+		t.Error("expected new plate size on settings page")
+	}
+}
+
+func TestHandleUpdateSettings_Invalid(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	resp := app.HTMXRequest(http.MethodPut, "/plates", "bar_weight=-5")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", resp.StatusCode)
+	}
+}
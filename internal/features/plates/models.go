@@ -0,0 +1,60 @@
+package plates
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Plate is a plate size and how many pairs of it are on hand
+type Plate struct {
+	Weight float64
+	Pairs  int
+}
+
+// Kit is the bar and plates available for loading a barbell
+type Kit struct {
+	BarWeight float64
+	Plates    []Plate // Heaviest first
+}
+
+// Breakdown is the plates to load on each side of the bar for a target weight
+type Breakdown struct {
+	PerSide   []float64 // Heaviest first
+	Remainder float64   // Weight that could not be loaded with the kit
+	BelowBar  bool      // Target is lighter than the empty bar
+}
+
+// Loaded returns the total weight on the bar, including the bar itself
+func (b Breakdown) Loaded(k Kit) float64 {
+	total := k.BarWeight
+	for _, p := range b.PerSide {
+		total += 2 * p
+	}
+	return total
+}
+
+// String lists the plates per side, e.g. "45 + 25 + 2.5"
+func (b Breakdown) String() string {
+	if b.BelowBar {
+		return "below bar"
+	}
+	if len(b.PerSide) == 0 {
+		return "empty bar"
+	}
+	parts := make([]string, len(b.PerSide))
+	for i, p := range b.PerSide {
+		parts[i] = FormatWeight(p)
+	}
+	return strings.Join(parts, " + ")
+}
+
+// WarmupSet is a generated warm-up set
+type WarmupSet struct {
+	Reps   int
+	Weight float64
+}
+
+// FormatWeight formats a plate or bar weight without trailing zeros
+func FormatWeight(w float64) string {
+	return strconv.FormatFloat(w, 'f', -1, 64)
+}
@@ -0,0 +1,65 @@
+package plates
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// GetKit returns the bar weight and plate inventory
+func GetKit(db *sql.DB) (Kit, error) {
+	var k Kit
+	if err := db.QueryRow(`SELECT bar_weight FROM plate_settings WHERE id = 1`).Scan(&k.BarWeight); err != nil {
+		return k, fmt.Errorf("failed to get bar weight: %w", err)
+	}
+
+	rows, err := db.Query(`SELECT weight, pairs FROM plate_inventory ORDER BY weight DESC`)
+	if err != nil {
+		return k, fmt.Errorf("failed to get plate inventory: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p Plate
+		if err := rows.Scan(&p.Weight, &p.Pairs); err != nil {
+			return k, fmt.Errorf("failed to scan plate: %w", err)
+		}
+		k.Plates = append(k.Plates, p)
+	}
+
+	return k, rows.Err()
+}
+
+// SaveKit replaces the bar weight and plate inventory. Plates with no pairs are dropped.
+func SaveKit(db *sql.DB, k Kit) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE plate_settings SET bar_weight = ? WHERE id = 1`, k.BarWeight); err != nil {
+		return fmt.Errorf("failed to save bar weight: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM plate_inventory`); err != nil {
+		return fmt.Errorf("failed to clear plate inventory: %w", err)
+	}
+	for _, p := range k.Plates {
+		if p.Pairs <= 0 {
+			continue
+		}
+		_, err := tx.Exec(`
+			INSERT INTO plate_inventory (weight, pairs) VALUES (?, ?)
+			ON CONFLICT (weight) DO UPDATE SET pairs = pairs + excluded.pairs
+		`, p.Weight, p.Pairs)
+		if err != nil {
+			return fmt.Errorf("failed to save plate: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
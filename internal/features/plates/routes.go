@@ -0,0 +1,9 @@
+package plates
+
+import "github.com/gofiber/fiber/v2"
+
+// RegisterRoutes sets up plate calculator routes
+func RegisterRoutes(app *fiber.App) {
+	app.Get("/plates", HandleSettings)
+	app.Put("/plates", HandleUpdateSettings)
+}
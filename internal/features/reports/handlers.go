@@ -0,0 +1,64 @@
+package reports
+
+import (
+	"strconv"
+	"time"
+
+	"phobos/internal/features/exercises"
+	"phobos/internal/shared/htmx"
+	"phobos/internal/shared/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Number of weeks shown by default and at most on the volume report
+const (
+	defaultWeeks = 8
+	maxWeeks     = 52
+)
+
+// HandleVolume displays hard sets per muscle group per week
+func HandleVolume(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	weeks := c.QueryInt("weeks", defaultWeeks)
+	if weeks < 1 || weeks > maxWeeks {
+		weeks = defaultWeeks
+	}
+
+	volume, err := GetWeeklyVolume(db, time.Now(), weeks)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load volume report")
+	}
+
+	targets, err := ListTargets(db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load volume targets")
+	}
+
+	return htmx.Render(c, VolumePage(volume, targets, weeks))
+}
+
+// HandleUpdateTargets saves the MEV/MRV range of every muscle group
+func HandleUpdateTargets(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	var targets []VolumeTarget
+	for _, muscle := range exercises.MuscleGroups {
+		mev, err := strconv.Atoi(c.FormValue("mev_" + muscle))
+		if err != nil || mev < 0 {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid MEV for " + exercises.TagLabel(muscle))
+		}
+		mrv, err := strconv.Atoi(c.FormValue("mrv_" + muscle))
+		if err != nil || mrv < mev {
+			return c.Status(fiber.StatusBadRequest).SendString("MRV must be at least MEV for " + exercises.TagLabel(muscle))
+		}
+		targets = append(targets, VolumeTarget{MuscleGroup: muscle, MEV: mev, MRV: mrv})
+	}
+
+	if err := SaveTargets(db, targets); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to save volume targets")
+	}
+
+	return htmx.Refresh(c)
+}
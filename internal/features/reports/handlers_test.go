@@ -0,0 +1,122 @@
+package reports_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"phobos/internal/features/exercises"
+	"phobos/internal/features/reports"
+	"phobos/internal/features/workouts"
+	"phobos/internal/testutil"
+)
+
+func TestHandleVolume_Empty(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	resp := app.Request("GET", "/reports/volume", "")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "Weekly Volume") {
+		t.Error("expected page to contain 'Weekly Volume'")
+	}
+	if !strings.Contains(body, "Chest") {
+		t.Error("expected page to list muscle groups")
+	}
+}
+
+func TestGetWeeklyVolume(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	benchID, _ := exercises.Create(app.DB, "Bench Press")
+	exercises.SetTags(app.DB, benchID, []string{"chest"}, []string{"triceps"}, nil)
+
+	now := time.Now()
+	workoutID, _ := workouts.Create(app.DB, "Push", now, nil)
+	weID, _ := workouts.AddExercise(app.DB, workoutID, benchID)
+	workouts.AddSet(app.DB, weID, 5, 185)
+	workouts.AddSet(app.DB, weID, 5, 185)
+	workouts.AddSet(app.DB, weID, 5, 185)
+	workouts.AddSet(app.DB, weID, 0, 185) // Failed attempt is not a hard set
+	workouts.Finish(app.DB, workoutID)
+
+	// In-progress workouts do not count
+	openID, _ := workouts.Create(app.DB, "Open", now, nil)
+	openWeID, _ := workouts.AddExercise(app.DB, openID, benchID)
+	workouts.AddSet(app.DB, openWeID, 5, 185)
+
+	weeks, err := reports.GetWeeklyVolume(app.DB, now, 4)
+	if err != nil {
+		t.Fatalf("failed to get weekly volume: %v", err)
+	}
+	if len(weeks) != 4 {
+		t.Fatalf("expected 4 weeks, got %d", len(weeks))
+	}
+
+	sets := make(map[string]reports.MuscleVolume)
+	for _, m := range weeks[0].Muscles {
+		sets[m.MuscleGroup] = m
+	}
+	if sets["chest"].Sets != 3 {
+		t.Errorf("expected 3 chest sets, got %v", sets["chest"].Sets)
+	}
+	if sets["triceps"].Sets != 1.5 {
+		t.Errorf("expected 1.5 triceps sets, got %v", sets["triceps"].Sets)
+	}
+	if sets["chest"].Status() != reports.VolumeUnder {
+		t.Errorf("expected chest to be under MEV, got %s", sets["chest"].Status())
+	}
+	for _, m := range weeks[1].Muscles {
+		if m.Sets != 0 {
+			t.Errorf("expected no volume in previous week, got %v for %s", m.Sets, m.MuscleGroup)
+		}
+	}
+}
+
+func TestHandleUpdateTargets(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	var form []string
+	for _, m := range exercises.MuscleGroups {
+		form = append(form, "mev_"+m+"=2", "mrv_"+m+"=4")
+	}
+
+	resp := app.HTMXRequest("PUT", "/reports/volume/targets", strings.Join(form, "&"))
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	targets, _ := reports.ListTargets(app.DB)
+	if targets["chest"].MEV != 2 || targets["chest"].MRV != 4 {
+		t.Errorf("expected chest target 2-4, got %d-%d", targets["chest"].MEV, targets["chest"].MRV)
+	}
+}
+
+func TestHandleUpdateTargets_Invalid(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	var form []string
+	for _, m := range exercises.MuscleGroups {
+		form = append(form, "mev_"+m+"=10", "mrv_"+m+"=4")
+	}
+
+	resp := app.HTMXRequest("PUT", "/reports/volume/targets", strings.Join(form, "&"))
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,49 @@
+package reports
+
+import "time"
+
+// SecondaryCredit is the fraction of a hard set credited to a secondary muscle
+const SecondaryCredit = 0.5
+
+// VolumeStatus classifies weekly volume against a muscle group's target range
+type VolumeStatus string
+
+const (
+	VolumeUnder  VolumeStatus = "under"
+	VolumeWithin VolumeStatus = "within"
+	VolumeOver   VolumeStatus = "over"
+)
+
+// VolumeTarget is the weekly hard-set range for a muscle group
+type VolumeTarget struct {
+	MuscleGroup string
+	MEV         int // Minimum effective volume
+	MRV         int // Maximum recoverable volume
+}
+
+// MuscleVolume is the hard-set count for one muscle group in one week
+type MuscleVolume struct {
+	MuscleGroup string
+	Sets        float64
+	Target      *VolumeTarget
+}
+
+// Status compares the set count with the target range
+func (m MuscleVolume) Status() VolumeStatus {
+	if m.Target == nil {
+		return VolumeWithin
+	}
+	if m.Sets < float64(m.Target.MEV) {
+		return VolumeUnder
+	}
+	if m.Sets > float64(m.Target.MRV) {
+		return VolumeOver
+	}
+	return VolumeWithin
+}
+
+// WeeklyVolume holds the volume of every muscle group for a week starting on Monday
+type WeeklyVolume struct {
+	WeekStart time.Time
+	Muscles   []MuscleVolume
+}
@@ -0,0 +1,124 @@
+package reports
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"phobos/internal/features/exercises"
+	"phobos/internal/shared/dates"
+)
+
+// ListTargets returns the volume target of every muscle group that has one
+func ListTargets(db *sql.DB) (map[string]VolumeTarget, error) {
+	rows, err := db.Query(`
+		SELECT muscle_group, mev, mrv
+		FROM muscle_volume_targets
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volume targets: %w", err)
+	}
+	defer rows.Close()
+
+	targets := make(map[string]VolumeTarget)
+	for rows.Next() {
+		var t VolumeTarget
+		if err := rows.Scan(&t.MuscleGroup, &t.MEV, &t.MRV); err != nil {
+			return nil, fmt.Errorf("failed to scan volume target: %w", err)
+		}
+		targets[t.MuscleGroup] = t
+	}
+
+	return targets, rows.Err()
+}
+
+// SaveTargets replaces the volume targets of the given muscle groups
+func SaveTargets(db *sql.DB, targets []VolumeTarget) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, t := range targets {
+		_, err := tx.Exec(`
+			INSERT INTO muscle_volume_targets (muscle_group, mev, mrv)
+			VALUES (?, ?, ?)
+			ON CONFLICT (muscle_group) DO UPDATE SET mev = excluded.mev, mrv = excluded.mrv
+		`, t.MuscleGroup, t.MEV, t.MRV)
+		if err != nil {
+			return fmt.Errorf("failed to save volume target: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetWeeklyVolume aggregates hard sets from finished workouts per muscle group
+// for the given number of weeks ending with the week containing now (newest
+// first). Sets count fully toward primary muscles and SecondaryCredit toward
+// secondary muscles. Every known muscle group is listed for every week.
+func GetWeeklyVolume(db *sql.DB, now time.Time, weeks int) ([]WeeklyVolume, error) {
+	targets, err := ListTargets(db)
+	if err != nil {
+		return nil, err
+	}
+
+	currentWeek := dates.WeekStart(now)
+	from := currentWeek.AddDate(0, 0, -7*(weeks-1))
+
+	rows, err := db.Query(`
+		SELECT date(w.date, 'weekday 0', '-6 days') AS week_start,
+		       m.muscle_group,
+		       SUM(CASE WHEN m.role = 'primary' THEN 1.0 ELSE ? END) AS sets
+		FROM logged_sets ls
+		JOIN workout_exercises we ON ls.workout_exercise_id = we.id
+		JOIN workouts w ON we.workout_id = w.id
+		JOIN exercise_muscle_groups m ON m.exercise_id = we.exercise_id
+		WHERE w.status = 'finished'
+		  AND ls.set_type = 'working'
+		  AND ls.reps > 0
+		  AND w.date >= ?
+		GROUP BY week_start, m.muscle_group
+	`, SecondaryCredit, from.Format(dates.Layout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get weekly volume: %w", err)
+	}
+	defer rows.Close()
+
+	sets := make(map[string]map[string]float64)
+	for rows.Next() {
+		var week, muscle string
+		var count float64
+		if err := rows.Scan(&week, &muscle, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan weekly volume: %w", err)
+		}
+		if sets[week] == nil {
+			sets[week] = make(map[string]float64)
+		}
+		sets[week][muscle] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]WeeklyVolume, 0, weeks)
+	for i := 0; i < weeks; i++ {
+		start := currentWeek.AddDate(0, 0, -7*i)
+		week := WeeklyVolume{WeekStart: start}
+		for _, muscle := range exercises.MuscleGroups {
+			mv := MuscleVolume{MuscleGroup: muscle, Sets: sets[start.Format(dates.Layout)][muscle]}
+			if t, ok := targets[muscle]; ok {
+				mv.Target = &t
+			}
+			week.Muscles = append(week.Muscles, mv)
+		}
+		result = append(result, week)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,9 @@
+package reports
+
+import "github.com/gofiber/fiber/v2"
+
+// RegisterRoutes sets up report routes
+func RegisterRoutes(app *fiber.App) {
+	app.Get("/reports/volume", HandleVolume)
+	app.Put("/reports/volume/targets", HandleUpdateTargets)
+}
@@ -2,14 +2,19 @@ package routines
 
 import (
 	"strconv"
+	"time"
 
 	"phobos/internal/features/templates"
+	"phobos/internal/shared/dates"
 	"phobos/internal/shared/htmx"
 	"phobos/internal/shared/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// upcomingDays is how far ahead the routine page lists scheduled sessions
+const upcomingDays = 14
+
 // HandleList displays all routines
 func HandleList(c *fiber.Ctx) error {
 	db := middleware.GetDB(c)
@@ -62,7 +67,13 @@ func HandleShow(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load templates")
 	}
 
-	return htmx.Render(c, RoutineDetailPage(routine, allTemplates))
+	var upcoming []ScheduledSession
+	if routine.Schedule != nil {
+		today := dates.Day(time.Now())
+		upcoming = routine.Schedule.Sessions(routine.Templates, today, today.AddDate(0, 0, upcomingDays))
+	}
+
+	return htmx.Render(c, RoutineDetailPage(routine, allTemplates, upcoming))
 }
 
 // HandleUpdate modifies a routine
@@ -145,3 +156,77 @@ func HandleRemoveTemplate(c *fiber.Ctx) error {
 
 	return c.SendString("")
 }
+
+// HandleSaveSchedule sets when a routine is trained
+func HandleSaveSchedule(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	schedule := Schedule{RoutineID: id}
+
+	schedule.StartDate, err = time.Parse(dates.Layout, c.FormValue("start_date"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid start date")
+	}
+
+	for _, v := range c.Request().PostArgs().PeekMulti("weekday") {
+		d, err := strconv.Atoi(string(v))
+		if err != nil || d < 0 || d > 6 {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid weekday")
+		}
+		if !schedule.OnWeekday(time.Weekday(d)) {
+			schedule.Weekdays = append(schedule.Weekdays, time.Weekday(d))
+		}
+	}
+	if len(schedule.Weekdays) == 0 {
+		return c.Status(fiber.StatusBadRequest).SendString("Choose at least one day")
+	}
+
+	if raw := c.FormValue("time"); raw != "" {
+		t, err := time.Parse("15:04", raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid time")
+		}
+		schedule.Time = t.Format("15:04")
+	}
+
+	minutes, err := strconv.Atoi(c.FormValue("duration_minutes", "60"))
+	if err != nil || minutes < 1 || minutes > 24*60 {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid duration")
+	}
+	schedule.Duration = time.Duration(minutes) * time.Minute
+
+	routine, err := GetByID(db, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load routine")
+	}
+	if routine == nil {
+		return c.Status(fiber.StatusNotFound).SendString("Routine not found")
+	}
+
+	if err := SaveSchedule(db, schedule); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to save schedule")
+	}
+
+	return htmx.Refresh(c)
+}
+
+// HandleDeleteSchedule removes a routine's schedule
+func HandleDeleteSchedule(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	if err := DeleteSchedule(db, id); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to remove schedule")
+	}
+
+	return htmx.Refresh(c)
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"phobos/internal/features/routines"
 	"phobos/internal/features/templates"
@@ -240,3 +241,82 @@ func TestRoutineShowIncludesStartWorkoutLinks(t *testing.T) {
 		t.Errorf("expected page to contain link '%s'", expectedLink)
 	}
 }
+
+func TestHandleSaveSchedule(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	routineID, _ := routines.Create(app.DB, "Full Body")
+	templateID, _ := templates.Create(app.DB, "Day A")
+	routines.AddTemplate(app.DB, routineID, templateID)
+
+	resp := app.HTMXRequest("PUT", "/routines/"+strconv.FormatInt(routineID, 10)+"/schedule",
+		"start_date=2024-03-04&weekday=1&weekday=3&weekday=5&time=07:30&duration_minutes=45")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	routine, _ := routines.GetByID(app.DB, routineID)
+	if routine.Schedule == nil {
+		t.Fatal("expected routine to have a schedule")
+	}
+	if len(routine.Schedule.Weekdays) != 3 || !routine.Schedule.OnWeekday(time.Wednesday) {
+		t.Errorf("expected Monday, Wednesday and Friday, got %v", routine.Schedule.Weekdays)
+	}
+	if routine.Schedule.Time != "07:30" || routine.Schedule.Duration != 45*time.Minute {
+		t.Errorf("expected 07:30 for 45 minutes, got %s for %v", routine.Schedule.Time, routine.Schedule.Duration)
+	}
+
+	resp = app.HTMXRequest("DELETE", "/routines/"+strconv.FormatInt(routineID, 10)+"/schedule", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+	if s, _ := routines.GetSchedule(app.DB, routineID); s != nil {
+		t.Error("expected schedule to be removed")
+	}
+}
+
+func TestHandleSaveSchedule_NoWeekdays(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	routineID, _ := routines.Create(app.DB, "Full Body")
+
+	resp := app.HTMXRequest("PUT", "/routines/"+strconv.FormatInt(routineID, 10)+"/schedule",
+		"start_date=2024-03-04&duration_minutes=60")
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestScheduleSessions(t *testing.T) {
+	t.Parallel()
+
+	tmpls := []routines.RoutineTemplate{{TemplateID: 1}, {TemplateID: 2}, {TemplateID: 3}}
+	schedule := routines.Schedule{
+		StartDate: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), // Monday
+		Weekdays:  []time.Weekday{time.Monday, time.Thursday},
+	}
+
+	// Sessions before the start date are left out
+	all := schedule.Sessions(tmpls, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 19, 0, 0, 0, 0, time.UTC))
+	want := []int64{1, 2, 3, 1, 2}
+	if len(all) != len(want) {
+		t.Fatalf("expected %d sessions, got %d", len(want), len(all))
+	}
+	for i, s := range all {
+		if s.Template.TemplateID != want[i] {
+			t.Errorf("session %d: expected template %d, got %d", i, want[i], s.Template.TemplateID)
+		}
+	}
+
+	// A later range continues the rotation rather than starting over
+	later := schedule.Sessions(tmpls, time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 19, 0, 0, 0, 0, time.UTC))
+	if len(later) != 2 || later[0].Template.TemplateID != 1 || later[1].Template.TemplateID != 2 {
+		t.Errorf("expected templates 1 and 2, got %+v", later)
+	}
+}
@@ -12,6 +12,7 @@ type Routine struct {
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	Templates []RoutineTemplate
+	Schedule  *Schedule // When the routine is trained, if scheduled
 }
 
 // RoutineTemplate represents a template in a routine
@@ -51,12 +51,37 @@ func GetByID(db *sql.DB, id int64) (*Routine, error) {
 		return nil, err
 	}
 
+	r.Schedule, err = GetSchedule(db, id)
+	if err != nil {
+		return nil, err
+	}
+
 	return &r, nil
 }
 
 // Create inserts a new routine and returns its ID
 func Create(db *sql.DB, name string) (int64, error) {
-	result, err := db.Exec(`
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	id, err := CreateTx(tx, name)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return id, nil
+}
+
+// CreateTx inserts a new routine as part of a caller's transaction
+func CreateTx(tx *sql.Tx, name string) (int64, error) {
+	result, err := tx.Exec(`
 		INSERT INTO routines (name) VALUES (?)
 	`, name)
 	if err != nil {
@@ -120,32 +145,47 @@ func GetRoutineTemplates(db *sql.DB, routineID int64) ([]RoutineTemplate, error)
 
 // AddTemplate adds a template to a routine
 func AddTemplate(db *sql.DB, routineID, templateID int64) (int64, error) {
-	// Get the next position
-	var maxPos sql.NullInt64
-	err := db.QueryRow(`
-		SELECT MAX(position) FROM routine_templates WHERE routine_id = ?
-	`, routineID).Scan(&maxPos)
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	id, err := AddTemplateTx(tx, routineID, templateID)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get max position: %w", err)
+		return 0, err
 	}
 
-	nextPos := 1
-	if maxPos.Valid {
-		nextPos = int(maxPos.Int64) + 1
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	result, err := db.Exec(`
+	return id, nil
+}
+
+// AddTemplateTx adds a template to a routine as part of a caller's transaction
+func AddTemplateTx(tx *sql.Tx, routineID, templateID int64) (int64, error) {
+	result, err := tx.Exec(`
 		INSERT INTO routine_templates (routine_id, template_id, position)
-		VALUES (?, ?, ?)
-	`, routineID, templateID, nextPos)
+		SELECT ?, ?, COALESCE(MAX(position), 0) + 1
+		FROM routine_templates
+		WHERE routine_id = ?
+	`, routineID, templateID, routineID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to add template to routine: %w", err)
 	}
 
-	// Update routine timestamp
-	db.Exec(`UPDATE routines SET updated_at = CURRENT_TIMESTAMP WHERE id = ?`, routineID)
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(`UPDATE routines SET updated_at = CURRENT_TIMESTAMP WHERE id = ?`, routineID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update routine timestamp: %w", err)
+	}
 
-	return result.LastInsertId()
+	return id, nil
 }
 
 // RemoveTemplate removes a template from a routine
@@ -9,6 +9,8 @@ func RegisterRoutes(app *fiber.App) {
 	app.Get("/routines/:id", HandleShow)
 	app.Put("/routines/:id", HandleUpdate)
 	app.Delete("/routines/:id", HandleDelete)
+	app.Put("/routines/:id/schedule", HandleSaveSchedule)
+	app.Delete("/routines/:id/schedule", HandleDeleteSchedule)
 	app.Post("/routines/:id/templates", HandleAddTemplate)
 	app.Delete("/routines/templates/:id", HandleRemoveTemplate)
 }
@@ -0,0 +1,167 @@
+package routines
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"phobos/internal/shared/dates"
+)
+
+// Schedule says when a routine is trained. Sessions fall on the chosen
+// weekdays from the start date and rotate through the routine's templates in
+// order, so a three-template routine on Monday, Wednesday and Friday runs
+// each template once a week.
+type Schedule struct {
+	RoutineID int64
+	StartDate time.Time
+	Weekdays  []time.Weekday
+	Time      string        // Wall-clock start as "15:04"; empty for all-day sessions
+	Duration  time.Duration // Planned length of timed sessions
+}
+
+// ScheduledSession is one planned session of a routine
+type ScheduledSession struct {
+	Date     time.Time // Midnight UTC of the session's day
+	Template RoutineTemplate
+}
+
+// IsAllDay returns true if the sessions have no start time
+func (s Schedule) IsAllDay() bool {
+	return s.Time == ""
+}
+
+// OnWeekday returns true if sessions fall on the weekday
+func (s Schedule) OnWeekday(d time.Weekday) bool {
+	return slices.Contains(s.Weekdays, d)
+}
+
+// Sessions returns the sessions on days from from up to but not including
+// to. Each session's template depends on how many sessions came before it
+// since the start date, so the rotation doesn't shift with the range asked for.
+func (s Schedule) Sessions(templates []RoutineTemplate, from, to time.Time) []ScheduledSession {
+	if len(templates) == 0 || len(s.Weekdays) == 0 {
+		return nil
+	}
+
+	var sessions []ScheduledSession
+	n := 0
+	end := dates.Day(to)
+	for day := dates.Day(s.StartDate); day.Before(end); day = day.AddDate(0, 0, 1) {
+		if !s.OnWeekday(day.Weekday()) {
+			continue
+		}
+		if !day.Before(dates.Day(from)) {
+			sessions = append(sessions, ScheduledSession{Date: day, Template: templates[n%len(templates)]})
+		}
+		n++
+	}
+	return sessions
+}
+
+// GetSchedule returns a routine's schedule, or nil if it has none
+func GetSchedule(db *sql.DB, routineID int64) (*Schedule, error) {
+	s, err := scanSchedule(db.QueryRow(`
+		SELECT routine_id, start_date, weekdays, COALESCE(time, ''), duration_minutes
+		FROM routine_schedules
+		WHERE routine_id = ?
+	`, routineID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ListScheduled returns every routine that has a schedule, with its templates
+func ListScheduled(db *sql.DB) ([]Routine, error) {
+	rows, err := db.Query(`
+		SELECT routine_id, start_date, weekdays, COALESCE(time, ''), duration_minutes
+		FROM routine_schedules
+		ORDER BY routine_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list routine schedules: %w", err)
+	}
+
+	var schedules []Schedule
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		schedules = append(schedules, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var scheduled []Routine
+	for _, s := range schedules {
+		r, err := GetByID(db, s.RoutineID)
+		if err != nil {
+			return nil, err
+		}
+		if r != nil {
+			scheduled = append(scheduled, *r)
+		}
+	}
+	return scheduled, nil
+}
+
+func scanSchedule(row interface{ Scan(...any) error }) (Schedule, error) {
+	var s Schedule
+	var weekdays string
+	var minutes int
+	if err := row.Scan(&s.RoutineID, &s.StartDate, &weekdays, &s.Time, &minutes); err != nil {
+		if err == sql.ErrNoRows {
+			return s, err
+		}
+		return s, fmt.Errorf("failed to scan routine schedule: %w", err)
+	}
+	for _, part := range strings.Split(weekdays, ",") {
+		if d, err := strconv.Atoi(part); err == nil {
+			s.Weekdays = append(s.Weekdays, time.Weekday(d))
+		}
+	}
+	s.Duration = time.Duration(minutes) * time.Minute
+	return s, nil
+}
+
+// SaveSchedule sets or replaces a routine's schedule
+func SaveSchedule(db *sql.DB, s Schedule) error {
+	days := make([]string, len(s.Weekdays))
+	for i, d := range s.Weekdays {
+		days[i] = strconv.Itoa(int(d))
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO routine_schedules (routine_id, start_date, weekdays, time, duration_minutes)
+		VALUES (?, ?, ?, NULLIF(?, ''), ?)
+		ON CONFLICT (routine_id) DO UPDATE SET
+			start_date = excluded.start_date,
+			weekdays = excluded.weekdays,
+			time = excluded.time,
+			duration_minutes = excluded.duration_minutes
+	`, s.RoutineID, s.StartDate.Format(dates.Layout), strings.Join(days, ","), s.Time, int(s.Duration.Minutes()))
+	if err != nil {
+		return fmt.Errorf("failed to save routine schedule: %w", err)
+	}
+	return nil
+}
+
+// DeleteSchedule removes a routine's schedule
+func DeleteSchedule(db *sql.DB, routineID int64) error {
+	_, err := db.Exec(`DELETE FROM routine_schedules WHERE routine_id = ?`, routineID)
+	if err != nil {
+		return fmt.Errorf("failed to delete routine schedule: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package search
+
+import (
+	"strings"
+
+	"phobos/internal/shared/htmx"
+	"phobos/internal/shared/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// resultLimit caps how many results a search returns
+const resultLimit = 50
+
+// HandleSearch searches workouts, templates, routines and exercises.
+// HTMX requests from the search page's live input get only the results.
+func HandleSearch(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	query := strings.TrimSpace(c.Query("q"))
+	results, err := Search(db, query, resultLimit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to search")
+	}
+
+	if htmx.IsHTMX(c) {
+		return htmx.Render(c, SearchResults(query, results))
+	}
+	return htmx.Render(c, SearchPage(query, results))
+}
@@ -0,0 +1,167 @@
+package search_test
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"phobos/internal/features/exercises"
+	"phobos/internal/features/routines"
+	"phobos/internal/features/search"
+	"phobos/internal/features/templates"
+	"phobos/internal/features/workouts"
+	"phobos/internal/testutil"
+)
+
+func TestSearch_AcrossKinds(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	exerciseID, _ := exercises.Create(app.DB, "Bench Press")
+	templateID, _ := templates.Create(app.DB, "Bench Day")
+	routineID, _ := routines.Create(app.DB, "Bench Specialization")
+	workoutID, _ := workouts.Create(app.DB, "Push", time.Now(), nil)
+	workouts.Update(app.DB, workoutID, "Push", time.Now(), "bench felt heavy today")
+
+	results, err := search.Search(app.DB, "bench", 10)
+	if err != nil {
+		t.Fatalf("failed to search: %v", err)
+	}
+
+	found := make(map[search.Kind]int64)
+	for _, r := range results {
+		found[r.Kind] = r.ID
+	}
+	want := map[search.Kind]int64{
+		search.KindExercise: exerciseID,
+		search.KindTemplate: templateID,
+		search.KindRoutine:  routineID,
+		search.KindWorkout:  workoutID,
+	}
+	for kind, id := range want {
+		if found[kind] != id {
+			t.Errorf("expected %s %d in results, got %v", kind, id, results)
+		}
+	}
+
+	// Title matches rank above notes matches
+	if results[len(results)-1].Kind != search.KindWorkout {
+		t.Errorf("expected the notes-only match to rank last, got %v", results)
+	}
+}
+
+func TestSearch_StaysInSync(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	workoutID, _ := workouts.Create(app.DB, "Morning Session", time.Now(), nil)
+	workouts.Update(app.DB, workoutID, "Evening Session", time.Now(), "")
+
+	if results, _ := search.Search(app.DB, "morning", 10); len(results) != 0 {
+		t.Errorf("expected renamed workout to drop its old name, got %v", results)
+	}
+	if results, _ := search.Search(app.DB, "evening", 10); len(results) != 1 {
+		t.Errorf("expected renamed workout to be found by its new name, got %v", results)
+	}
+
+	workouts.Delete(app.DB, workoutID)
+	if results, _ := search.Search(app.DB, "evening", 10); len(results) != 0 {
+		t.Errorf("expected deleted workout to leave the index, got %v", results)
+	}
+}
+
+func TestSearch_SameIDAcrossKinds(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	// A template and a routine can share an id; changing one leaves the other indexed
+	templateID, _ := templates.Create(app.DB, "Tempo Squats")
+	routineID, _ := routines.Create(app.DB, "Tempo Block")
+	templates.Delete(app.DB, templateID)
+
+	results, _ := search.Search(app.DB, "tempo", 10)
+	if len(results) != 1 || results[0].Kind != search.KindRoutine || results[0].ID != routineID {
+		t.Errorf("expected only the routine to remain, got %v", results)
+	}
+}
+
+func TestSearch_StripsHighlightMarkers(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	exercises.Create(app.DB, "Hack\x02 Squat\x03")
+
+	results, _ := search.Search(app.DB, "hack", 10)
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %v", results)
+	}
+	title := search.HighlightHTML(results[0].Title)
+	if strings.Count(title, "<mark") != 1 || strings.Count(title, "</mark>") != 1 {
+		t.Errorf("expected only the match to be highlighted, got %q", title)
+	}
+}
+
+func TestSearch_PrefixAndOperators(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	exercises.Create(app.DB, "Romanian Deadlift")
+
+	results, err := search.Search(app.DB, "rom dead", 10)
+	if err != nil {
+		t.Fatalf("failed to search: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("expected prefixes to match, got %v", results)
+	}
+
+	// FTS5 syntax in the input must not cause an error
+	if _, err := search.Search(app.DB, `"dead OR (lift* NEAR`, 10); err != nil {
+		t.Errorf("expected operators to be treated literally, got %v", err)
+	}
+}
+
+func TestHandleSearch(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	exercises.Create(app.DB, "Overhead <Press>")
+
+	resp := app.Request("GET", "/search?q="+url.QueryEscape("overhead"), "")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "<mark") {
+		t.Error("expected matched term to be highlighted")
+	}
+	if strings.Contains(body, "<Press>") {
+		t.Error("expected result text to be escaped")
+	}
+}
+
+func TestHandleSearch_Fragment(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	resp := app.HTMXRequest("GET", "/search?q=nothing", "")
+
+	body := testutil.ReadBody(t, resp)
+	if strings.Contains(body, "<html") {
+		t.Error("expected a fragment for HTMX requests")
+	}
+	if !strings.Contains(body, "No results") {
+		t.Error("expected an empty state")
+	}
+}
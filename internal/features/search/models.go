@@ -0,0 +1,72 @@
+package search
+
+import (
+	"html"
+	"strconv"
+	"strings"
+)
+
+// Kind identifies what a search result points at
+type Kind string
+
+const (
+	KindWorkout  Kind = "workout"
+	KindTemplate Kind = "template"
+	KindRoutine  Kind = "routine"
+	KindExercise Kind = "exercise"
+)
+
+// Label returns a human-readable name for the kind
+func (k Kind) Label() string {
+	switch k {
+	case KindWorkout:
+		return "Workout"
+	case KindTemplate:
+		return "Template"
+	case KindRoutine:
+		return "Routine"
+	case KindExercise:
+		return "Exercise"
+	}
+	return string(k)
+}
+
+// Highlight markers wrapped around matched terms by the FTS5 highlight and
+// snippet functions. The index triggers strip these bytes from indexed text,
+// so every marker in a result came from FTS5 and is safe to swap for <mark>
+// after escaping.
+const (
+	markStart = "\x02"
+	markEnd   = "\x03"
+)
+
+// Result is a single ranked search hit
+type Result struct {
+	Kind    Kind
+	ID      int64
+	Title   string // Contains highlight markers
+	Snippet string // Contains highlight markers; empty when only the title matched
+}
+
+// URL returns the page the result links to
+func (r Result) URL() string {
+	id := strconv.FormatInt(r.ID, 10)
+	switch r.Kind {
+	case KindWorkout:
+		return "/workouts/" + id
+	case KindTemplate:
+		return "/templates/" + id
+	case KindRoutine:
+		return "/routines/" + id
+	case KindExercise:
+		return "/exercises/" + id
+	}
+	return "/"
+}
+
+// HighlightHTML escapes text and turns highlight markers into <mark> elements
+func HighlightHTML(text string) string {
+	escaped := html.EscapeString(text)
+	escaped = strings.ReplaceAll(escaped, markStart, `<mark class="bg-yellow-200 rounded-sm">`)
+	return strings.ReplaceAll(escaped, markEnd, "</mark>")
+}
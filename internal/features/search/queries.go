@@ -0,0 +1,55 @@
+package search
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Search returns index entries matching every word of the query, best matches first.
+// Title matches rank above matches in notes.
+func Search(db *sql.DB, query string, limit int) ([]Result, error) {
+	match := MatchExpression(query)
+	if match == "" {
+		return nil, nil
+	}
+
+	rows, err := db.Query(`
+		SELECT kind, ref_id,
+		       highlight(search_index, 2, ?, ?),
+		       snippet(search_index, 3, ?, ?, '…', 12)
+		FROM search_index
+		WHERE search_index MATCH ?
+		ORDER BY bm25(search_index, 0, 0, 10.0, 1.0)
+		LIMIT ?
+	`, markStart, markEnd, markStart, markEnd, match, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	defer rows.Close()
+
+	var results []Result
+	for rows.Next() {
+		var r Result
+		if err := rows.Scan(&r.Kind, &r.ID, &r.Title, &r.Snippet); err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		if !strings.Contains(r.Snippet, markStart) {
+			r.Snippet = ""
+		}
+		results = append(results, r)
+	}
+
+	return results, rows.Err()
+}
+
+// MatchExpression turns free text into an FTS5 query that prefix-matches every word.
+// Each word is quoted so FTS5 operators and punctuation in the input are taken literally.
+func MatchExpression(query string) string {
+	var terms []string
+	for _, word := range strings.Fields(query) {
+		word = strings.ReplaceAll(word, `"`, `""`)
+		terms = append(terms, `"`+word+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,8 @@
+package search
+
+import "github.com/gofiber/fiber/v2"
+
+// RegisterRoutes sets up search routes
+func RegisterRoutes(app *fiber.App) {
+	app.Get("/search", HandleSearch)
+}
@@ -0,0 +1,11 @@
+package shares
+
+import (
+	"net/http"
+	"time"
+)
+
+// Tests import from httptest servers, which listen on loopback
+func init() {
+	importClient = &http.Client{Timeout: 10 * time.Second}
+}
@@ -0,0 +1,259 @@
+package shares
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"phobos/internal/features/plates"
+	"phobos/internal/features/routines"
+	"phobos/internal/features/templates"
+	"phobos/internal/features/workouts"
+	"phobos/internal/shared/htmx"
+	"phobos/internal/shared/middleware"
+	"phobos/internal/shared/safehttp"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// importClient fetches exports from other installs. It only reaches public
+// addresses, so a visitor can't make the server fetch from its own network.
+var importClient = safehttp.NewClient(10 * time.Second)
+
+// HandlePanel renders the share links of a workout, template or routine with
+// a form to create another. Detail pages load it with hx-get.
+func HandlePanel(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	kind, ok := ParseKind(c.Query("kind"))
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid kind")
+	}
+
+	targetID, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	links, err := ListActive(db, kind, targetID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load share links")
+	}
+
+	return htmx.Render(c, SharePanel(kind, targetID, links, ""))
+}
+
+// HandleCreate adds a share link to a finished workout, a template or a routine
+func HandleCreate(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	kind, ok := ParseKind(c.FormValue("kind"))
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid kind")
+	}
+
+	targetID, err := strconv.ParseInt(c.FormValue("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	expiresInDays, err := strconv.Atoi(c.FormValue("expires_in_days", "0"))
+	if err != nil || !slices.Contains(ExpiryOptions, expiresInDays) {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid expiry")
+	}
+
+	shareable, err := isShareable(c, kind, targetID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load " + string(kind))
+	}
+	if !shareable {
+		return c.Status(fiber.StatusBadRequest).SendString("Only finished workouts, templates and routines can be shared")
+	}
+
+	_, token, err := Create(db, kind, targetID, expiresInDays)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to create share link")
+	}
+
+	links, err := ListActive(db, kind, targetID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load share links")
+	}
+
+	return htmx.Render(c, SharePanel(kind, targetID, links, c.BaseURL()+Path(token)))
+}
+
+// isShareable reports whether the target exists and, for workouts, is finished
+func isShareable(c *fiber.Ctx, kind Kind, targetID int64) (bool, error) {
+	db := middleware.GetDB(c)
+	switch kind {
+	case KindWorkout:
+		w, err := workouts.GetByID(db, targetID)
+		return w != nil && w.IsFinished(), err
+	case KindTemplate:
+		t, err := templates.GetByID(db, targetID)
+		return t != nil, err
+	case KindRoutine:
+		r, err := routines.GetByID(db, targetID)
+		return r != nil, err
+	}
+	return false, nil
+}
+
+// HandleRevoke stops a share link from working
+func HandleRevoke(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	link, err := GetByID(db, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load share link")
+	}
+	if link == nil {
+		return c.Status(fiber.StatusNotFound).SendString("Share link not found")
+	}
+
+	if err := Revoke(db, id); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to revoke share link")
+	}
+
+	links, err := ListActive(db, link.Kind, link.TargetID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load share links")
+	}
+
+	return htmx.Render(c, SharePanel(link.Kind, link.TargetID, links, ""))
+}
+
+// sharedLink returns the working link for the token in the path, or a 404
+// error for unknown, revoked and expired links
+func sharedLink(c *fiber.Ctx) (*ShareLink, error) {
+	link, err := GetByToken(middleware.GetDB(c), c.Params("token"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to load share link")
+	}
+	if link == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Link not found")
+	}
+	return link, nil
+}
+
+// HandleShow renders what a share link points at, read-only, in the public layout
+func HandleShow(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	link, err := sharedLink(c)
+	if err != nil {
+		return err
+	}
+
+	switch link.Kind {
+	case KindWorkout:
+		w, err := workouts.GetByID(db, link.TargetID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString("Failed to load workout")
+		}
+		if w == nil || !w.IsFinished() {
+			return c.Status(fiber.StatusNotFound).SendString("Link not found")
+		}
+		kit, err := plates.GetKit(db)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString("Failed to load plates")
+		}
+		return htmx.Render(c, SharedWorkoutPage(w, kit))
+
+	case KindTemplate:
+		t, err := templates.GetByID(db, link.TargetID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString("Failed to load template")
+		}
+		if t == nil {
+			return c.Status(fiber.StatusNotFound).SendString("Link not found")
+		}
+		return htmx.Render(c, SharedTemplatePage(c.BaseURL()+Path(c.Params("token")), t))
+
+	case KindRoutine:
+		r, err := routines.GetByID(db, link.TargetID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString("Failed to load routine")
+		}
+		if r == nil {
+			return c.Status(fiber.StatusNotFound).SendString("Link not found")
+		}
+		var ts []templates.WorkoutTemplate
+		for _, rt := range r.Templates {
+			t, err := templates.GetByID(db, rt.TemplateID)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).SendString("Failed to load template")
+			}
+			if t != nil {
+				ts = append(ts, *t)
+			}
+		}
+		return htmx.Render(c, SharedRoutinePage(c.BaseURL()+Path(c.Params("token")), r, ts))
+	}
+
+	return c.Status(fiber.StatusNotFound).SendString("Link not found")
+}
+
+// HandleExport serves a shared template or routine as JSON for importing into
+// another install
+func HandleExport(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	link, err := sharedLink(c)
+	if err != nil {
+		return err
+	}
+
+	var export *Export
+	switch link.Kind {
+	case KindTemplate:
+		export, err = ExportTemplate(db, link.TargetID)
+	case KindRoutine:
+		export, err = ExportRoutine(db, link.TargetID)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to export " + string(link.Kind))
+	}
+	if export == nil {
+		return c.Status(fiber.StatusNotFound).SendString("Nothing to import")
+	}
+
+	return c.JSON(export)
+}
+
+// HandleImportForm shows the form for importing a shared template or routine
+func HandleImportForm(c *fiber.Ctx) error {
+	return htmx.Render(c, ImportPage(c.Query("url"), ""))
+}
+
+// HandleImport copies the template or routine behind a share link from any
+// install into this one, then redirects to the copy
+func HandleImport(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	shareURL := c.FormValue("url")
+	export, err := FetchExport(importClient, shareURL)
+	if errors.Is(err, ErrInvalidShareURL) {
+		return htmx.RenderStatus(c, fiber.StatusBadRequest, ImportPage(shareURL, "Paste a share link, such as https://example.com/s/1a2b3c."))
+	}
+	if errors.Is(err, ErrInvalidExport) {
+		return htmx.RenderStatus(c, fiber.StatusBadRequest, ImportPage(shareURL, "That link can't be imported. Only templates and routines can, and the link may have expired."))
+	}
+	if err != nil {
+		return htmx.RenderStatus(c, fiber.StatusBadGateway, ImportPage(shareURL, "Couldn't reach that link. Check it and try again."))
+	}
+
+	path, err := Import(db, export)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to import")
+	}
+
+	return htmx.Redirect(c, path)
+}
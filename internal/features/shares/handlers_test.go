@@ -0,0 +1,247 @@
+package shares_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"phobos/internal/features/exercises"
+	"phobos/internal/features/routines"
+	"phobos/internal/features/shares"
+	"phobos/internal/features/templates"
+	"phobos/internal/features/workouts"
+	"phobos/internal/testutil"
+
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+)
+
+var sharePath = regexp.MustCompile(`/s/[0-9a-f]{64}`)
+
+func TestShareWorkout(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	squatID, _ := exercises.Create(app.DB, "Squat")
+	workoutID, _ := workouts.Create(app.DB, "Leg Day", time.Now(), nil)
+	weID, _ := workouts.AddExercise(app.DB, workoutID, squatID)
+	workouts.AddSet(app.DB, weID, 5, 225)
+	form := "kind=workout&id=" + strconv.FormatInt(workoutID, 10)
+
+	// Workouts can only be shared once finished
+	if resp := app.HTMXRequest("POST", "/shares", form); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an in-progress workout, got %d", resp.StatusCode)
+	}
+
+	workouts.Finish(app.DB, workoutID)
+	resp := app.HTMXRequest("POST", "/shares", form)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	path := sharePath.FindString(testutil.ReadBody(t, resp))
+	if path == "" {
+		t.Fatal("expected the panel to show the new link")
+	}
+
+	// Only a hash of the token is stored, so the link is shown just once
+	var stored string
+	app.DB.QueryRow(`SELECT token_hash FROM share_links`).Scan(&stored)
+	if stored == "" || strings.Contains(path, stored) {
+		t.Errorf("expected the token to be stored hashed, got %q", stored)
+	}
+	panel := testutil.ReadBody(t, app.HTMXRequest("GET", "/shares/panel?kind=workout&id="+strconv.FormatInt(workoutID, 10), ""))
+	if strings.Contains(panel, path) || !strings.Contains(panel, "Revoke") {
+		t.Error("expected the panel to list the link without its URL")
+	}
+
+	resp = app.Request("GET", path, "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "Leg Day") || !strings.Contains(body, "Squat") {
+		t.Error("expected the shared page to show the workout")
+	}
+	if strings.Contains(body, "hx-delete") || strings.Contains(body, `href="/workouts`) {
+		t.Error("expected the shared page to be read-only without athlete navigation")
+	}
+
+	// A workout's export isn't importable
+	if resp := app.Request("GET", path+"/export", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404 exporting a workout, got %d", resp.StatusCode)
+	}
+}
+
+func TestShare_RevokedAndExpired(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	templateID, _ := templates.Create(app.DB, "Push")
+
+	id, token, _ := shares.Create(app.DB, shares.KindTemplate, templateID, 7)
+	link, _ := shares.GetByID(app.DB, id)
+	if link.ExpiresAt == nil || link.ExpiresAt.Before(time.Now().AddDate(0, 0, 6)) {
+		t.Errorf("expected the link to expire in 7 days, got %v", link.ExpiresAt)
+	}
+	if resp := app.Request("GET", shares.Path(token), ""); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	app.HTMXRequest("DELETE", "/shares/"+strconv.FormatInt(id, 10), "")
+	if resp := app.Request("GET", shares.Path(token), ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404 for a revoked link, got %d", resp.StatusCode)
+	}
+
+	id, token, _ = shares.Create(app.DB, shares.KindTemplate, templateID, 1)
+	app.DB.Exec(`UPDATE share_links SET expires_at = datetime('now', '-1 minute') WHERE id = ?`, id)
+	if resp := app.Request("GET", shares.Path(token), ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404 for an expired link, got %d", resp.StatusCode)
+	}
+
+	if links, _ := shares.ListActive(app.DB, shares.KindTemplate, templateID); len(links) != 0 {
+		t.Errorf("expected no active links, got %d", len(links))
+	}
+}
+
+func TestHandleExport_Template(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	benchID, _ := exercises.Create(app.DB, "Bench Press")
+	templateID, _ := templates.Create(app.DB, "Push")
+	templates.AddExercise(app.DB, templateID, benchID, 3, 8)
+	_, token, _ := shares.Create(app.DB, shares.KindTemplate, templateID, 0)
+
+	resp := app.Request("GET", shares.Path(token)+"/export", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	var export shares.Export
+	if err := json.NewDecoder(resp.Body).Decode(&export); err != nil {
+		t.Fatalf("failed to decode export: %v", err)
+	}
+	if export.Kind != shares.KindTemplate || export.Name != "Push" || len(export.Exercises) != 1 {
+		t.Fatalf("unexpected export %+v", export)
+	}
+	if e := export.Exercises[0]; e.Name != "Bench Press" || e.TargetSets != 3 || e.TargetReps != 8 {
+		t.Errorf("unexpected exercise %+v", e)
+	}
+}
+
+func TestHandleImport_RoutineFromAnotherInstall(t *testing.T) {
+	t.Parallel()
+	source := testutil.NewTestApp(t)
+	defer source.Close()
+	server := httptest.NewServer(adaptor.FiberApp(source.App))
+	defer server.Close()
+
+	squatID, _ := exercises.Create(source.DB, "Squat")
+	curlID, _ := exercises.Create(source.DB, "Cable Curl")
+	legsID, _ := templates.Create(source.DB, "Legs")
+	templates.AddExercise(source.DB, legsID, squatID, 5, 5)
+	armsID, _ := templates.Create(source.DB, "Arms")
+	templates.AddExercise(source.DB, armsID, curlID, 3, 12)
+	routineID, _ := routines.Create(source.DB, "Split")
+	routines.AddTemplate(source.DB, routineID, legsID)
+	routines.AddTemplate(source.DB, routineID, armsID)
+	_, token, _ := shares.Create(source.DB, shares.KindRoutine, routineID, 0)
+
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+	existingID, _ := exercises.Create(app.DB, "squat")
+
+	resp := app.Request("POST", "/import", "url="+url.QueryEscape(server.URL+shares.Path(token)))
+	if resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("expected status 303, got %d: %s", resp.StatusCode, testutil.ReadBody(t, resp))
+	}
+	if !strings.HasPrefix(resp.Header.Get("Location"), "/routines/") {
+		t.Errorf("expected a redirect to the new routine, got %q", resp.Header.Get("Location"))
+	}
+
+	list, _ := routines.ListAll(app.DB)
+	if len(list) != 1 || list[0].Name != "Split" {
+		t.Fatalf("expected routine Split, got %+v", list)
+	}
+	rts, _ := routines.GetRoutineTemplates(app.DB, list[0].ID)
+	if len(rts) != 2 || rts[0].Template.Name != "Legs" || rts[1].Template.Name != "Arms" {
+		t.Fatalf("expected Legs then Arms, got %+v", rts)
+	}
+
+	legs, _ := templates.GetByID(app.DB, rts[0].TemplateID)
+	if len(legs.Exercises) != 1 || legs.Exercises[0].ExerciseID != existingID || legs.Exercises[0].TargetReps != 5 {
+		t.Errorf("expected legs to use the existing squat, got %+v", legs.Exercises)
+	}
+	arms, _ := templates.GetByID(app.DB, rts[1].TemplateID)
+	if len(arms.Exercises) != 1 || arms.Exercises[0].Exercise.Name != "Cable Curl" {
+		t.Errorf("expected arms to use a new cable curl exercise, got %+v", arms.Exercises)
+	}
+}
+
+func TestImport_AllOrNothing(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	// The second template breaks the target check after the first is copied
+	_, err := shares.Import(app.DB, &shares.Export{
+		Kind: shares.KindRoutine,
+		Name: "Split",
+		Templates: []shares.ExportedTemplate{
+			{Name: "Legs", Exercises: []shares.ExportedExercise{{Name: "Squat", TargetSets: 5, TargetReps: 5}}},
+			{Name: "Arms", Exercises: []shares.ExportedExercise{{Name: "Curl", TargetSets: 0, TargetReps: 12}}},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected an error for an invalid target")
+	}
+
+	if list, _ := templates.ListAll(app.DB); len(list) != 0 {
+		t.Errorf("expected no templates, got %d", len(list))
+	}
+	if list, _ := routines.ListAll(app.DB); len(list) != 0 {
+		t.Errorf("expected no routines, got %d", len(list))
+	}
+	if list, _ := exercises.ListAll(app.DB); len(list) != 0 {
+		t.Errorf("expected no exercises, got %d", len(list))
+	}
+}
+
+func TestHandleImport_Invalid(t *testing.T) {
+	t.Parallel()
+	source := testutil.NewTestApp(t)
+	defer source.Close()
+	server := httptest.NewServer(adaptor.FiberApp(source.App))
+	defer server.Close()
+
+	workoutID, _ := workouts.Create(source.DB, "Leg Day", time.Now(), nil)
+	workouts.Finish(source.DB, workoutID)
+	_, token, _ := shares.Create(source.DB, shares.KindWorkout, workoutID, 0)
+
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	for _, shareURL := range []string{
+		"ftp://example.com/s/abc",
+		server.URL + "/templates/1",
+		server.URL + shares.Path(token),
+		server.URL + "/s/unknown",
+	} {
+		resp := app.Request("POST", "/import", "url="+url.QueryEscape(shareURL))
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", shareURL, resp.StatusCode)
+		}
+	}
+
+	if list, _ := templates.ListAll(app.DB); len(list) != 0 {
+		t.Errorf("expected nothing imported, got %d templates", len(list))
+	}
+}
@@ -0,0 +1,69 @@
+package shares
+
+import "time"
+
+// Kind is what a share link points at
+type Kind string
+
+const (
+	KindWorkout  Kind = "workout"
+	KindTemplate Kind = "template"
+	KindRoutine  Kind = "routine"
+)
+
+// ParseKind returns the kind named by s, or false if there is no such kind
+func ParseKind(s string) (Kind, bool) {
+	switch k := Kind(s); k {
+	case KindWorkout, KindTemplate, KindRoutine:
+		return k, true
+	}
+	return "", false
+}
+
+// ExpiryOptions are the lifetimes offered for a new link, in days; 0 never expires
+var ExpiryOptions = []int{0, 1, 7, 30}
+
+// ShareLink is a public read-only link to a finished workout, a template or a
+// routine. Only a hash of its token is stored.
+type ShareLink struct {
+	ID        int64
+	Kind      Kind
+	TargetID  int64
+	CreatedAt time.Time
+	ExpiresAt *time.Time
+	RevokedAt *time.Time
+}
+
+// Path returns the public path of the link with a token
+func Path(token string) string {
+	return "/s/" + token
+}
+
+// Importable reports whether viewers can copy what the link points at into
+// their own library
+func (l ShareLink) Importable() bool {
+	return l.Kind == KindTemplate || l.Kind == KindRoutine
+}
+
+// Export is the portable form of a shared template or routine, served as JSON
+// so another install can import it. Exercises are identified by name because
+// IDs mean nothing outside the install they came from.
+type Export struct {
+	Kind      Kind               `json:"kind"`
+	Name      string             `json:"name"`
+	Exercises []ExportedExercise `json:"exercises,omitempty"` // Templates only
+	Templates []ExportedTemplate `json:"templates,omitempty"` // Routines only, in order
+}
+
+// ExportedTemplate is a template within an exported routine
+type ExportedTemplate struct {
+	Name      string             `json:"name"`
+	Exercises []ExportedExercise `json:"exercises"`
+}
+
+// ExportedExercise is an exercise with its targets within an exported template
+type ExportedExercise struct {
+	Name       string `json:"name"`
+	TargetSets int    `json:"target_sets"`
+	TargetReps int    `json:"target_reps"`
+}
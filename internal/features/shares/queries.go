@@ -0,0 +1,125 @@
+package shares
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"phobos/internal/shared/dates"
+	"phobos/internal/shared/tokens"
+)
+
+// Create adds a share link and returns its ID and token. A link with
+// expiresInDays of 0 never expires. The token can't be recovered later.
+func Create(db *sql.DB, kind Kind, targetID int64, expiresInDays int) (int64, string, error) {
+	token, err := tokens.New()
+	if err != nil {
+		return 0, "", err
+	}
+
+	var expiresAt *string
+	if expiresInDays > 0 {
+		ts := time.Now().AddDate(0, 0, expiresInDays).UTC().Format(dates.TimestampLayout)
+		expiresAt = &ts
+	}
+
+	result, err := db.Exec(`
+		INSERT INTO share_links (token_hash, kind, target_id, expires_at) VALUES (?, ?, ?, ?)
+	`, tokens.Hash(token), kind, targetID, expiresAt)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create share link: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, "", err
+	}
+	return id, token, nil
+}
+
+// activeCondition matches links that haven't been revoked or expired
+const activeCondition = `revoked_at IS NULL AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)`
+
+// ListActive returns the working links to a target, newest first
+func ListActive(db *sql.DB, kind Kind, targetID int64) ([]ShareLink, error) {
+	rows, err := db.Query(`
+		SELECT id, kind, target_id, created_at, expires_at, revoked_at
+		FROM share_links
+		WHERE kind = ? AND target_id = ? AND `+activeCondition+`
+		ORDER BY created_at DESC, id DESC
+	`, kind, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list share links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []ShareLink
+	for rows.Next() {
+		l, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+
+	return links, rows.Err()
+}
+
+// GetByID returns a link whether or not it still works
+func GetByID(db *sql.DB, id int64) (*ShareLink, error) {
+	l, err := scanLink(db.QueryRow(`
+		SELECT id, kind, target_id, created_at, expires_at, revoked_at
+		FROM share_links
+		WHERE id = ?
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// GetByToken returns the link with a token, or nil if there is none or it has
+// been revoked or has expired
+func GetByToken(db *sql.DB, token string) (*ShareLink, error) {
+	l, err := scanLink(db.QueryRow(`
+		SELECT id, kind, target_id, created_at, expires_at, revoked_at
+		FROM share_links
+		WHERE token_hash = ? AND `+activeCondition, tokens.Hash(token)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func scanLink(row interface{ Scan(...any) error }) (ShareLink, error) {
+	var l ShareLink
+	var expiresAt, revokedAt sql.NullTime
+	if err := row.Scan(&l.ID, &l.Kind, &l.TargetID, &l.CreatedAt, &expiresAt, &revokedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return l, err
+		}
+		return l, fmt.Errorf("failed to scan share link: %w", err)
+	}
+	if expiresAt.Valid {
+		l.ExpiresAt = &expiresAt.Time
+	}
+	if revokedAt.Valid {
+		l.RevokedAt = &revokedAt.Time
+	}
+	return l, nil
+}
+
+// Revoke stops a link from working
+func Revoke(db *sql.DB, id int64) error {
+	_, err := db.Exec(`UPDATE share_links SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND revoked_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke share link: %w", err)
+	}
+	return nil
+}
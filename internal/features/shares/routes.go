@@ -0,0 +1,17 @@
+package shares
+
+import "github.com/gofiber/fiber/v2"
+
+// RegisterRoutes sets up share link management, public share pages and import
+func RegisterRoutes(app *fiber.App) {
+	app.Get("/shares/panel", HandlePanel)
+	app.Post("/shares", HandleCreate)
+	app.Delete("/shares/:id", HandleRevoke)
+
+	// Public pages, authorised by the token in the link
+	app.Get("/s/:token", HandleShow)
+	app.Get("/s/:token/export", HandleExport)
+
+	app.Get("/import", HandleImportForm)
+	app.Post("/import", HandleImport)
+}
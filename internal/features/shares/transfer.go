@@ -0,0 +1,232 @@
+package shares
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"phobos/internal/features/exercises"
+	"phobos/internal/features/routines"
+	"phobos/internal/features/templates"
+)
+
+// maxExportSize caps how much of a fetched export is read
+const maxExportSize = 1 << 20
+
+var (
+	// ErrInvalidShareURL is returned when importing from something that isn't a share link
+	ErrInvalidShareURL = errors.New("not a share link")
+	// ErrInvalidExport is returned when a fetched export can't be imported
+	ErrInvalidExport = errors.New("shared link can't be imported")
+)
+
+// ExportTemplate returns the portable form of a template, or nil if there is no such template
+func ExportTemplate(db *sql.DB, id int64) (*Export, error) {
+	t, err := templates.GetByID(db, id)
+	if err != nil || t == nil {
+		return nil, err
+	}
+	return &Export{Kind: KindTemplate, Name: t.Name, Exercises: exportExercises(t)}, nil
+}
+
+// ExportRoutine returns the portable form of a routine with its templates, or
+// nil if there is no such routine
+func ExportRoutine(db *sql.DB, id int64) (*Export, error) {
+	r, err := routines.GetByID(db, id)
+	if err != nil || r == nil {
+		return nil, err
+	}
+
+	e := &Export{Kind: KindRoutine, Name: r.Name}
+	for _, rt := range r.Templates {
+		t, err := templates.GetByID(db, rt.TemplateID)
+		if err != nil {
+			return nil, err
+		}
+		if t == nil {
+			continue
+		}
+		e.Templates = append(e.Templates, ExportedTemplate{Name: t.Name, Exercises: exportExercises(t)})
+	}
+	return e, nil
+}
+
+func exportExercises(t *templates.WorkoutTemplate) []ExportedExercise {
+	exported := make([]ExportedExercise, 0, len(t.Exercises))
+	for _, te := range t.Exercises {
+		exported = append(exported, ExportedExercise{
+			Name:       te.Exercise.Name,
+			TargetSets: te.TargetSets,
+			TargetReps: te.TargetReps,
+		})
+	}
+	return exported
+}
+
+// FetchExport downloads the export behind another install's share link. The
+// client should be one from safehttp, since the link comes from a visitor.
+func FetchExport(client *http.Client, shareURL string) (*Export, error) {
+	u, err := url.Parse(strings.TrimSpace(shareURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrInvalidShareURL
+	}
+	token, ok := strings.CutPrefix(strings.TrimSuffix(u.Path, "/"), "/s/")
+	if !ok || token == "" || strings.Contains(token, "/") {
+		return nil, ErrInvalidShareURL
+	}
+	u.Path = "/s/" + token + "/export"
+	u.RawQuery = ""
+	u.Fragment = ""
+
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch shared link: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: server answered %d", ErrInvalidExport, resp.StatusCode)
+	}
+
+	var e Export
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxExportSize)).Decode(&e); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+	}
+	if err := validateExport(&e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func validateExport(e *Export) error {
+	if strings.TrimSpace(e.Name) == "" {
+		return fmt.Errorf("%w: missing name", ErrInvalidExport)
+	}
+	switch e.Kind {
+	case KindTemplate:
+		return validateExercises(e.Exercises)
+	case KindRoutine:
+		if len(e.Templates) == 0 {
+			return fmt.Errorf("%w: routine has no templates", ErrInvalidExport)
+		}
+		for _, t := range e.Templates {
+			if strings.TrimSpace(t.Name) == "" {
+				return fmt.Errorf("%w: template without a name", ErrInvalidExport)
+			}
+			if err := validateExercises(t.Exercises); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unknown kind %q", ErrInvalidExport, e.Kind)
+}
+
+func validateExercises(exported []ExportedExercise) error {
+	for _, ee := range exported {
+		if strings.TrimSpace(ee.Name) == "" || ee.TargetSets < 1 || ee.TargetReps < 1 {
+			return fmt.Errorf("%w: invalid exercise", ErrInvalidExport)
+		}
+	}
+	return nil
+}
+
+// Import deep-copies an export into this install's library and returns the
+// path of the new template or routine. Exercises are matched by name
+// (ignoring case) and created when missing. Routines get fresh copies of
+// their templates, so later edits on either side don't affect the other.
+// Nothing is kept if any part of the import fails.
+func Import(db *sql.DB, e *Export) (string, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var path string
+	switch e.Kind {
+	case KindTemplate:
+		id, err := importTemplate(tx, e.Name, e.Exercises)
+		if err != nil {
+			return "", err
+		}
+		path = "/templates/" + strconv.FormatInt(id, 10)
+	case KindRoutine:
+		id, err := importRoutine(tx, e)
+		if err != nil {
+			return "", err
+		}
+		path = "/routines/" + strconv.FormatInt(id, 10)
+	default:
+		return "", ErrInvalidExport
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return path, nil
+}
+
+func importTemplate(tx *sql.Tx, name string, exported []ExportedExercise) (int64, error) {
+	exerciseIDs := make([]int64, len(exported))
+	for i, ee := range exported {
+		id, err := findOrCreateExercise(tx, strings.TrimSpace(ee.Name))
+		if err != nil {
+			return 0, err
+		}
+		exerciseIDs[i] = id
+	}
+
+	templateID, err := templates.CreateTx(tx, strings.TrimSpace(name))
+	if err != nil {
+		return 0, err
+	}
+	for i, ee := range exported {
+		if _, err := templates.AddExerciseTx(tx, templateID, exerciseIDs[i], ee.TargetSets, ee.TargetReps); err != nil {
+			return 0, err
+		}
+	}
+	return templateID, nil
+}
+
+func importRoutine(tx *sql.Tx, e *Export) (int64, error) {
+	var templateIDs []int64
+	for _, t := range e.Templates {
+		id, err := importTemplate(tx, t.Name, t.Exercises)
+		if err != nil {
+			return 0, err
+		}
+		templateIDs = append(templateIDs, id)
+	}
+
+	routineID, err := routines.CreateTx(tx, strings.TrimSpace(e.Name))
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range templateIDs {
+		if _, err := routines.AddTemplateTx(tx, routineID, id); err != nil {
+			return 0, err
+		}
+	}
+	return routineID, nil
+}
+
+// findOrCreateExercise returns the ID of the exercise with a name, creating it if needed
+func findOrCreateExercise(tx *sql.Tx, name string) (int64, error) {
+	var id int64
+	err := tx.QueryRow(`SELECT id FROM exercises WHERE LOWER(name) = LOWER(?)`, name).Scan(&id)
+	if err == sql.ErrNoRows {
+		return exercises.CreateTx(tx, name)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find exercise: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,68 @@
+package strength
+
+import (
+	"strconv"
+	"time"
+
+	"phobos/internal/shared/htmx"
+	"phobos/internal/shared/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HandleProfile displays the strength profile and its settings
+func HandleProfile(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	profile, err := GetProfile(db, time.Now())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load strength profile")
+	}
+
+	allExercises, err := ListExerciseOptions(db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load exercises")
+	}
+
+	return htmx.Render(c, ProfilePage(profile, allExercises, ListStandards()))
+}
+
+// HandleUpdateSettings saves the lifter's sex, standards table and the exercise for each lift
+func HandleUpdateSettings(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	settings := Settings{Sex: Sex(c.FormValue("sex")), Standards: c.FormValue("standards")}
+	if settings.Sex != SexMale && settings.Sex != SexFemale {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid sex")
+	}
+	if _, ok := GetStandards(settings.Standards); !ok {
+		return c.Status(fiber.StatusBadRequest).SendString("Unknown standards table")
+	}
+
+	lifts := make(map[string]*int64)
+	for _, lift := range Lifts {
+		raw := c.FormValue("lift_" + lift)
+		if raw == "" {
+			lifts[lift] = nil
+			continue
+		}
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid exercise for " + LiftLabel(lift))
+		}
+		exercise, err := GetExerciseOption(db, id)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString("Failed to load exercise")
+		}
+		if exercise == nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid exercise for " + LiftLabel(lift))
+		}
+		lifts[lift] = &id
+	}
+
+	if err := UpdateSettings(db, settings, lifts); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to save settings")
+	}
+
+	return htmx.Refresh(c)
+}
@@ -0,0 +1,260 @@
+package strength_test
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"phobos/internal/features/exercises"
+	"phobos/internal/features/metrics"
+	"phobos/internal/features/strength"
+	"phobos/internal/features/workouts"
+	"phobos/internal/testutil"
+)
+
+const lbsPerKg = 1 / 0.45359237
+
+func TestPointsFormulas(t *testing.T) {
+	t.Parallel()
+
+	// 700 kg total at 100 kg bodyweight
+	total, bw := 700*lbsPerKg, 100*lbsPerKg
+
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"wilks", strength.Wilks(strength.SexMale, total, bw), 425.8},
+		{"dots", strength.DOTS(strength.SexMale, total, bw), 430.9},
+		{"ipf gl", strength.IPFGL(strength.SexMale, total, bw), 88.4},
+		// 200 kg total at 35 kg, below the men's 40 kg floor
+		{"wilks female", strength.Wilks(strength.SexFemale, 200*lbsPerKg, 35*lbsPerKg), 318.4},
+	}
+	for _, tt := range tests {
+		if math.Abs(tt.got-tt.want) > 0.5 {
+			t.Errorf("%s: expected about %v, got %v", tt.name, tt.want, tt.got)
+		}
+	}
+}
+
+func TestEstimateOneRepMax(t *testing.T) {
+	t.Parallel()
+
+	if got := strength.EstimateOneRepMax(300, 1); got != 300 {
+		t.Errorf("expected a single to be its own max, got %v", got)
+	}
+	if got := strength.EstimateOneRepMax(300, 5); got != 350 {
+		t.Errorf("expected 350, got %v", got)
+	}
+	if got := strength.EstimateOneRepMax(100, strength.MaxE1RMReps+1); got != 0 {
+		t.Errorf("expected long sets to be ignored, got %v", got)
+	}
+}
+
+func TestStandardsClassify(t *testing.T) {
+	t.Parallel()
+
+	table, ok := strength.GetStandards("general")
+	if !ok {
+		t.Fatal("expected embedded general standards")
+	}
+
+	level, next, nextMultiple := table.Classify(strength.SexMale, strength.LiftSquat, 1.6)
+	if level != "Intermediate" || next != "Advanced" || nextMultiple != 2.25 {
+		t.Errorf("expected Intermediate then Advanced at 2.25, got %q, %q, %v", level, next, nextMultiple)
+	}
+
+	level, next, _ = table.Classify(strength.SexMale, strength.LiftSquat, 0.5)
+	if level != "" || next != "Beginner" {
+		t.Errorf("expected below standards, got %q, %q", level, next)
+	}
+
+	level, next, _ = table.Classify(strength.SexMale, strength.LiftSquat, 3.5)
+	if level != "Elite" || next != "" {
+		t.Errorf("expected top level, got %q, %q", level, next)
+	}
+}
+
+func TestFinishRefreshesLiftRecords(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	squatID, _ := exercises.Create(app.DB, "Back Squat")
+	squat := squatID
+	strength.SetLiftExercise(app.DB, strength.LiftSquat, &squat)
+
+	workoutID, _ := workouts.Create(app.DB, "Legs", time.Now(), nil)
+	weID, _ := workouts.AddExercise(app.DB, workoutID, squatID)
+	workouts.AddSet(app.DB, weID, 5, 300)
+	workouts.AddSet(app.DB, weID, 1, 330)
+
+	records, _ := strength.ListRecords(app.DB)
+	if records[0].E1RM != nil {
+		t.Fatal("expected no record before the workout is finished")
+	}
+
+	workouts.Finish(app.DB, workoutID)
+
+	records, err := strength.ListRecords(app.DB)
+	if err != nil {
+		t.Fatalf("failed to list records: %v", err)
+	}
+	if records[0].Lift != strength.LiftSquat || records[0].E1RM == nil || *records[0].E1RM != 350 {
+		t.Fatalf("expected squat e1RM 350, got %+v", records[0])
+	}
+	if records[0].Weight != 300 || records[0].Reps != 5 {
+		t.Errorf("expected the record to come from 300 x 5, got %v x %d", records[0].Weight, records[0].Reps)
+	}
+}
+
+func TestDeletingRecordWorkoutRefreshesLiftRecords(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	squatID, _ := exercises.Create(app.DB, "Back Squat")
+	strength.SetLiftExercise(app.DB, strength.LiftSquat, &squatID)
+
+	logSquat := func(weight float64) (int64, int64) {
+		workoutID, _ := workouts.Create(app.DB, "Legs", time.Now(), nil)
+		weID, _ := workouts.AddExercise(app.DB, workoutID, squatID)
+		setID, _ := workouts.AddSet(app.DB, weID, 1, weight)
+		workouts.Finish(app.DB, workoutID)
+		return workoutID, setID
+	}
+	olderID, olderSetID := logSquat(300)
+	prID, _ := logSquat(330)
+
+	records, _ := strength.ListRecords(app.DB)
+	if records[0].WorkoutID == nil || *records[0].WorkoutID != prID {
+		t.Fatalf("expected the record from workout %d, got %+v", prID, records[0])
+	}
+
+	if err := workouts.Delete(app.DB, prID); err != nil {
+		t.Fatalf("failed to delete workout: %v", err)
+	}
+
+	records, _ = strength.ListRecords(app.DB)
+	if records[0].WorkoutID == nil || *records[0].WorkoutID != olderID || records[0].Weight != 300 {
+		t.Fatalf("expected the record to fall back to workout %d, got %+v", olderID, records[0])
+	}
+
+	// Editing and deleting sets of a finished workout move the record too
+	workouts.UpdateSet(app.DB, olderSetID, 1, 310)
+	records, _ = strength.ListRecords(app.DB)
+	if records[0].Weight != 310 {
+		t.Errorf("expected the record to follow the edited set, got %+v", records[0])
+	}
+
+	workouts.DeleteSet(app.DB, olderSetID)
+	records, _ = strength.ListRecords(app.DB)
+	if records[0].E1RM != nil || records[0].WorkoutID != nil {
+		t.Errorf("expected the record to be cleared, got %+v", records[0])
+	}
+}
+
+func TestMergeRefreshesLiftRecords(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	squatID, _ := exercises.Create(app.DB, "Back Squat")
+	oldID, _ := exercises.Create(app.DB, "Squat (old)")
+	strength.SetLiftExercise(app.DB, strength.LiftSquat, &squatID)
+
+	workoutID, _ := workouts.Create(app.DB, "Legs", time.Now(), nil)
+	weID, _ := workouts.AddExercise(app.DB, workoutID, oldID)
+	workouts.AddSet(app.DB, weID, 1, 350)
+	workouts.Finish(app.DB, workoutID)
+
+	if err := exercises.Merge(app.DB, oldID, squatID); err != nil {
+		t.Fatalf("failed to merge exercises: %v", err)
+	}
+
+	records, _ := strength.ListRecords(app.DB)
+	if records[0].E1RM == nil || records[0].Weight != 350 {
+		t.Errorf("expected the merged sets to count towards the squat, got %+v", records[0])
+	}
+}
+
+func TestHandleProfile(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	metrics.SaveEntries(app.DB, time.Now(), map[string]float64{metrics.MetricBodyweight: 200})
+	for _, lift := range []struct {
+		lift   string
+		name   string
+		weight float64
+	}{
+		{strength.LiftSquat, "Squat", 400},
+		{strength.LiftBench, "Bench", 300},
+		{strength.LiftDeadlift, "Deadlift", 500},
+	} {
+		id, _ := exercises.Create(app.DB, lift.name)
+		strength.SetLiftExercise(app.DB, lift.lift, &id)
+		workoutID, _ := workouts.Create(app.DB, lift.name+" Day", time.Now(), nil)
+		weID, _ := workouts.AddExercise(app.DB, workoutID, id)
+		workouts.AddSet(app.DB, weID, 1, lift.weight)
+		workouts.Finish(app.DB, workoutID)
+	}
+
+	resp := app.Request("GET", "/strength", "")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "1200 lbs") {
+		t.Error("expected the squat, bench and deadlift total")
+	}
+	if !strings.Contains(body, "2.00") || !strings.Contains(body, "Intermediate") {
+		t.Error("expected squat bodyweight multiple and level")
+	}
+}
+
+func TestHandleUpdateSettings(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	benchID, _ := exercises.Create(app.DB, "Bench Press")
+
+	resp := app.HTMXRequest("PUT", "/strength/settings",
+		"sex=female&standards=general&lift_bench="+strconv.FormatInt(benchID, 10))
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	settings, _ := strength.GetSettings(app.DB)
+	if settings.Sex != strength.SexFemale {
+		t.Errorf("expected sex to be saved, got %s", settings.Sex)
+	}
+	records, _ := strength.ListRecords(app.DB)
+	if records[1].ExerciseID == nil || *records[1].ExerciseID != benchID {
+		t.Errorf("expected bench to map to exercise %d, got %v", benchID, records[1].ExerciseID)
+	}
+
+	resp = app.HTMXRequest("PUT", "/strength/settings", "sex=female&standards=missing")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400 for unknown standards, got %d", resp.StatusCode)
+	}
+
+	// An unknown exercise saves nothing
+	resp = app.HTMXRequest("PUT", "/strength/settings", "sex=male&standards=general&lift_bench=9999")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown exercise, got %d", resp.StatusCode)
+	}
+	settings, _ = strength.GetSettings(app.DB)
+	if settings.Sex != strength.SexFemale {
+		t.Errorf("expected the settings to be left alone, got %s", settings.Sex)
+	}
+}
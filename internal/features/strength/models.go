@@ -0,0 +1,88 @@
+package strength
+
+import "time"
+
+// Sex selects the coefficients used for points formulas and standards
+type Sex string
+
+const (
+	SexMale   Sex = "male"
+	SexFemale Sex = "female"
+)
+
+// Big lifts scored on the strength profile
+const (
+	LiftSquat    = "squat"
+	LiftBench    = "bench"
+	LiftDeadlift = "deadlift"
+	LiftPress    = "press"
+)
+
+// Lifts lists the big lifts in display order
+var Lifts = []string{LiftSquat, LiftBench, LiftDeadlift, LiftPress}
+
+// TotalLifts are the lifts summed into the powerlifting total
+var TotalLifts = []string{LiftSquat, LiftBench, LiftDeadlift}
+
+var liftLabels = map[string]string{
+	LiftSquat:    "Squat",
+	LiftBench:    "Bench Press",
+	LiftDeadlift: "Deadlift",
+	LiftPress:    "Overhead Press",
+}
+
+// LiftLabel returns the display name of a lift
+func LiftLabel(lift string) string {
+	if label, ok := liftLabels[lift]; ok {
+		return label
+	}
+	return lift
+}
+
+// MaxE1RMReps is the highest rep count used to estimate a one-rep max;
+// estimates from longer sets are too unreliable to score
+const MaxE1RMReps = 12
+
+// Settings is the lifter profile used for scoring
+type Settings struct {
+	Sex       Sex
+	Standards string // Name of the embedded standards table
+}
+
+// LiftRecord is the best estimated one-rep max for a lift's exercise
+type LiftRecord struct {
+	Lift         string
+	ExerciseID   *int64
+	ExerciseName string
+	E1RM         *float64
+	Weight       float64 // Set the estimate came from
+	Reps         int
+	Date         *time.Time
+	WorkoutID    *int64
+}
+
+// ExerciseOption is an exercise that can be chosen for a lift
+type ExerciseOption struct {
+	ID   int64
+	Name string
+}
+
+// LiftScore is a lift's record scored against bodyweight and standards
+type LiftScore struct {
+	LiftRecord
+	Multiple  float64 // E1RM / bodyweight
+	Level     string  // Empty below the first standard
+	NextLevel string  // Empty at the top level
+	NextE1RM  float64 // E1RM needed to reach NextLevel
+}
+
+// Profile is the full strength profile for a bodyweight
+type Profile struct {
+	Settings   Settings
+	Bodyweight *float64
+	Lifts      []LiftScore
+	Total      float64 // Sum of TotalLifts estimates, 0 unless all are known
+	Wilks      float64
+	DOTS       float64
+	IPFGL      float64
+}
@@ -0,0 +1,300 @@
+package strength
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"phobos/internal/features/metrics"
+	"phobos/internal/shared/dates"
+)
+
+// querier is a *sql.DB or a *sql.Tx, so records can be refreshed inside a
+// caller's transaction
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// GetSettings returns the lifter profile settings
+func GetSettings(db *sql.DB) (Settings, error) {
+	var s Settings
+	err := db.QueryRow(`SELECT sex, standards FROM strength_settings WHERE id = 1`).Scan(&s.Sex, &s.Standards)
+	if err != nil {
+		return s, fmt.Errorf("failed to get strength settings: %w", err)
+	}
+	return s, nil
+}
+
+// UpdateSettings saves the settings and the exercise for each lift in one
+// transaction. Lifts missing from the map keep their exercise.
+func UpdateSettings(db *sql.DB, s Settings, lifts map[string]*int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := saveSettings(tx, s); err != nil {
+		return err
+	}
+	for _, lift := range Lifts {
+		exerciseID, ok := lifts[lift]
+		if !ok {
+			continue
+		}
+		if err := setLiftExercise(tx, lift, exerciseID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func saveSettings(db querier, s Settings) error {
+	_, err := db.Exec(`UPDATE strength_settings SET sex = ?, standards = ? WHERE id = 1`, s.Sex, s.Standards)
+	if err != nil {
+		return fmt.Errorf("failed to save strength settings: %w", err)
+	}
+	return nil
+}
+
+// ListRecords returns each big lift's exercise and best estimate, in Lifts order
+func ListRecords(db *sql.DB) ([]LiftRecord, error) {
+	rows, err := db.Query(`
+		SELECT sl.lift, sl.exercise_id, COALESCE(e.name, ''), sl.e1rm,
+		       COALESCE(sl.weight, 0), COALESCE(sl.reps, 0), sl.date, sl.workout_id
+		FROM strength_lifts sl
+		LEFT JOIN exercises e ON e.id = sl.exercise_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list lift records: %w", err)
+	}
+	defer rows.Close()
+
+	byLift := make(map[string]LiftRecord)
+	for rows.Next() {
+		var r LiftRecord
+		var exerciseID, workoutID sql.NullInt64
+		var e1rm sql.NullFloat64
+		var date sql.NullTime
+		if err := rows.Scan(&r.Lift, &exerciseID, &r.ExerciseName, &e1rm, &r.Weight, &r.Reps, &date, &workoutID); err != nil {
+			return nil, fmt.Errorf("failed to scan lift record: %w", err)
+		}
+		if exerciseID.Valid {
+			r.ExerciseID = &exerciseID.Int64
+		}
+		if e1rm.Valid {
+			r.E1RM = &e1rm.Float64
+		}
+		if date.Valid {
+			r.Date = &date.Time
+		}
+		if workoutID.Valid {
+			r.WorkoutID = &workoutID.Int64
+		}
+		byLift[r.Lift] = r
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	records := make([]LiftRecord, 0, len(Lifts))
+	for _, lift := range Lifts {
+		r, ok := byLift[lift]
+		if !ok {
+			r = LiftRecord{Lift: lift}
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// GetExerciseOption returns the exercise with an ID, or nil if there is none
+func GetExerciseOption(db *sql.DB, id int64) (*ExerciseOption, error) {
+	var o ExerciseOption
+	err := db.QueryRow(`SELECT id, name FROM exercises WHERE id = ?`, id).Scan(&o.ID, &o.Name)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exercise: %w", err)
+	}
+	return &o, nil
+}
+
+// ListExerciseOptions returns the active exercises a lift can be counted from, by name
+func ListExerciseOptions(db *sql.DB) ([]ExerciseOption, error) {
+	rows, err := db.Query(`SELECT id, name FROM exercises WHERE archived_at IS NULL ORDER BY name ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exercises: %w", err)
+	}
+	defer rows.Close()
+
+	var options []ExerciseOption
+	for rows.Next() {
+		var o ExerciseOption
+		if err := rows.Scan(&o.ID, &o.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan exercise: %w", err)
+		}
+		options = append(options, o)
+	}
+	return options, rows.Err()
+}
+
+// SetLiftExercise chooses the exercise that counts as a lift and recomputes its record
+func SetLiftExercise(db *sql.DB, lift string, exerciseID *int64) error {
+	return setLiftExercise(db, lift, exerciseID)
+}
+
+func setLiftExercise(db querier, lift string, exerciseID *int64) error {
+	_, err := db.Exec(`UPDATE strength_lifts SET exercise_id = ? WHERE lift = ?`, exerciseID, lift)
+	if err != nil {
+		return fmt.Errorf("failed to set lift exercise: %w", err)
+	}
+	return refreshLift(db, lift)
+}
+
+// RefreshForWorkout recomputes the records of any big lift performed in a
+// workout or whose record came from it. Deleting a workout clears the
+// workout_id of records that came from it, so those are refreshed too.
+func RefreshForWorkout(db *sql.DB, workoutID int64) error {
+	return refreshForWorkout(db, workoutID)
+}
+
+// RefreshForWorkoutTx is RefreshForWorkout as part of a caller's transaction
+func RefreshForWorkoutTx(tx *sql.Tx, workoutID int64) error {
+	return refreshForWorkout(tx, workoutID)
+}
+
+func refreshForWorkout(db querier, workoutID int64) error {
+	return refreshLifts(db, `
+		SELECT lift FROM strength_lifts
+		WHERE workout_id = ? OR workout_id IS NULL
+		   OR exercise_id IN (SELECT exercise_id FROM workout_exercises WHERE workout_id = ?)
+	`, workoutID, workoutID)
+}
+
+// RefreshForExercise recomputes the record of any big lift counted from an exercise
+func RefreshForExercise(db *sql.DB, exerciseID int64) error {
+	return refreshForExercise(db, exerciseID)
+}
+
+// RefreshForExerciseTx is RefreshForExercise as part of a caller's transaction
+func RefreshForExerciseTx(tx *sql.Tx, exerciseID int64) error {
+	return refreshForExercise(tx, exerciseID)
+}
+
+func refreshForExercise(db querier, exerciseID int64) error {
+	return refreshLifts(db, `SELECT lift FROM strength_lifts WHERE exercise_id = ?`, exerciseID)
+}
+
+// refreshLifts recomputes the records of the lifts a query selects
+func refreshLifts(db querier, query string, args ...any) error {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to find lifts: %w", err)
+	}
+
+	var lifts []string
+	for rows.Next() {
+		var lift string
+		if err := rows.Scan(&lift); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan lift: %w", err)
+		}
+		lifts = append(lifts, lift)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, lift := range lifts {
+		if err := refreshLift(db, lift); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshLift stores the best estimated one-rep max across finished workouts
+// for the lift's exercise, or clears it if there is none
+func refreshLift(db querier, lift string) error {
+	rows, err := db.Query(`
+		SELECT ls.weight, ls.reps, w.date, w.id
+		FROM strength_lifts sl
+		JOIN workout_exercises we ON we.exercise_id = sl.exercise_id
+		JOIN workouts w ON w.id = we.workout_id
+		JOIN logged_sets ls ON ls.workout_exercise_id = we.id
+		WHERE sl.lift = ? AND w.status = 'finished' AND ls.set_type = 'working'
+		  AND ls.reps BETWEEN 1 AND ?
+	`, lift, MaxE1RMReps)
+	if err != nil {
+		return fmt.Errorf("failed to get sets for %s: %w", lift, err)
+	}
+
+	var best LiftRecord
+	var bestE1RM float64
+	for rows.Next() {
+		var weight float64
+		var reps int
+		var date time.Time
+		var workoutID int64
+		if err := rows.Scan(&weight, &reps, &date, &workoutID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan set: %w", err)
+		}
+		if e := EstimateOneRepMax(weight, reps); e > bestE1RM {
+			bestE1RM = e
+			best = LiftRecord{Weight: weight, Reps: reps, Date: &date, WorkoutID: &workoutID}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if bestE1RM == 0 {
+		_, err = db.Exec(`
+			UPDATE strength_lifts
+			SET e1rm = NULL, weight = NULL, reps = NULL, date = NULL, workout_id = NULL
+			WHERE lift = ?
+		`, lift)
+	} else {
+		_, err = db.Exec(`
+			UPDATE strength_lifts
+			SET e1rm = ?, weight = ?, reps = ?, date = ?, workout_id = ?
+			WHERE lift = ?
+		`, bestE1RM, best.Weight, best.Reps, best.Date.Format(dates.Layout), *best.WorkoutID, lift)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save %s record: %w", lift, err)
+	}
+	return nil
+}
+
+// GetProfile scores the stored lift records against the latest bodyweight
+func GetProfile(db *sql.DB, now time.Time) (*Profile, error) {
+	settings, err := GetSettings(db)
+	if err != nil {
+		return nil, err
+	}
+
+	bodyweight, err := metrics.BodyweightOn(db, now)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := ListRecords(db)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := Score(settings, bodyweight, records)
+	return &profile, nil
+}
@@ -0,0 +1,9 @@
+package strength
+
+import "github.com/gofiber/fiber/v2"
+
+// RegisterRoutes sets up strength profile routes
+func RegisterRoutes(app *fiber.App) {
+	app.Get("/strength", HandleProfile)
+	app.Put("/strength/settings", HandleUpdateSettings)
+}
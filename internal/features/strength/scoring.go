@@ -0,0 +1,105 @@
+package strength
+
+import "math"
+
+// kgPerLb converts the app's pounds into the kilograms the formulas expect
+const kgPerLb = 0.45359237
+
+// EstimateOneRepMax estimates a one-rep max with the Epley formula.
+// It returns 0 for sets that cannot be scored.
+func EstimateOneRepMax(weight float64, reps int) float64 {
+	if reps < 1 || reps > MaxE1RMReps || weight <= 0 {
+		return 0
+	}
+	if reps == 1 {
+		return weight
+	}
+	return weight * (1 + float64(reps)/30)
+}
+
+// Wilks returns Wilks points (1995 coefficients) for a total and bodyweight in pounds
+func Wilks(sex Sex, totalLbs, bodyweightLbs float64) float64 {
+	coef := [6]float64{-216.0475144, 16.2606339, -0.002388645, -0.00113732, 7.01863e-06, -1.291e-08}
+	lo, hi := 40.0, 201.9
+	if sex == SexFemale {
+		coef = [6]float64{594.31747775582, -27.23842536447, 0.82112226871, -0.00930733913, 4.731582e-05, -9.054e-08}
+		lo, hi = 26.51, 154.53
+	}
+	bw := clamp(bodyweightLbs*kgPerLb, lo, hi)
+	return totalLbs * kgPerLb * 500 / polynomial(coef[:], bw)
+}
+
+// DOTS returns DOTS points for a total and bodyweight in pounds
+func DOTS(sex Sex, totalLbs, bodyweightLbs float64) float64 {
+	coef := [5]float64{-307.75076, 24.0900756, -0.1918759221, 0.0007391293, -0.000001093}
+	lo, hi := 40.0, 210.0
+	if sex == SexFemale {
+		coef = [5]float64{-57.96288, 13.6175032, -0.1126655495, 0.0005158568, -0.0000010706}
+		hi = 150.0
+	}
+	bw := clamp(bodyweightLbs*kgPerLb, lo, hi)
+	return totalLbs * kgPerLb * 500 / polynomial(coef[:], bw)
+}
+
+// IPFGL returns IPF GL points (classic raw powerlifting) for a total and bodyweight in pounds
+func IPFGL(sex Sex, totalLbs, bodyweightLbs float64) float64 {
+	a, b, c := 1199.72839, 1025.18162, 0.00921
+	if sex == SexFemale {
+		a, b, c = 610.32796, 1045.59282, 0.03048
+	}
+	bw := bodyweightLbs * kgPerLb
+	return totalLbs * kgPerLb * 100 / (a - b*math.Exp(-c*bw))
+}
+
+// polynomial evaluates coef[0] + coef[1]*x + coef[2]*x^2 + ...
+func polynomial(coef []float64, x float64) float64 {
+	sum := 0.0
+	for i := len(coef) - 1; i >= 0; i-- {
+		sum = sum*x + coef[i]
+	}
+	return sum
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
+
+// Score builds a strength profile from lift records. Multiples, levels and
+// points need a bodyweight; points also need every lift in TotalLifts.
+func Score(settings Settings, bodyweight *float64, records []LiftRecord) Profile {
+	p := Profile{Settings: settings, Bodyweight: bodyweight}
+	standards, hasStandards := GetStandards(settings.Standards)
+
+	estimates := make(map[string]float64)
+	for _, r := range records {
+		score := LiftScore{LiftRecord: r}
+		if r.E1RM != nil {
+			estimates[r.Lift] = *r.E1RM
+			if bodyweight != nil {
+				score.Multiple = *r.E1RM / *bodyweight
+				if hasStandards {
+					var nextMultiple float64
+					score.Level, score.NextLevel, nextMultiple = standards.Classify(settings.Sex, r.Lift, score.Multiple)
+					score.NextE1RM = nextMultiple * *bodyweight
+				}
+			}
+		}
+		p.Lifts = append(p.Lifts, score)
+	}
+
+	for _, lift := range TotalLifts {
+		e, ok := estimates[lift]
+		if !ok {
+			p.Total = 0
+			return p
+		}
+		p.Total += e
+	}
+
+	if bodyweight != nil {
+		p.Wilks = Wilks(settings.Sex, p.Total, *bodyweight)
+		p.DOTS = DOTS(settings.Sex, p.Total, *bodyweight)
+		p.IPFGL = IPFGL(settings.Sex, p.Total, *bodyweight)
+	}
+	return p
+}
@@ -0,0 +1,96 @@
+package strength
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Standards tables ship as JSON files in standards/. Adding a file adds a
+// table to choose from on the strength page; the file name is its key.
+//
+//go:embed standards/*.json
+var standardsFS embed.FS
+
+// StandardsTable classifies lifts by bodyweight multiple. For each sex and
+// lift, Lifts holds the multiple at which each of Levels begins.
+type StandardsTable struct {
+	Key         string                       `json:"-"`
+	Name        string                       `json:"name"`
+	Description string                       `json:"description"`
+	Levels      []string                     `json:"levels"`
+	Lifts       map[Sex]map[string][]float64 `json:"lifts"`
+}
+
+var standardsTables = mustLoadStandards()
+
+func mustLoadStandards() map[string]StandardsTable {
+	tables, err := loadStandards()
+	if err != nil {
+		panic(err)
+	}
+	return tables
+}
+
+func loadStandards() (map[string]StandardsTable, error) {
+	files, err := standardsFS.ReadDir("standards")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read standards: %w", err)
+	}
+
+	tables := make(map[string]StandardsTable)
+	for _, f := range files {
+		data, err := standardsFS.ReadFile(path.Join("standards", f.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read standards %s: %w", f.Name(), err)
+		}
+		var t StandardsTable
+		if err := json.Unmarshal(data, &t); err != nil {
+			return nil, fmt.Errorf("failed to parse standards %s: %w", f.Name(), err)
+		}
+		for sex, lifts := range t.Lifts {
+			for lift, thresholds := range lifts {
+				if len(thresholds) != len(t.Levels) {
+					return nil, fmt.Errorf("standards %s: %s %s has %d thresholds for %d levels",
+						f.Name(), sex, lift, len(thresholds), len(t.Levels))
+				}
+			}
+		}
+		t.Key = strings.TrimSuffix(f.Name(), ".json")
+		tables[t.Key] = t
+	}
+
+	return tables, nil
+}
+
+// ListStandards returns the embedded standards tables ordered by name
+func ListStandards() []StandardsTable {
+	tables := make([]StandardsTable, 0, len(standardsTables))
+	for _, t := range standardsTables {
+		tables = append(tables, t)
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	return tables
+}
+
+// GetStandards returns the standards table with the given key
+func GetStandards(key string) (StandardsTable, bool) {
+	t, ok := standardsTables[key]
+	return t, ok
+}
+
+// Classify returns the level reached with a bodyweight multiple, and the next
+// level with the multiple it starts at. Levels are empty when not applicable.
+func (t StandardsTable) Classify(sex Sex, lift string, multiple float64) (level, next string, nextMultiple float64) {
+	thresholds := t.Lifts[sex][lift]
+	for i, threshold := range thresholds {
+		if multiple < threshold {
+			return level, t.Levels[i], threshold
+		}
+		level = t.Levels[i]
+	}
+	return level, "", 0
+}
@@ -1,6 +1,7 @@
 package templates
 
 import (
+	"errors"
 	"strconv"
 
 	"phobos/internal/features/exercises"
@@ -155,3 +156,111 @@ func HandleRemoveExercise(c *fiber.Ctx) error {
 
 	return c.SendString("")
 }
+
+// HandleVersions lists a template's version history
+func HandleVersions(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	template, err := GetByID(db, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load template")
+	}
+	if template == nil {
+		return c.Status(fiber.StatusNotFound).SendString("Template not found")
+	}
+
+	versions, err := ListVersions(db, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load versions")
+	}
+
+	return htmx.Render(c, VersionsPage(template, versions))
+}
+
+// HandleVersion shows what changed in a version, compared with the previous
+// version or the one given by ?compare. Versions stay viewable after their
+// template is deleted, since workouts link to them.
+func HandleVersion(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	version, err := strconv.Atoi(c.Params("version"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid version")
+	}
+
+	to, err := GetVersion(db, id, version)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load version")
+	}
+	if to == nil {
+		return c.Status(fiber.StatusNotFound).SendString("Version not found")
+	}
+
+	compare := version - 1
+	if v := c.Query("compare"); v != "" {
+		compare, err = strconv.Atoi(v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid version to compare")
+		}
+	}
+
+	var from *TemplateVersion
+	if compare > 0 {
+		from, err = GetVersion(db, id, compare)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString("Failed to load version")
+		}
+		if from == nil {
+			return c.Status(fiber.StatusNotFound).SendString("Version not found")
+		}
+	}
+
+	template, err := GetByID(db, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load template")
+	}
+
+	return htmx.Render(c, VersionPage(template, Diff(from, to)))
+}
+
+// HandleRestore rolls a template back to an earlier version
+func HandleRestore(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	version, err := strconv.Atoi(c.Params("version"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid version")
+	}
+
+	template, err := GetByID(db, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load template")
+	}
+	if template == nil {
+		return c.Status(fiber.StatusNotFound).SendString("Template not found")
+	}
+
+	if err := Restore(db, id, version); err != nil {
+		if errors.Is(err, ErrVersionNotFound) {
+			return c.Status(fiber.StatusNotFound).SendString("Version not found")
+		}
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to restore version")
+	}
+
+	return htmx.Redirect(c, "/templates/"+strconv.FormatInt(id, 10))
+}
@@ -223,3 +223,166 @@ func TestTemplateWithMultipleExercises(t *testing.T) {
 		t.Errorf("expected third exercise to be Barbell Row, got %s", tmpl.Exercises[2].Exercise.Name)
 	}
 }
+
+func TestVersions_RecordedOnEveryEdit(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	templateID, _ := templates.Create(app.DB, "Legs")
+	squatID, _ := exercises.Create(app.DB, "Squat")
+	teID, _ := templates.AddExercise(app.DB, templateID, squatID, 3, 5)
+	templates.UpdateExercise(app.DB, teID, 5, 5)
+	templates.Update(app.DB, templateID, "Leg Day")
+
+	// Saving without a change doesn't record a version
+	templates.UpdateExercise(app.DB, teID, 5, 5)
+	templates.Update(app.DB, templateID, "Leg Day")
+	templates.ReorderExercises(app.DB, templateID, []int64{teID})
+
+	versions, err := templates.ListVersions(app.DB, templateID)
+	if err != nil {
+		t.Fatalf("failed to list versions: %v", err)
+	}
+	want := []string{"Renamed to Leg Day", "Changed targets of Squat", "Added Squat", "Created"}
+	if len(versions) != len(want) {
+		t.Fatalf("expected %d versions, got %d", len(want), len(versions))
+	}
+	for i, v := range versions {
+		if v.Change != want[i] {
+			t.Errorf("version %d: expected change %q, got %q", v.Version, want[i], v.Change)
+		}
+	}
+
+	// Earlier versions are unaffected by later edits
+	v2, _ := templates.GetVersion(app.DB, templateID, 2)
+	if v2.Name != "Legs" || len(v2.Exercises) != 1 || v2.Exercises[0].TargetSets != 3 {
+		t.Errorf("expected version 2 to keep Legs with 3 sets of squats, got %+v", v2)
+	}
+
+	tmpl, _ := templates.GetByID(app.DB, templateID)
+	if tmpl.Version != 4 {
+		t.Errorf("expected current version 4, got %d", tmpl.Version)
+	}
+}
+
+func TestHandleVersion_ShowsDiff(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	templateID, _ := templates.Create(app.DB, "Legs")
+	squatID, _ := exercises.Create(app.DB, "Squat")
+	lungeID, _ := exercises.Create(app.DB, "Lunge")
+	squat, _ := templates.AddExercise(app.DB, templateID, squatID, 3, 5)
+	lunge, _ := templates.AddExercise(app.DB, templateID, lungeID, 3, 10)
+	templates.UpdateExercise(app.DB, squat, 5, 5)
+	templates.RemoveExercise(app.DB, lunge)
+
+	path := "/templates/" + strconv.FormatInt(templateID, 10) + "/versions/"
+
+	resp := app.Request("GET", path+"4", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "3 sets x 5 reps") || !strings.Contains(body, "5 sets x 5 reps") {
+		t.Error("expected old and new squat targets in the diff")
+	}
+
+	// Compare across several versions
+	resp = app.Request("GET", path+"5?compare=3", "")
+	body = testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "Compared with version 3") {
+		t.Error("expected the compared version to be named")
+	}
+	if !strings.Contains(body, "removed") || !strings.Contains(body, "Lunge") {
+		t.Error("expected lunge to show as removed")
+	}
+
+	resp = app.Request("GET", path+"9", "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404 for a missing version, got %d", resp.StatusCode)
+	}
+}
+
+func TestDiff_RepeatedExercise(t *testing.T) {
+	t.Parallel()
+
+	squat := int64(1)
+	from := &templates.TemplateVersion{Version: 1, Exercises: []templates.VersionExercise{
+		{ExerciseID: &squat, ExerciseName: "Squat", TargetSets: 3, TargetReps: 5, Position: 1},
+		{ExerciseID: &squat, ExerciseName: "Squat", TargetSets: 2, TargetReps: 10, Position: 2},
+	}}
+	to := &templates.TemplateVersion{Version: 2, Exercises: []templates.VersionExercise{
+		{ExerciseID: &squat, ExerciseName: "Squat", TargetSets: 3, TargetReps: 5, Position: 1},
+		{ExerciseID: &squat, ExerciseName: "Squat", TargetSets: 2, TargetReps: 8, Position: 2},
+	}}
+
+	diff := templates.Diff(from, to)
+	if len(diff.Exercises) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(diff.Exercises))
+	}
+	if diff.Exercises[0].Kind != templates.DiffUnchanged {
+		t.Errorf("expected heavy squats unchanged, got %s", diff.Exercises[0].Kind)
+	}
+	if diff.Exercises[1].Kind != templates.DiffChanged || !diff.Exercises[1].TargetsChanged() {
+		t.Errorf("expected back-off squats changed, got %s", diff.Exercises[1].Kind)
+	}
+}
+
+func TestHandleRestore(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	templateID, _ := templates.Create(app.DB, "Legs")
+	squatID, _ := exercises.Create(app.DB, "Squat")
+	lungeID, _ := exercises.Create(app.DB, "Lunge")
+	squat, _ := templates.AddExercise(app.DB, templateID, squatID, 3, 5)
+	lunge, _ := templates.AddExercise(app.DB, templateID, lungeID, 3, 10)
+	templates.RemoveExercise(app.DB, lunge)
+	templates.Update(app.DB, templateID, "Leg Day")
+	templates.UpdateExercise(app.DB, squat, 5, 5)
+
+	path := "/templates/" + strconv.FormatInt(templateID, 10) + "/versions/"
+	resp := app.HTMXRequest("POST", path+"3/restore", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("HX-Redirect") != "/templates/"+strconv.FormatInt(templateID, 10) {
+		t.Errorf("expected redirect to the template, got %q", resp.Header.Get("HX-Redirect"))
+	}
+
+	tmpl, _ := templates.GetByID(app.DB, templateID)
+	if tmpl.Name != "Legs" {
+		t.Errorf("expected name restored to Legs, got %q", tmpl.Name)
+	}
+	if len(tmpl.Exercises) != 2 || tmpl.Exercises[1].Exercise.Name != "Lunge" {
+		t.Fatalf("expected squat and lunge restored, got %+v", tmpl.Exercises)
+	}
+
+	// Entries that survive are restored in place, keeping workouts linked to them
+	if tmpl.Exercises[0].ID != squat || tmpl.Exercises[0].TargetSets != 3 {
+		t.Errorf("expected the squat entry %d restored to 3 sets, got %+v", squat, tmpl.Exercises[0])
+	}
+
+	// The restore is recorded as a new version rather than rewriting history
+	versions, _ := templates.ListVersions(app.DB, templateID)
+	if len(versions) != 7 || versions[0].Change != "Restored version 3" {
+		t.Errorf("expected a seventh version recording the restore, got %+v", versions[0])
+	}
+
+	// Restoring the version the template already matches changes nothing
+	if err := templates.Restore(app.DB, templateID, 3); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if versions, _ := templates.ListVersions(app.DB, templateID); len(versions) != 7 {
+		t.Errorf("expected no version for a restore without changes, got %d versions", len(versions))
+	}
+
+	resp = app.HTMXRequest("POST", path+"42/restore", "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404 for a missing version, got %d", resp.StatusCode)
+	}
+}
@@ -11,6 +11,7 @@ type WorkoutTemplate struct {
 	Name      string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	Version   int // Current version number
 	Exercises []TemplateExercise
 }
 
@@ -24,3 +25,64 @@ type TemplateExercise struct {
 	TargetReps int
 	Position   int
 }
+
+// TemplateVersion is an immutable snapshot of a template. Every edit records
+// a new version.
+type TemplateVersion struct {
+	ID         int64
+	TemplateID int64
+	Version    int
+	Name       string
+	Change     string // Short description of the edit that produced the version
+	CreatedAt  time.Time
+	Exercises  []VersionExercise
+}
+
+// VersionExercise is an exercise and its targets as they were in a version
+type VersionExercise struct {
+	ExerciseID   *int64 // Nil if the exercise has since been deleted
+	ExerciseName string
+	TargetSets   int
+	TargetReps   int
+	Position     int
+}
+
+// DiffKind describes how an exercise differs between two versions
+type DiffKind string
+
+const (
+	DiffAdded     DiffKind = "added"
+	DiffRemoved   DiffKind = "removed"
+	DiffChanged   DiffKind = "changed"
+	DiffUnchanged DiffKind = "unchanged"
+)
+
+// ExerciseDiff pairs an exercise in one version with the same entry in another
+type ExerciseDiff struct {
+	Kind DiffKind
+	Old  *VersionExercise // Nil when added
+	New  *VersionExercise // Nil when removed
+}
+
+// TargetsChanged reports whether the entry's sets or reps changed
+func (d ExerciseDiff) TargetsChanged() bool {
+	return d.Old != nil && d.New != nil &&
+		(d.Old.TargetSets != d.New.TargetSets || d.Old.TargetReps != d.New.TargetReps)
+}
+
+// Moved reports whether the entry changed position
+func (d ExerciseDiff) Moved() bool {
+	return d.Old != nil && d.New != nil && d.Old.Position != d.New.Position
+}
+
+// VersionDiff compares two versions of a template
+type VersionDiff struct {
+	From      *TemplateVersion // Nil when To is the first version
+	To        *TemplateVersion
+	Exercises []ExerciseDiff
+}
+
+// Renamed reports whether the template name changed between the versions
+func (d VersionDiff) Renamed() bool {
+	return d.From != nil && d.From.Name != d.To.Name
+}
@@ -5,6 +5,12 @@ import (
 	"fmt"
 )
 
+// querier is a *sql.DB or a *sql.Tx, so reads can run inside a transaction
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 // ListAll returns all workout templates
 func ListAll(db *sql.DB) ([]WorkoutTemplate, error) {
 	rows, err := db.Query(`
@@ -33,10 +39,11 @@ func ListAll(db *sql.DB) ([]WorkoutTemplate, error) {
 func GetByID(db *sql.DB, id int64) (*WorkoutTemplate, error) {
 	var t WorkoutTemplate
 	err := db.QueryRow(`
-		SELECT id, name, created_at, updated_at
+		SELECT id, name, created_at, updated_at,
+		       (SELECT COALESCE(MAX(version), 0) FROM template_versions WHERE template_id = workout_templates.id)
 		FROM workout_templates
 		WHERE id = ?
-	`, id).Scan(&t.ID, &t.Name, &t.CreatedAt, &t.UpdatedAt)
+	`, id).Scan(&t.ID, &t.Name, &t.CreatedAt, &t.UpdatedAt, &t.Version)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -56,26 +63,73 @@ func GetByID(db *sql.DB, id int64) (*WorkoutTemplate, error) {
 
 // Create inserts a new template and returns its ID
 func Create(db *sql.DB, name string) (int64, error) {
-	result, err := db.Exec(`
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	id, err := CreateTx(tx, name)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return id, nil
+}
+
+// CreateTx inserts a new template as part of a caller's transaction
+func CreateTx(tx *sql.Tx, name string) (int64, error) {
+	result, err := tx.Exec(`
 		INSERT INTO workout_templates (name) VALUES (?)
 	`, name)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create template: %w", err)
 	}
 
-	return result.LastInsertId()
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := recordVersion(tx, id, "Created"); err != nil {
+		return 0, err
+	}
+
+	return id, nil
 }
 
 // Update modifies a template's name
 func Update(db *sql.DB, id int64, name string) error {
-	_, err := db.Exec(`
-		UPDATE workout_templates
-		SET name = ?, updated_at = CURRENT_TIMESTAMP
-		WHERE id = ?
-	`, name, id)
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE workout_templates SET name = ? WHERE id = ? AND name != ?
+	`, name, id, name)
 	if err != nil {
 		return fmt.Errorf("failed to update template: %w", err)
 	}
+
+	// Saving the same name again isn't a new version
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+
+	if err := recordVersion(tx, id, "Renamed to "+name); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
@@ -120,53 +174,111 @@ func GetTemplateExercises(db *sql.DB, templateID int64) ([]TemplateExercise, err
 
 // AddExercise adds an exercise to a template
 func AddExercise(db *sql.DB, templateID, exerciseID int64, targetSets, targetReps int) (int64, error) {
-	// Get the next position
-	var maxPos sql.NullInt64
-	err := db.QueryRow(`
-		SELECT MAX(position) FROM template_exercises WHERE template_id = ?
-	`, templateID).Scan(&maxPos)
+	tx, err := db.Begin()
 	if err != nil {
-		return 0, fmt.Errorf("failed to get max position: %w", err)
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	nextPos := 1
-	if maxPos.Valid {
-		nextPos = int(maxPos.Int64) + 1
+	id, err := AddExerciseTx(tx, templateID, exerciseID, targetSets, targetReps)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	result, err := db.Exec(`
+	return id, nil
+}
+
+// AddExerciseTx adds an exercise to a template as part of a caller's transaction
+func AddExerciseTx(tx *sql.Tx, templateID, exerciseID int64, targetSets, targetReps int) (int64, error) {
+	result, err := tx.Exec(`
 		INSERT INTO template_exercises (template_id, exercise_id, target_sets, target_reps, position)
-		VALUES (?, ?, ?, ?, ?)
-	`, templateID, exerciseID, targetSets, targetReps, nextPos)
+		SELECT ?, ?, ?, ?, COALESCE(MAX(position), 0) + 1
+		FROM template_exercises
+		WHERE template_id = ?
+	`, templateID, exerciseID, targetSets, targetReps, templateID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to add exercise to template: %w", err)
 	}
 
-	// Update template timestamp
-	db.Exec(`UPDATE workout_templates SET updated_at = CURRENT_TIMESTAMP WHERE id = ?`, templateID)
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := recordVersion(tx, templateID, "Added "+exerciseName(tx, exerciseID)); err != nil {
+		return 0, err
+	}
 
-	return result.LastInsertId()
+	return id, nil
 }
 
 // UpdateExercise updates a template exercise's targets
 func UpdateExercise(db *sql.DB, id int64, targetSets, targetReps int) error {
-	_, err := db.Exec(`
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	te, err := getExerciseRef(tx, id)
+	if err != nil || te == nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
 		UPDATE template_exercises
 		SET target_sets = ?, target_reps = ?
-		WHERE id = ?
-	`, targetSets, targetReps, id)
+		WHERE id = ? AND (target_sets != ? OR target_reps != ?)
+	`, targetSets, targetReps, id, targetSets, targetReps)
 	if err != nil {
 		return fmt.Errorf("failed to update template exercise: %w", err)
 	}
+
+	// Saving the same targets again isn't a new version
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+
+	if err := recordVersion(tx, te.templateID, "Changed targets of "+te.name); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
 // RemoveExercise removes an exercise from a template
 func RemoveExercise(db *sql.DB, id int64) error {
-	_, err := db.Exec(`DELETE FROM template_exercises WHERE id = ?`, id)
+	tx, err := db.Begin()
 	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	te, err := getExerciseRef(tx, id)
+	if err != nil || te == nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM template_exercises WHERE id = ?`, id); err != nil {
 		return fmt.Errorf("failed to remove exercise from template: %w", err)
 	}
+
+	if err := recordVersion(tx, te.templateID, "Removed "+te.name); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
@@ -178,15 +290,30 @@ func ReorderExercises(db *sql.DB, templateID int64, exerciseIDs []int64) error {
 	}
 	defer tx.Rollback()
 
+	var moved int64
 	for i, id := range exerciseIDs {
-		_, err := tx.Exec(`
+		result, err := tx.Exec(`
 			UPDATE template_exercises
 			SET position = ?
-			WHERE id = ? AND template_id = ?
-		`, i+1, id, templateID)
+			WHERE id = ? AND template_id = ? AND position != ?
+		`, i+1, id, templateID, i+1)
 		if err != nil {
 			return fmt.Errorf("failed to update position: %w", err)
 		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		moved += n
+	}
+
+	// Dropping an exercise back into its place isn't a new version
+	if moved == 0 {
+		return nil
+	}
+
+	if err := recordVersion(tx, templateID, "Reordered exercises"); err != nil {
+		return err
 	}
 
 	if err := tx.Commit(); err != nil {
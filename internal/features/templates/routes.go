@@ -9,6 +9,9 @@ func RegisterRoutes(app *fiber.App) {
 	app.Get("/templates/:id", HandleShow)
 	app.Put("/templates/:id", HandleUpdate)
 	app.Delete("/templates/:id", HandleDelete)
+	app.Get("/templates/:id/versions", HandleVersions)
+	app.Get("/templates/:id/versions/:version", HandleVersion)
+	app.Post("/templates/:id/versions/:version/restore", HandleRestore)
 	app.Post("/templates/:id/exercises", HandleAddExercise)
 	app.Delete("/templates/exercises/:id", HandleRemoveExercise)
 }
@@ -0,0 +1,364 @@
+package templates
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrVersionNotFound is returned when restoring a version the template doesn't have
+var ErrVersionNotFound = errors.New("template version not found")
+
+// recordVersion snapshots a template's current name and exercises as its next
+// version. It is called in the same transaction as every edit.
+func recordVersion(tx *sql.Tx, templateID int64, change string) error {
+	result, err := tx.Exec(`
+		INSERT INTO template_versions (template_id, version, name, change)
+		SELECT wt.id,
+		       (SELECT COALESCE(MAX(version), 0) + 1 FROM template_versions WHERE template_id = wt.id),
+		       wt.name, ?
+		FROM workout_templates wt
+		WHERE wt.id = ?
+	`, change, templateID)
+	if err != nil {
+		return fmt.Errorf("failed to record template version: %w", err)
+	}
+
+	versionID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO template_version_exercises (version_id, exercise_id, exercise_name, target_sets, target_reps, position)
+		SELECT ?, te.exercise_id, e.name, te.target_sets, te.target_reps, te.position
+		FROM template_exercises te
+		JOIN exercises e ON te.exercise_id = e.id
+		WHERE te.template_id = ?
+	`, versionID, templateID)
+	if err != nil {
+		return fmt.Errorf("failed to record template version exercises: %w", err)
+	}
+
+	_, err = tx.Exec(`UPDATE workout_templates SET updated_at = CURRENT_TIMESTAMP WHERE id = ?`, templateID)
+	if err != nil {
+		return fmt.Errorf("failed to update template timestamp: %w", err)
+	}
+
+	return nil
+}
+
+// exerciseRef is the template and exercise name behind a template exercise
+type exerciseRef struct {
+	templateID int64
+	name       string
+}
+
+func getExerciseRef(tx *sql.Tx, id int64) (*exerciseRef, error) {
+	var ref exerciseRef
+	err := tx.QueryRow(`
+		SELECT te.template_id, e.name
+		FROM template_exercises te
+		JOIN exercises e ON te.exercise_id = e.id
+		WHERE te.id = ?
+	`, id).Scan(&ref.templateID, &ref.name)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template exercise: %w", err)
+	}
+	return &ref, nil
+}
+
+// exerciseName returns an exercise's name for describing a change, falling
+// back to a generic description if it can't be read
+func exerciseName(tx *sql.Tx, exerciseID int64) string {
+	var name string
+	if err := tx.QueryRow(`SELECT name FROM exercises WHERE id = ?`, exerciseID).Scan(&name); err != nil {
+		return "exercise"
+	}
+	return name
+}
+
+// ListVersions returns a template's versions, newest first, without their exercises
+func ListVersions(db *sql.DB, templateID int64) ([]TemplateVersion, error) {
+	rows, err := db.Query(`
+		SELECT id, template_id, version, name, change, created_at
+		FROM template_versions
+		WHERE template_id = ?
+		ORDER BY version DESC
+	`, templateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list template versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []TemplateVersion
+	for rows.Next() {
+		var v TemplateVersion
+		if err := rows.Scan(&v.ID, &v.TemplateID, &v.Version, &v.Name, &v.Change, &v.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan template version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, rows.Err()
+}
+
+// GetVersion returns a version of a template with its exercises
+func GetVersion(db querier, templateID int64, version int) (*TemplateVersion, error) {
+	var v TemplateVersion
+	err := db.QueryRow(`
+		SELECT id, template_id, version, name, change, created_at
+		FROM template_versions
+		WHERE template_id = ? AND version = ?
+	`, templateID, version).Scan(&v.ID, &v.TemplateID, &v.Version, &v.Name, &v.Change, &v.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template version: %w", err)
+	}
+
+	rows, err := db.Query(`
+		SELECT exercise_id, exercise_name, target_sets, target_reps, position
+		FROM template_version_exercises
+		WHERE version_id = ?
+		ORDER BY position ASC
+	`, v.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template version exercises: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ve VersionExercise
+		var exerciseID sql.NullInt64
+		if err := rows.Scan(&exerciseID, &ve.ExerciseName, &ve.TargetSets, &ve.TargetReps, &ve.Position); err != nil {
+			return nil, fmt.Errorf("failed to scan template version exercise: %w", err)
+		}
+		if exerciseID.Valid {
+			ve.ExerciseID = &exerciseID.Int64
+		}
+		v.Exercises = append(v.Exercises, ve)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+// Restore rolls a template back to an earlier version by recording a new
+// version with the old name and exercises. Exercises deleted since are left out.
+// Entries are restored in place, so workouts started from the template keep
+// their link to the entries that survive: each entry of the old version takes
+// the first current entry for the same exercise, and entries left over are
+// removed, unlinking the workouts that came from them.
+func Restore(db *sql.DB, templateID int64, version int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	old, err := GetVersion(tx, templateID, version)
+	if err != nil {
+		return err
+	}
+	if old == nil {
+		return ErrVersionNotFound
+	}
+
+	// Restoring the version the template already matches isn't a new version
+	same, err := matchesVersion(tx, templateID, old)
+	if err != nil || same {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE workout_templates SET name = ? WHERE id = ?`, old.Name, templateID); err != nil {
+		return fmt.Errorf("failed to restore template name: %w", err)
+	}
+
+	rows, err := tx.Query(`
+		SELECT id, exercise_id FROM template_exercises WHERE template_id = ? ORDER BY position ASC
+	`, templateID)
+	if err != nil {
+		return fmt.Errorf("failed to get template exercises: %w", err)
+	}
+	current := make(map[int64][]int64) // Entry IDs by exercise, in order
+	for rows.Next() {
+		var id, exerciseID int64
+		if err := rows.Scan(&id, &exerciseID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan template exercise: %w", err)
+		}
+		current[exerciseID] = append(current[exerciseID], id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Move current entries out of the way first since positions are unique per template
+	_, err = tx.Exec(`UPDATE template_exercises SET position = -position WHERE template_id = ?`, templateID)
+	if err != nil {
+		return fmt.Errorf("failed to reorder template exercises: %w", err)
+	}
+
+	position := 0
+	for _, ve := range old.Exercises {
+		if ve.ExerciseID == nil {
+			continue
+		}
+		position++
+
+		if ids := current[*ve.ExerciseID]; len(ids) > 0 {
+			current[*ve.ExerciseID] = ids[1:]
+			_, err := tx.Exec(`
+				UPDATE template_exercises SET target_sets = ?, target_reps = ?, position = ? WHERE id = ?
+			`, ve.TargetSets, ve.TargetReps, position, ids[0])
+			if err != nil {
+				return fmt.Errorf("failed to restore template exercise: %w", err)
+			}
+			continue
+		}
+
+		_, err := tx.Exec(`
+			INSERT INTO template_exercises (template_id, exercise_id, target_sets, target_reps, position)
+			VALUES (?, ?, ?, ?, ?)
+		`, templateID, *ve.ExerciseID, ve.TargetSets, ve.TargetReps, position)
+		if err != nil {
+			return fmt.Errorf("failed to restore template exercise: %w", err)
+		}
+	}
+
+	for _, ids := range current {
+		for _, id := range ids {
+			if _, err := tx.Exec(`DELETE FROM template_exercises WHERE id = ?`, id); err != nil {
+				return fmt.Errorf("failed to remove template exercise: %w", err)
+			}
+		}
+	}
+
+	if err := recordVersion(tx, templateID, "Restored version "+strconv.Itoa(version)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// matchesVersion reports whether a template's name and exercises are those of
+// a version, leaving out the version's exercises that have since been deleted
+func matchesVersion(tx *sql.Tx, templateID int64, v *TemplateVersion) (bool, error) {
+	var name string
+	err := tx.QueryRow(`SELECT name FROM workout_templates WHERE id = ?`, templateID).Scan(&name)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get template: %w", err)
+	}
+	if name != v.Name {
+		return false, nil
+	}
+
+	var want []VersionExercise
+	for _, ve := range v.Exercises {
+		if ve.ExerciseID != nil {
+			want = append(want, ve)
+		}
+	}
+
+	rows, err := tx.Query(`
+		SELECT exercise_id, target_sets, target_reps
+		FROM template_exercises
+		WHERE template_id = ?
+		ORDER BY position ASC
+	`, templateID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get template exercises: %w", err)
+	}
+	defer rows.Close()
+
+	i := 0
+	for rows.Next() {
+		var exerciseID int64
+		var sets, reps int
+		if err := rows.Scan(&exerciseID, &sets, &reps); err != nil {
+			return false, fmt.Errorf("failed to scan template exercise: %w", err)
+		}
+		if i >= len(want) || *want[i].ExerciseID != exerciseID || want[i].TargetSets != sets || want[i].TargetReps != reps {
+			return false, nil
+		}
+		i++
+	}
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	return i == len(want), nil
+}
+
+// Diff compares two versions of a template. Entries are matched by exercise,
+// in order, so a template using an exercise twice pairs the first use with
+// the first use. from may be nil to show every exercise in to as added.
+// Removed entries are listed after the rest.
+func Diff(from, to *TemplateVersion) VersionDiff {
+	diff := VersionDiff{From: from, To: to}
+
+	unmatched := make(map[string][]*VersionExercise)
+	if from != nil {
+		for i := range from.Exercises {
+			key := diffKey(from.Exercises[i])
+			unmatched[key] = append(unmatched[key], &from.Exercises[i])
+		}
+	}
+
+	matched := make(map[*VersionExercise]bool)
+	for i := range to.Exercises {
+		ve := &to.Exercises[i]
+		key := diffKey(*ve)
+		if len(unmatched[key]) == 0 {
+			diff.Exercises = append(diff.Exercises, ExerciseDiff{Kind: DiffAdded, New: ve})
+			continue
+		}
+
+		old := unmatched[key][0]
+		unmatched[key] = unmatched[key][1:]
+		matched[old] = true
+
+		d := ExerciseDiff{Kind: DiffUnchanged, Old: old, New: ve}
+		if d.TargetsChanged() || d.Moved() {
+			d.Kind = DiffChanged
+		}
+		diff.Exercises = append(diff.Exercises, d)
+	}
+
+	if from != nil {
+		for i := range from.Exercises {
+			if !matched[&from.Exercises[i]] {
+				diff.Exercises = append(diff.Exercises, ExerciseDiff{Kind: DiffRemoved, Old: &from.Exercises[i]})
+			}
+		}
+	}
+
+	return diff
+}
+
+// diffKey identifies the exercise behind a version entry, using the name when
+// the exercise has been deleted
+func diffKey(ve VersionExercise) string {
+	if ve.ExerciseID != nil {
+		return "id:" + strconv.FormatInt(*ve.ExerciseID, 10)
+	}
+	return "name:" + ve.ExerciseName
+}
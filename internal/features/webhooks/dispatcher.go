@@ -0,0 +1,165 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"phobos/internal/shared/backoff"
+	"phobos/internal/shared/safehttp"
+)
+
+const (
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the body,
+	// keyed with the webhook's secret
+	SignatureHeader = "X-Phobos-Signature"
+	// EventHeader carries the event name, also found in the body
+	EventHeader = "X-Phobos-Event"
+	// DeliveryHeader carries the delivery ID, which stays the same across retries
+	DeliveryHeader = "X-Phobos-Delivery"
+
+	// MaxAttempts is how many times a delivery is tried before it is marked failed
+	MaxAttempts = 8
+
+	batchSize     = 20
+	firstRetry    = 30 * time.Second
+	maxRetry      = 6 * time.Hour
+	maxErrorBytes = 200
+)
+
+// Sign returns the signature header value for a body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// RetryDelay returns how long to wait before the next try of a delivery that
+// has failed the given number of times, or zero once it is out of attempts.
+// The delay doubles each time from 30 seconds, up to 6 hours.
+func RetryDelay(attempts int) time.Duration {
+	if attempts >= MaxAttempts {
+		return 0
+	}
+	return backoff.Delay(attempts, firstRetry, maxRetry)
+}
+
+const deliveryTimeout = 10 * time.Second
+
+// deliveryClient sends deliveries. Webhook URLs are entered by users and
+// failed responses are logged, so it only reaches public addresses unless
+// the server allows more with SetAllowedNetworks.
+var deliveryClient = safehttp.NewClient(deliveryTimeout)
+
+// SetAllowedNetworks lets deliveries reach the given private ranges as well
+// as public addresses, for receivers on the operator's own network. It must
+// be called before any Dispatcher is created.
+func SetAllowedNetworks(networks []netip.Prefix) {
+	deliveryClient = safehttp.NewClient(deliveryTimeout, networks...)
+}
+
+// Dispatcher sends queued deliveries. Only one should run per database, since
+// deliveries aren't claimed before they are sent; the server runs it as a
+// background job, which the job lock keeps to one process.
+type Dispatcher struct {
+	db     *sql.DB
+	client *http.Client
+}
+
+// NewDispatcher returns a dispatcher for the deliveries queued in db
+func NewDispatcher(db *sql.DB) *Dispatcher {
+	return &Dispatcher{db: db, client: deliveryClient}
+}
+
+// DeliverDue sends every delivery whose next attempt is due and returns how
+// many it tried
+func (d *Dispatcher) DeliverDue(ctx context.Context) (int, error) {
+	tried := 0
+	for {
+		due, err := dueDeliveries(d.db, batchSize)
+		if err != nil {
+			return tried, err
+		}
+		if len(due) == 0 {
+			return tried, nil
+		}
+
+		secrets := make(map[int64]string)
+		for _, delivery := range due {
+			if ctx.Err() != nil {
+				return tried, ctx.Err()
+			}
+
+			secret, ok := secrets[delivery.WebhookID]
+			if !ok {
+				w, err := GetByID(d.db, delivery.WebhookID)
+				if err != nil {
+					return tried, err
+				}
+				if w == nil {
+					// Deleted since the queue was read; its deliveries went with it
+					continue
+				}
+				secret = w.Secret
+				secrets[w.ID] = secret
+			}
+
+			status, sendErr := d.send(ctx, delivery, secret)
+			tried++
+
+			errMsg, retryIn := "", time.Duration(0)
+			if sendErr != nil {
+				errMsg = truncate(sendErr.Error())
+				retryIn = RetryDelay(delivery.Attempts + 1)
+			}
+			if err := recordAttempt(d.db, delivery.ID, status, errMsg, retryIn); err != nil {
+				return tried, err
+			}
+		}
+	}
+}
+
+// send posts a delivery's payload and returns the response status. Any
+// response other than 2xx is an error, including redirects, which aren't
+// followed.
+func (d *Dispatcher) send(ctx context.Context, delivery Delivery, secret string) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Phobos-Webhooks")
+	req.Header.Set(SignatureHeader, Sign(secret, body))
+	req.Header.Set(EventHeader, string(delivery.Event))
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBytes))
+		return resp.StatusCode, fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(snippet))
+	}
+	return resp.StatusCode, nil
+}
+
+// truncate shortens an error message for the delivery log
+func truncate(s string) string {
+	if len(s) <= maxErrorBytes {
+		return s
+	}
+	return s[:maxErrorBytes] + "…"
+}
@@ -0,0 +1,11 @@
+package webhooks
+
+import (
+	"net/http"
+	"time"
+)
+
+// Tests deliver to httptest servers, which listen on loopback
+func init() {
+	deliveryClient = &http.Client{Timeout: 10 * time.Second}
+}
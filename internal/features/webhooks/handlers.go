@@ -0,0 +1,130 @@
+package webhooks
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"phobos/internal/shared/htmx"
+	"phobos/internal/shared/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// deliveryLogLimit is how many deliveries the delivery log shows
+const deliveryLogLimit = 100
+
+// HandleIndex lists webhooks with a form to add another
+func HandleIndex(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	hooks, err := ListAll(db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load webhooks")
+	}
+
+	return htmx.Render(c, WebhooksPage(hooks))
+}
+
+// HandleCreate adds a webhook. A secret is generated if none is given.
+func HandleCreate(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	target := strings.TrimSpace(c.FormValue("url"))
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return c.Status(fiber.StatusBadRequest).SendString("URL must start with http:// or https://")
+	}
+
+	var events []Event
+	for _, v := range c.Request().PostArgs().PeekMulti("event") {
+		e, ok := ParseEvent(string(v))
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid event")
+		}
+		events = append(events, e)
+	}
+	if len(events) == 0 {
+		return c.Status(fiber.StatusBadRequest).SendString("Choose at least one event")
+	}
+
+	secret := strings.TrimSpace(c.FormValue("secret"))
+	if secret == "" {
+		secret, err = NewSecret()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString("Failed to add webhook")
+		}
+	}
+
+	if _, err := Create(db, target, secret, events); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to add webhook")
+	}
+
+	return htmx.Refresh(c)
+}
+
+// HandleDelete removes a webhook and its delivery log
+func HandleDelete(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	if err := Delete(db, id); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to delete webhook")
+	}
+
+	return htmx.Refresh(c)
+}
+
+// HandleDeliveries shows recent deliveries, optionally to a single webhook
+func HandleDeliveries(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	webhookID := int64(0)
+	if raw := c.Query("webhook_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid webhook ID")
+		}
+		webhookID = id
+	}
+
+	hooks, err := ListAll(db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load webhooks")
+	}
+
+	deliveries, err := ListDeliveries(db, webhookID, deliveryLogLimit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load deliveries")
+	}
+
+	return htmx.Render(c, DeliveriesPage(hooks, webhookID, deliveries))
+}
+
+// HandleRedeliver queues a failed delivery to be sent again
+func HandleRedeliver(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	if err := Redeliver(db, id); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to retry delivery")
+	}
+
+	delivery, err := GetDelivery(db, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load delivery")
+	}
+	if delivery == nil {
+		return c.Status(fiber.StatusNotFound).SendString("Delivery not found")
+	}
+
+	return htmx.Render(c, DeliveryRow(*delivery))
+}
@@ -0,0 +1,262 @@
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"phobos/internal/features/webhooks"
+	"phobos/internal/testutil"
+)
+
+func TestHandleCreate(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	resp := app.HTMXRequest("POST", "/webhooks",
+		"url=https://example.com/hook&event=workout.finished&event=pr.achieved")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	hooks, _ := webhooks.ListAll(app.DB)
+	if len(hooks) != 1 {
+		t.Fatalf("expected 1 webhook, got %d", len(hooks))
+	}
+	w := hooks[0]
+	if w.URL != "https://example.com/hook" {
+		t.Errorf("expected the given URL, got %s", w.URL)
+	}
+	if !w.Subscribes(webhooks.EventPRAchieved) || w.Subscribes(webhooks.EventSetLogged) {
+		t.Errorf("expected only the chosen events, got %v", w.Events)
+	}
+	if len(w.Secret) != 64 {
+		t.Errorf("expected a generated secret, got %q", w.Secret)
+	}
+}
+
+func TestHandleCreate_Invalid(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"not http", "url=ftp://example.com&event=workout.finished"},
+		{"no events", "url=https://example.com/hook"},
+		{"unknown event", "url=https://example.com/hook&event=workout.deleted"},
+	}
+	for _, tt := range tests {
+		resp := app.HTMXRequest("POST", "/webhooks", tt.body)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", tt.name, resp.StatusCode)
+		}
+	}
+}
+
+func TestDispatcher_SignsAndDelivers(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	var mu sync.Mutex
+	var got *http.Request
+	var gotBody []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+	}))
+	defer receiver.Close()
+
+	webhooks.Create(app.DB, receiver.URL, "s3cret", []webhooks.Event{webhooks.EventWorkoutFinished})
+	webhooks.Enqueue(app.DB, webhooks.EventWorkoutFinished, map[string]any{"id": 7})
+	webhooks.Enqueue(app.DB, webhooks.EventSetLogged, map[string]any{"id": 8})
+
+	tried, err := webhooks.NewDispatcher(app.DB).DeliverDue(context.Background())
+	if err != nil {
+		t.Fatalf("DeliverDue: %v", err)
+	}
+	if tried != 1 {
+		t.Fatalf("expected only the subscribed event to be sent, got %d", tried)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got.Header.Get(webhooks.SignatureHeader) != webhooks.Sign("s3cret", gotBody) {
+		t.Error("expected the body to be signed with the webhook's secret")
+	}
+	if got.Header.Get(webhooks.EventHeader) != "workout.finished" {
+		t.Errorf("expected the event header, got %q", got.Header.Get(webhooks.EventHeader))
+	}
+
+	var payload struct {
+		Event string         `json:"event"`
+		Data  map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(gotBody, &payload); err != nil {
+		t.Fatalf("expected a JSON body: %v", err)
+	}
+	if payload.Event != "workout.finished" || payload.Data["id"] != float64(7) {
+		t.Errorf("unexpected payload %s", gotBody)
+	}
+
+	deliveries, _ := webhooks.ListDeliveries(app.DB, 0, 10)
+	if len(deliveries) != 1 || deliveries[0].Status != webhooks.DeliveryDelivered {
+		t.Fatalf("expected the delivery to be marked delivered, got %+v", deliveries)
+	}
+	if deliveries[0].ResponseStatus == nil || *deliveries[0].ResponseStatus != http.StatusOK {
+		t.Error("expected the response status to be logged")
+	}
+}
+
+func TestDispatcher_RetriesThenFails(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	webhooks.Create(app.DB, receiver.URL, "s3cret", []webhooks.Event{webhooks.EventSetLogged})
+	webhooks.Enqueue(app.DB, webhooks.EventSetLogged, map[string]any{"id": 1})
+	dispatcher := webhooks.NewDispatcher(app.DB)
+
+	dispatcher.DeliverDue(context.Background())
+
+	deliveries, _ := webhooks.ListDeliveries(app.DB, 0, 10)
+	d := deliveries[0]
+	if d.Status != webhooks.DeliveryPending || d.Attempts != 1 {
+		t.Fatalf("expected a pending delivery after 1 attempt, got %s after %d", d.Status, d.Attempts)
+	}
+	if !strings.Contains(d.LastError, "down for maintenance") {
+		t.Errorf("expected the response to be logged, got %q", d.LastError)
+	}
+
+	// Not due again until the backoff has passed
+	if tried, _ := dispatcher.DeliverDue(context.Background()); tried != 0 {
+		t.Errorf("expected no retry before the backoff, got %d", tried)
+	}
+
+	// The last attempt marks it failed
+	app.DB.Exec(`UPDATE webhook_deliveries SET attempts = ?, next_attempt_at = datetime('now', '-1 minute')`, webhooks.MaxAttempts-1)
+	dispatcher.DeliverDue(context.Background())
+
+	deliveries, _ = webhooks.ListDeliveries(app.DB, 0, 10)
+	if deliveries[0].Status != webhooks.DeliveryFailed {
+		t.Fatalf("expected the delivery to fail, got %s", deliveries[0].Status)
+	}
+
+	resp := app.HTMXRequest("POST", "/webhooks/deliveries/"+strconv.FormatInt(d.ID, 10)+"/retry", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+	retried, _ := webhooks.GetDelivery(app.DB, d.ID)
+	if retried.Status != webhooks.DeliveryPending || retried.Attempts != 0 {
+		t.Errorf("expected the delivery to be queued again, got %s after %d", retried.Status, retried.Attempts)
+	}
+}
+
+func TestHandleDelete_RemovesDeliveries(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	id, _ := webhooks.Create(app.DB, "https://example.com/hook", "s3cret", []webhooks.Event{webhooks.EventSetLogged})
+	webhooks.Enqueue(app.DB, webhooks.EventSetLogged, map[string]any{"id": 1})
+
+	resp := app.HTMXRequest("DELETE", "/webhooks/"+strconv.FormatInt(id, 10), "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	if w, _ := webhooks.GetByID(app.DB, id); w != nil {
+		t.Error("expected the webhook to be deleted")
+	}
+	var count int
+	app.DB.QueryRow(`SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id = ?`, id).Scan(&count)
+	if count != 0 {
+		t.Errorf("expected the deliveries to be deleted with the webhook, got %d", count)
+	}
+}
+
+// Not parallel: the enabled flag is shared by the whole package
+func TestSetEnabled_DropsEvents(t *testing.T) {
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	webhooks.Create(app.DB, "https://example.com/hook", "s3cret", []webhooks.Event{webhooks.EventSetLogged})
+
+	webhooks.SetEnabled(false)
+	defer webhooks.SetEnabled(true)
+
+	if ok, _ := webhooks.Subscribed(app.DB, webhooks.EventSetLogged); ok {
+		t.Error("expected no subscribers while webhooks are disabled")
+	}
+	if err := webhooks.Enqueue(app.DB, webhooks.EventSetLogged, map[string]any{"id": 1}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	var count int
+	app.DB.QueryRow(`SELECT COUNT(*) FROM webhook_deliveries`).Scan(&count)
+	if count != 0 {
+		t.Errorf("expected no deliveries while webhooks are disabled, got %d", count)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{webhooks.MaxAttempts, 0},
+	}
+	for _, tt := range tests {
+		if got := webhooks.RetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("RetryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestHandleDeliveries(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	first, _ := webhooks.Create(app.DB, "https://example.com/a", "s", []webhooks.Event{webhooks.EventWorkoutCreated})
+	webhooks.Create(app.DB, "https://example.com/b", "s", []webhooks.Event{webhooks.EventPRAchieved})
+	webhooks.Enqueue(app.DB, webhooks.EventWorkoutCreated, map[string]any{"name": "Leg Day"})
+	webhooks.Enqueue(app.DB, webhooks.EventPRAchieved, map[string]any{"exercise": "Squat"})
+
+	resp := app.Request("GET", "/webhooks/deliveries?webhook_id="+strconv.FormatInt(first, 10), "")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "workout.created") || !strings.Contains(body, "Leg Day") {
+		t.Error("expected the webhook's delivery with its payload")
+	}
+	if strings.Contains(body, "Squat") {
+		t.Error("expected deliveries to other webhooks to be left out")
+	}
+}
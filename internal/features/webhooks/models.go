@@ -0,0 +1,71 @@
+package webhooks
+
+import (
+	"slices"
+	"time"
+)
+
+// Event names something that happened to a workout
+type Event string
+
+const (
+	EventWorkoutCreated  Event = "workout.created"
+	EventWorkoutFinished Event = "workout.finished"
+	EventSetLogged       Event = "set.logged"
+	EventPRAchieved      Event = "pr.achieved"
+)
+
+// Events lists every event a webhook can subscribe to, in display order
+var Events = []Event{EventWorkoutCreated, EventWorkoutFinished, EventSetLogged, EventPRAchieved}
+
+// ParseEvent returns the event with the given name, or false if there is none
+func ParseEvent(s string) (Event, bool) {
+	e := Event(s)
+	return e, slices.Contains(Events, e)
+}
+
+// Webhook is a URL notified of workout events
+type Webhook struct {
+	ID        int64
+	URL       string
+	Secret    string
+	Events    []Event
+	CreatedAt time.Time
+}
+
+// Subscribes returns true if the webhook is notified of the event
+func (w Webhook) Subscribes(e Event) bool {
+	return slices.Contains(w.Events, e)
+}
+
+// DeliveryStatus is where a delivery is in the queue
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Delivery is one payload queued for, or sent to, a webhook
+type Delivery struct {
+	ID             int64
+	WebhookID      int64
+	WebhookURL     string
+	Event          Event
+	Payload        string
+	Status         DeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	ResponseStatus *int // Status code of the last response; nil if none was received
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+// envelope is the JSON body sent to webhooks
+type envelope struct {
+	Event     Event     `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
@@ -0,0 +1,321 @@
+package webhooks
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"phobos/internal/shared/dates"
+)
+
+// disabled is set when the webhooks feature is turned off, so other slices'
+// events are dropped instead of queued for a dispatcher that never runs
+var disabled atomic.Bool
+
+// SetEnabled turns queuing events on or off. Webhooks are enabled unless the
+// server turns them off at startup.
+func SetEnabled(enabled bool) {
+	disabled.Store(!enabled)
+}
+
+// NewSecret returns a random secret for signing a webhook's payloads
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Create adds a webhook for the given events and returns its ID
+func Create(db *sql.DB, url, secret string, events []Event) (int64, error) {
+	names := make([]string, len(events))
+	for i, e := range events {
+		names[i] = string(e)
+	}
+
+	result, err := db.Exec(`
+		INSERT INTO webhooks (url, secret, events) VALUES (?, ?, ?)
+	`, url, secret, strings.Join(names, ","))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	return result.LastInsertId()
+}
+
+// ListAll returns every webhook, oldest first
+func ListAll(db *sql.DB) ([]Webhook, error) {
+	rows, err := db.Query(`
+		SELECT id, url, secret, events, created_at
+		FROM webhooks
+		ORDER BY id ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var hooks []Webhook
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, w)
+	}
+
+	return hooks, rows.Err()
+}
+
+// GetByID returns a webhook, or nil if it doesn't exist
+func GetByID(db *sql.DB, id int64) (*Webhook, error) {
+	w, err := scanWebhook(db.QueryRow(`
+		SELECT id, url, secret, events, created_at
+		FROM webhooks
+		WHERE id = ?
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func scanWebhook(row interface{ Scan(...any) error }) (Webhook, error) {
+	var w Webhook
+	var events string
+	if err := row.Scan(&w.ID, &w.URL, &w.Secret, &events, &w.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return w, err
+		}
+		return w, fmt.Errorf("failed to scan webhook: %w", err)
+	}
+	for _, name := range strings.Split(events, ",") {
+		if e, ok := ParseEvent(name); ok {
+			w.Events = append(w.Events, e)
+		}
+	}
+	return w, nil
+}
+
+// Delete removes a webhook along with its deliveries
+func Delete(db *sql.DB, id int64) error {
+	_, err := db.Exec(`DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// Subscribed reports whether any webhook is notified of an event, so callers
+// can skip building payloads nobody will receive
+func Subscribed(db *sql.DB, event Event) (bool, error) {
+	if disabled.Load() {
+		return false, nil
+	}
+	hooks, err := ListAll(db)
+	if err != nil {
+		return false, err
+	}
+	for _, w := range hooks {
+		if w.Subscribes(event) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Enqueue queues a delivery of an event to every webhook subscribed to it.
+// data becomes the "data" field of the JSON payload. Nothing is sent here;
+// the Dispatcher picks the deliveries up. Nothing is queued while webhooks
+// are disabled.
+func Enqueue(db *sql.DB, event Event, data any) error {
+	if disabled.Load() {
+		return nil
+	}
+	hooks, err := ListAll(db)
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	for _, w := range hooks {
+		if !w.Subscribes(event) {
+			continue
+		}
+		if payload == nil {
+			payload, err = json.Marshal(envelope{Event: event, CreatedAt: time.Now().UTC(), Data: data})
+			if err != nil {
+				return fmt.Errorf("failed to encode webhook payload: %w", err)
+			}
+		}
+
+		_, err := db.Exec(`
+			INSERT INTO webhook_deliveries (webhook_id, event, payload) VALUES (?, ?, ?)
+		`, w.ID, event, string(payload))
+		if err != nil {
+			return fmt.Errorf("failed to queue webhook delivery: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ListDeliveries returns the most recent deliveries, newest first. A
+// webhookID of 0 lists deliveries to every webhook.
+func ListDeliveries(db *sql.DB, webhookID int64, limit int) ([]Delivery, error) {
+	rows, err := db.Query(`
+		SELECT d.id, d.webhook_id, w.url, d.event, d.payload, d.status, d.attempts,
+		       d.next_attempt_at, d.response_status, COALESCE(d.last_error, ''),
+		       d.created_at, d.delivered_at
+		FROM webhook_deliveries d
+		JOIN webhooks w ON d.webhook_id = w.id
+		WHERE ? = 0 OR d.webhook_id = ?
+		ORDER BY d.id DESC
+		LIMIT ?
+	`, webhookID, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// GetDelivery returns a delivery, or nil if it doesn't exist
+func GetDelivery(db *sql.DB, id int64) (*Delivery, error) {
+	d, err := scanDelivery(db.QueryRow(`
+		SELECT d.id, d.webhook_id, w.url, d.event, d.payload, d.status, d.attempts,
+		       d.next_attempt_at, d.response_status, COALESCE(d.last_error, ''),
+		       d.created_at, d.delivered_at
+		FROM webhook_deliveries d
+		JOIN webhooks w ON d.webhook_id = w.id
+		WHERE d.id = ?
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func scanDelivery(row interface{ Scan(...any) error }) (Delivery, error) {
+	var d Delivery
+	var responseStatus sql.NullInt64
+	var deliveredAt sql.NullTime
+	err := row.Scan(&d.ID, &d.WebhookID, &d.WebhookURL, &d.Event, &d.Payload, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &responseStatus, &d.LastError, &d.CreatedAt, &deliveredAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return d, err
+		}
+		return d, fmt.Errorf("failed to scan webhook delivery: %w", err)
+	}
+	if responseStatus.Valid {
+		status := int(responseStatus.Int64)
+		d.ResponseStatus = &status
+	}
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
+	}
+	return d, nil
+}
+
+// Redeliver puts a failed delivery back in the queue with a fresh set of attempts
+func Redeliver(db *sql.DB, id int64) error {
+	_, err := db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = 0, next_attempt_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = ?
+	`, DeliveryPending, id, DeliveryFailed)
+	if err != nil {
+		return fmt.Errorf("failed to requeue webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// PruneDeliveries deletes delivered and failed deliveries queued before the
+// given time and returns how many were deleted
+func PruneDeliveries(db *sql.DB, before time.Time) (int64, error) {
+	result, err := db.Exec(`
+		DELETE FROM webhook_deliveries WHERE status != ? AND created_at < ?
+	`, DeliveryPending, before.UTC().Format(dates.TimestampLayout))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune webhook deliveries: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// dueDeliveries returns up to limit pending deliveries whose next attempt is
+// due, oldest first
+func dueDeliveries(db *sql.DB, limit int) ([]Delivery, error) {
+	rows, err := db.Query(`
+		SELECT d.id, d.webhook_id, w.url, d.event, d.payload, d.status, d.attempts,
+		       d.next_attempt_at, d.response_status, COALESCE(d.last_error, ''),
+		       d.created_at, d.delivered_at
+		FROM webhook_deliveries d
+		JOIN webhooks w ON d.webhook_id = w.id
+		WHERE d.status = ? AND d.next_attempt_at <= CURRENT_TIMESTAMP
+		ORDER BY d.id ASC
+		LIMIT ?
+	`, DeliveryPending, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// recordAttempt stores the outcome of sending a delivery. A failed attempt
+// is retried after retryIn, or marked failed when retryIn is zero.
+func recordAttempt(db *sql.DB, id int64, responseStatus int, errMsg string, retryIn time.Duration) error {
+	status := DeliveryDelivered
+	if errMsg != "" {
+		status = DeliveryPending
+		if retryIn == 0 {
+			status = DeliveryFailed
+		}
+	}
+
+	_, err := db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = attempts + 1,
+		    response_status = NULLIF(?, 0), last_error = NULLIF(?, ''),
+		    next_attempt_at = datetime('now', ?),
+		    delivered_at = CASE WHEN ? = 'delivered' THEN CURRENT_TIMESTAMP END
+		WHERE id = ?
+	`, status, responseStatus, errMsg, fmt.Sprintf("+%d seconds", int(retryIn.Seconds())), status, id)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+	return nil
+}
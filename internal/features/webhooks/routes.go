@@ -0,0 +1,12 @@
+package webhooks
+
+import "github.com/gofiber/fiber/v2"
+
+// RegisterRoutes sets up webhook management and delivery log routes
+func RegisterRoutes(app *fiber.App) {
+	app.Get("/webhooks", HandleIndex)
+	app.Post("/webhooks", HandleCreate)
+	app.Delete("/webhooks/:id", HandleDelete)
+	app.Get("/webhooks/deliveries", HandleDeliveries)
+	app.Post("/webhooks/deliveries/:id/retry", HandleRedeliver)
+}
@@ -1,10 +1,15 @@
 package workouts
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"phobos/internal/features/exercises"
+	"phobos/internal/features/plates"
+	"phobos/internal/shared/dates"
 	"phobos/internal/shared/htmx"
 	"phobos/internal/shared/middleware"
 
@@ -23,16 +28,84 @@ func HandleList(c *fiber.Ctx) error {
 	return htmx.Render(c, WorkoutsPage(workouts))
 }
 
-// HandleHistory displays finished workouts
+// dateTimeLocalLayout is the value format of <input type="datetime-local">
+const dateTimeLocalLayout = "2006-01-02T15:04"
+
+// historyPageSize is how many workouts each history page loads
+const historyPageSize = 20
+
+// HandleHistory displays the first page of finished workouts with the history filters
 func HandleHistory(c *fiber.Ctx) error {
 	db := middleware.GetDB(c)
 
-	workouts, err := ListFinished(db)
+	filter, err := historyFilterFromQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid date filter")
+	}
+
+	result, err := ListHistory(db, filter, nil, historyPageSize)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load history")
+	}
+
+	options, err := ListHistoryFilterOptions(db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load history filters")
+	}
+
+	return htmx.Render(c, HistoryPage(result, filter, *options))
+}
+
+// HandleHistoryPage returns a page of history cards, used by the filters and infinite scroll
+func HandleHistoryPage(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	filter, err := historyFilterFromQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid date filter")
+	}
+
+	var after *HistoryCursor
+	if cursor := c.Query("after"); cursor != "" {
+		after, err = ParseHistoryCursor(cursor)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid cursor")
+		}
+	}
+
+	result, err := ListHistory(db, filter, after, historyPageSize)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load history")
 	}
 
-	return htmx.Render(c, HistoryPage(workouts))
+	return htmx.Render(c, HistoryItems(result, filter, after == nil))
+}
+
+// historyFilterFromQuery reads the history filters from the query string
+func historyFilterFromQuery(c *fiber.Ctx) (HistoryFilter, error) {
+	filter := HistoryFilter{
+		Query:      strings.TrimSpace(c.Query("q")),
+		TemplateID: int64(c.QueryInt("template_id")),
+		RoutineID:  int64(c.QueryInt("routine_id")),
+		ExerciseID: int64(c.QueryInt("exercise_id")),
+	}
+
+	if from := c.Query("from"); from != "" {
+		d, err := time.Parse(dates.Layout, from)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from date: %w", err)
+		}
+		filter.From = &d
+	}
+	if to := c.Query("to"); to != "" {
+		d, err := time.Parse(dates.Layout, to)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to date: %w", err)
+		}
+		filter.To = &d
+	}
+
+	return filter, nil
 }
 
 // HandleNew displays the new workout form
@@ -49,6 +122,7 @@ func HandleNew(c *fiber.Ctx) error {
 			if err != nil {
 				return c.Status(fiber.StatusInternalServerError).SendString("Failed to create workout: " + err.Error())
 			}
+			notifyWorkoutCreated(c, id)
 			return c.Redirect("/workouts/"+strconv.FormatInt(id, 10), fiber.StatusSeeOther)
 		}
 	}
@@ -71,7 +145,7 @@ func HandleCreate(c *fiber.Ctx) error {
 	}
 
 	dateStr := c.FormValue("date")
-	date, err := time.Parse("2006-01-02", dateStr)
+	date, err := time.Parse(dates.Layout, dateStr)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).SendString("Invalid date")
 	}
@@ -81,6 +155,7 @@ func HandleCreate(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).SendString("Failed to create workout")
 	}
 
+	notifyWorkoutCreated(c, id)
 	return c.Redirect("/workouts/"+strconv.FormatInt(id, 10), fiber.StatusSeeOther)
 }
 
@@ -101,21 +176,22 @@ func HandleShow(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusNotFound).SendString("Workout not found")
 	}
 
-	// Load template targets if applicable
-	if workout.TemplateID != nil {
-		for i := range workout.Exercises {
-			targetSets, targetReps, _ := GetTemplateTargets(db, *workout.TemplateID, workout.Exercises[i].ExerciseID)
-			workout.Exercises[i].TargetSets = targetSets
-			workout.Exercises[i].TargetReps = targetReps
-		}
-	}
-
 	allExercises, err := exercises.ListAll(db)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load exercises")
 	}
 
-	return htmx.Render(c, WorkoutDetailPage(workout, allExercises))
+	kit, err := plates.GetKit(db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load plates")
+	}
+
+	clientID, err := NewClientID()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to generate set ID")
+	}
+
+	return htmx.Render(c, WorkoutDetailPage(workout, allExercises, kit, clientID))
 }
 
 // HandleUpdate modifies a workout
@@ -133,21 +209,83 @@ func HandleUpdate(c *fiber.Ctx) error {
 	}
 
 	dateStr := c.FormValue("date")
-	date, err := time.Parse("2006-01-02", dateStr)
+	date, err := time.Parse(dates.Layout, dateStr)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).SendString("Invalid date")
 	}
 
 	notes := c.FormValue("notes")
 
-	if err := Update(db, id, name, date, notes); err != nil {
+	startedAt, endedAt, invalid := formTimes(c)
+	if invalid != "" {
+		return c.Status(fiber.StatusBadRequest).SendString(invalid)
+	}
+
+	err = UpdateWithTimes(db, id, name, date, notes, startedAt, endedAt)
+	if errors.Is(err, ErrFinished) {
+		return c.Status(fiber.StatusConflict).SendString("Finished workouts can't be edited")
+	}
+	if errors.Is(err, ErrEndBeforeStart) {
+		return c.Status(fiber.StatusBadRequest).SendString("End time must be after start time")
+	}
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).SendString("Failed to update workout")
 	}
 
+	publishWorkout(c, id)
+	htmx.Trigger(c, "workoutUpdated")
+	return c.SendString("")
+}
+
+// HandleUpdateTimes corrects when a session started and ended, including after it's finished
+func HandleUpdateTimes(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	startedAt, endedAt, invalid := formTimes(c)
+	if invalid != "" {
+		return c.Status(fiber.StatusBadRequest).SendString(invalid)
+	}
+
+	err = SetTimes(db, id, startedAt, endedAt)
+	if errors.Is(err, ErrEndBeforeStart) {
+		return c.Status(fiber.StatusBadRequest).SendString("End time must be after start time")
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to update workout times")
+	}
+
+	publishWorkout(c, id)
 	htmx.Trigger(c, "workoutUpdated")
 	return c.SendString("")
 }
 
+// formTimes reads the optional started_at and ended_at form values, or returns
+// a message for the client if one is invalid. They are datetime-local values,
+// which carry no zone, so they are read in the server's local time zone, the
+// same zone the form shows them in. A blank value is nil.
+func formTimes(c *fiber.Ctx) (startedAt, endedAt *time.Time, invalid string) {
+	if v := c.FormValue("started_at"); v != "" {
+		t, err := time.ParseInLocation(dateTimeLocalLayout, v, time.Local)
+		if err != nil {
+			return nil, nil, "Invalid start time"
+		}
+		startedAt = &t
+	}
+	if v := c.FormValue("ended_at"); v != "" {
+		t, err := time.ParseInLocation(dateTimeLocalLayout, v, time.Local)
+		if err != nil {
+			return nil, nil, "Invalid end time"
+		}
+		endedAt = &t
+	}
+	return startedAt, endedAt, ""
+}
+
 // HandleDelete removes a workout
 func HandleDelete(c *fiber.Ctx) error {
 	db := middleware.GetDB(c)
@@ -161,6 +299,7 @@ func HandleDelete(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).SendString("Failed to delete workout")
 	}
 
+	publishWorkout(c, id)
 	return c.SendString("")
 }
 
@@ -173,11 +312,83 @@ func HandleFinish(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
 	}
 
-	if err := Finish(db, id); err != nil {
+	workout, err := GetByID(db, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load workout")
+	}
+	if workout == nil {
+		return c.Status(fiber.StatusNotFound).SendString("Workout not found")
+	}
+
+	finished, err := Finish(db, id)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).SendString("Failed to finish workout")
 	}
 
-	return htmx.Redirect(c, "/workouts/history")
+	// Only the request that finished the workout sends the events, so
+	// finishing again or concurrently doesn't repeat them
+	if finished {
+		notifyFinished(c, id)
+	}
+	publishWorkout(c, id)
+	return htmx.Redirect(c, "/workouts/"+strconv.FormatInt(id, 10)+"/summary")
+}
+
+// HandlePause pauses the session clock
+func HandlePause(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	if err := Pause(db, id); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to pause workout")
+	}
+
+	publishWorkout(c, id)
+	return htmx.Refresh(c)
+}
+
+// HandleResume restarts the session clock
+func HandleResume(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	if err := Resume(db, id); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to resume workout")
+	}
+
+	publishWorkout(c, id)
+	return htmx.Refresh(c)
+}
+
+// HandleSummary displays the totals, PRs and targets of a finished workout
+func HandleSummary(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	summary, err := ComputeFinishSummary(db, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load summary")
+	}
+	if summary == nil {
+		return c.Status(fiber.StatusNotFound).SendString("Workout not found")
+	}
+	if !summary.Workout.IsFinished() {
+		return c.Redirect("/workouts/"+strconv.FormatInt(id, 10), fiber.StatusSeeOther)
+	}
+
+	return htmx.Render(c, SummaryPage(summary))
 }
 
 // HandleAddExercise adds an exercise to a workout
@@ -213,7 +424,13 @@ func HandleAddExercise(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load exercise")
 	}
 
-	return htmx.Render(c, WorkoutExerciseCard(*we, false, workout.TemplateID))
+	kit, err := plates.GetKit(db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load plates")
+	}
+
+	publishExerciseAdded(c, workoutID, id)
+	return htmx.Render(c, WorkoutExerciseCard(*we, false, workout.TemplateID, workout.Bodyweight, kit))
 }
 
 // HandleRemoveExercise removes an exercise from a workout
@@ -240,6 +457,7 @@ func HandleRemoveExercise(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).SendString("Failed to remove exercise")
 	}
 
+	publishExerciseRemoved(c, we.WorkoutID, id)
 	return c.SendString("")
 }
 
@@ -273,17 +491,294 @@ func HandleAddSet(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).SendString("Invalid weight")
 	}
 
-	id, err := AddSet(db, workoutExerciseID, reps, weight)
+	setType := SetType(c.FormValue("set_type", string(SetWorking)))
+	if setType != SetWorking && setType != SetWarmup {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid set type")
+	}
+
+	clientID := c.FormValue("client_id")
+	if clientID == "" {
+		if clientID, err = NewClientID(); err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString("Failed to generate set ID")
+		}
+	} else if !validClientID(clientID) {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid client ID")
+	}
+
+	// A resubmitted form returns the set it already created
+	set, err := GetSetByClientID(db, clientID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString("Failed to add set")
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load set")
+	}
+	if set == nil {
+		// A resubmitted form for a set deleted since must not bring it back
+		deletedAt, err := GetSetDeletedAt(db, clientID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString("Failed to load set")
+		}
+		if deletedAt != nil {
+			return c.Status(fiber.StatusConflict).SendString("Set was deleted")
+		}
+
+		change := SetChange{ClientID: clientID, Reps: reps, Weight: weight, Type: setType, UpdatedAt: time.Now()}
+		if _, err := AddClientSet(db, workoutExerciseID, change); err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString("Failed to add set")
+		}
+
+		// Load by client ID, since a resubmission racing this one may have saved the set first
+		set, err = GetSetByClientID(db, clientID)
+		if err != nil || set == nil {
+			return c.Status(fiber.StatusInternalServerError).SendString("Failed to load set")
+		}
+		if set.WorkoutExerciseID == workoutExerciseID {
+			notifySetLogged(c, *set)
+		}
+	}
+
+	// A client ID names one set, which can't move to another exercise
+	if set.WorkoutExerciseID != workoutExerciseID {
+		return c.Status(fiber.StatusConflict).SendString("Set belongs to another exercise")
+	}
+
+	// Reload to align the new set with the previous session
+	we, err = GetWorkoutExerciseByID(db, workoutExerciseID)
+	if err != nil || we == nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load exercise")
+	}
+
+	kit, err := plates.GetKit(db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load plates")
+	}
+
+	publishExercise(c, we.WorkoutID, we.ID)
+	return htmx.Render(c, AddedSet(*set, *we, kit))
+}
+
+// HandleSyncSet applies a set added or edited on a client, typically replayed
+// from the offline outbox. It is idempotent: the set is identified by the
+// client ID in the path.
+func HandleSyncSet(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	change := SetChange{ClientID: c.Params("clientID"), Type: SetType(c.FormValue("set_type", string(SetWorking)))}
+	if !validClientID(change.ClientID) {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid client ID")
+	}
+	if change.Type != SetWorking && change.Type != SetWarmup {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid set type")
+	}
+
+	var err error
+	change.WorkoutExerciseID, err = strconv.ParseInt(c.FormValue("workout_exercise_id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid exercise ID")
+	}
+	change.Reps, err = strconv.Atoi(c.FormValue("reps"))
+	if err != nil || change.Reps < 0 {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid reps")
+	}
+	change.Weight, err = strconv.ParseFloat(c.FormValue("weight"), 64)
+	if err != nil || change.Weight < 0 {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid weight")
+	}
+	change.UpdatedAt, err = parseClientTime(c.FormValue("updated_at"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid updated_at")
+	}
+
+	existing, err := GetSetByClientID(db, change.ClientID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load set")
+	}
+
+	result, err := ApplySetChange(db, change)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to sync set")
+	}
+
+	if result.Status == SyncApplied {
+		if existing == nil {
+			notifySetLogged(c, *result.Set)
+		}
+		publishSetChange(c, result.Set.WorkoutExerciseID)
+	}
+	return sendSyncResult(c, result)
+}
+
+// HandleSyncDeleteSet deletes a set on behalf of a client. Deleting a set that
+// is already gone succeeds.
+func HandleSyncDeleteSet(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	clientID := c.Params("clientID")
+	if !validClientID(clientID) {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid client ID")
+	}
+
+	deletedAt, err := parseClientTime(c.Query("updated_at"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid updated_at")
+	}
+
+	existing, err := GetSetByClientID(db, clientID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load set")
+	}
+
+	result, err := ApplySetDeletion(db, clientID, deletedAt)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to sync set")
+	}
+
+	if result.Status == SyncApplied && existing != nil {
+		publishSetChange(c, existing.WorkoutExerciseID)
+	}
+	return sendSyncResult(c, result)
+}
+
+// syncedSet is the JSON form of a set returned to the outbox
+type syncedSet struct {
+	ClientID          string  `json:"client_id"`
+	WorkoutExerciseID int64   `json:"workout_exercise_id"`
+	Reps              int     `json:"reps"`
+	Weight            float64 `json:"weight"`
+	Type              SetType `json:"set_type"`
+	UpdatedAt         int64   `json:"updated_at"`
+}
+
+// sendSyncResult responds with the resolution and the server's copy of the set.
+// Changes the server did not apply get 409 so the client drops them.
+func sendSyncResult(c *fiber.Ctx, result SyncResult) error {
+	body := fiber.Map{"status": result.Status}
+	if s := result.Set; s != nil {
+		body["set"] = syncedSet{
+			ClientID:          s.ClientID,
+			WorkoutExerciseID: s.WorkoutExerciseID,
+			Reps:              s.Reps,
+			Weight:            s.Weight,
+			Type:              s.Type,
+			UpdatedAt:         s.UpdatedAt.UnixMilli(),
+		}
+	}
+	if result.Status != SyncApplied {
+		c.Status(fiber.StatusConflict)
+	}
+	return c.JSON(body)
+}
+
+// validClientID accepts the UUIDs and hex strings generated by clients and NewClientID
+func validClientID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// parseClientTime parses a client timestamp in Unix milliseconds, defaulting to now
+func parseClientTime(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Now(), nil
+	}
+	ms, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(ms), nil
+}
+
+// HandleAddWarmups replaces an exercise's warm-up sets with a ramp up to its
+// first working set, or to the previous session's if none is logged yet
+func HandleAddWarmups(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid exercise ID")
+	}
+
+	we, err := GetWorkoutExerciseByID(db, id)
+	if err != nil || we == nil {
+		return c.Status(fiber.StatusNotFound).SendString("Exercise not found")
+	}
+
+	workout, err := GetByID(db, we.WorkoutID)
+	if err != nil || workout == nil || workout.IsFinished() {
+		return c.Status(fiber.StatusBadRequest).SendString("Cannot modify finished workout")
+	}
+
+	working := we.FirstWorkingWeight()
+	if working == 0 && we.Previous != nil {
+		working = WorkoutExercise{Sets: we.Previous.Sets}.FirstWorkingWeight()
+	}
+	if working == 0 {
+		return c.Status(fiber.StatusBadRequest).SendString("Log a working set first")
+	}
+
+	kit, err := plates.GetKit(db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load plates")
+	}
+
+	warmups := kit.Warmups(working)
+	if len(warmups) == 0 {
+		return c.Status(fiber.StatusBadRequest).SendString("Working weight is too light to warm up to")
+	}
+
+	if err := ReplaceWarmups(db, id, warmups); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to add warm-up sets")
+	}
+
+	publishExercise(c, we.WorkoutID, id)
+	return htmx.Refresh(c)
+}
+
+// HandleCopyPreviousSet overwrites a set with the aligned set from the previous session
+func HandleCopyPreviousSet(c *fiber.Ctx) error {
+	db := middleware.GetDB(c)
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
 	}
 
 	set, err := GetSetByID(db, id)
 	if err != nil || set == nil {
-		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load set")
+		return c.Status(fiber.StatusNotFound).SendString("Set not found")
 	}
 
-	return htmx.Render(c, SetRow(*set, false))
+	we, err := GetWorkoutExerciseByID(db, set.WorkoutExerciseID)
+	if err != nil || we == nil {
+		return c.Status(fiber.StatusNotFound).SendString("Exercise not found")
+	}
+
+	workout, err := GetByID(db, we.WorkoutID)
+	if err != nil || workout == nil || workout.IsFinished() {
+		return c.Status(fiber.StatusBadRequest).SendString("Cannot modify finished workout")
+	}
+
+	prev := we.PreviousSet(we.SetIndex(set.ID))
+	if prev == nil {
+		return c.Status(fiber.StatusNotFound).SendString("No previous set to copy")
+	}
+
+	if err := UpdateSet(db, set.ID, prev.Reps, prev.Weight); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to update set")
+	}
+	set.Reps, set.Weight = prev.Reps, prev.Weight
+	publishExercise(c, we.WorkoutID, we.ID)
+
+	kit, err := plates.GetKit(db)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load plates")
+	}
+
+	return htmx.Render(c, SetRow(*set, false, prev, barbellKit(*we, kit)))
 }
 
 // HandleUpdateSet modifies an existing set
@@ -309,7 +804,22 @@ func HandleUpdateSet(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).SendString("Failed to update set")
 	}
 
-	return c.SendString("")
+	// Refresh the plate breakdown for barbell exercises
+	set, err := GetSetByID(db, id)
+	if err != nil || set == nil {
+		return c.SendString("")
+	}
+	publishSetChange(c, set.WorkoutExerciseID)
+	we, err := GetWorkoutExerciseByID(db, set.WorkoutExerciseID)
+	if err != nil || we == nil || !we.UsesBarbell {
+		return c.SendString("")
+	}
+	kit, err := plates.GetKit(db)
+	if err != nil {
+		return c.SendString("")
+	}
+
+	return htmx.Render(c, SetPlates(set.Weight, kit))
 }
 
 // HandleDeleteSet removes a set
@@ -321,9 +831,17 @@ func HandleDeleteSet(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
 	}
 
+	set, err := GetSetByID(db, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load set")
+	}
+
 	if err := DeleteSet(db, id); err != nil {
 		return c.Status(fiber.StatusInternalServerError).SendString("Failed to delete set")
 	}
 
+	if set != nil {
+		publishSetChange(c, set.WorkoutExerciseID)
+	}
 	return c.SendString("")
 }
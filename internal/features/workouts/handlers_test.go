@@ -2,14 +2,18 @@ package workouts_test
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"phobos/internal/features/exercises"
+	"phobos/internal/features/routines"
 	"phobos/internal/features/templates"
+	"phobos/internal/features/webhooks"
 	"phobos/internal/features/workouts"
+	"phobos/internal/shared/middleware"
 	"phobos/internal/testutil"
 )
 
@@ -139,6 +143,163 @@ func TestHandleFinish(t *testing.T) {
 	if !workout.IsFinished() {
 		t.Error("expected workout to be finished")
 	}
+
+	expected := "/workouts/" + strconv.FormatInt(id, 10) + "/summary"
+	if resp.Header.Get("HX-Redirect") != expected {
+		t.Errorf("expected redirect to %s, got %s", expected, resp.Header.Get("HX-Redirect"))
+	}
+}
+
+func TestFinish_AlreadyFinished(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	exerciseID, _ := exercises.Create(app.DB, "Squat")
+	id, _ := workouts.Create(app.DB, "Legs", time.Now(), nil)
+	weID, _ := workouts.AddExercise(app.DB, id, exerciseID)
+	workouts.AddSet(app.DB, weID, 5, 100)
+
+	if finished, err := workouts.Finish(app.DB, id); err != nil || !finished {
+		t.Fatalf("Finish: finished %v, %v", finished, err)
+	}
+	var setCount int
+	app.DB.QueryRow(`SELECT set_count FROM workout_summaries WHERE workout_id = ?`, id).Scan(&setCount)
+	if setCount != 1 {
+		t.Fatalf("expected the summary to be saved with 1 set, got %d", setCount)
+	}
+
+	// Finishing again leaves the stored summary alone
+	app.DB.Exec(`UPDATE workout_summaries SET set_count = 99 WHERE workout_id = ?`, id)
+	if finished, err := workouts.Finish(app.DB, id); err != nil || finished {
+		t.Fatalf("expected finishing again to report no change: finished %v, %v", finished, err)
+	}
+	app.DB.QueryRow(`SELECT set_count FROM workout_summaries WHERE workout_id = ?`, id).Scan(&setCount)
+	if setCount != 99 {
+		t.Errorf("expected the summary not to be recomputed, got %d sets", setCount)
+	}
+}
+
+func TestSetChanges_RefreshFinishedSummary(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	exerciseID, _ := exercises.Create(app.DB, "Squat")
+	id, _ := workouts.Create(app.DB, "Legs", time.Now(), nil)
+	weID, _ := workouts.AddExercise(app.DB, id, exerciseID)
+	firstID, _ := workouts.AddSet(app.DB, weID, 5, 100)
+	secondID, _ := workouts.AddSet(app.DB, weID, 5, 100)
+	workouts.Finish(app.DB, id)
+
+	summary := func() (volume float64, sets int) {
+		app.DB.QueryRow(`SELECT total_volume, set_count FROM workout_summaries WHERE workout_id = ?`, id).Scan(&volume, &sets)
+		return volume, sets
+	}
+
+	resp := app.HTMXRequest("PUT", "/workouts/sets/"+strconv.FormatInt(firstID, 10), "reps=5&weight=120")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	if volume, sets := summary(); volume != 1100 || sets != 2 {
+		t.Errorf("expected the edit to give 1100 volume over 2 sets, got %v over %d", volume, sets)
+	}
+
+	resp = app.HTMXRequest("DELETE", "/workouts/sets/"+strconv.FormatInt(secondID, 10), "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	if volume, sets := summary(); volume != 600 || sets != 1 {
+		t.Errorf("expected the deletion to leave 600 volume over 1 set, got %v over %d", volume, sets)
+	}
+}
+
+func TestComputeFinishSummary(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	benchID, _ := exercises.Create(app.DB, "Bench Press")
+	rowID, _ := exercises.Create(app.DB, "Row")
+	templateID, _ := templates.Create(app.DB, "Upper")
+	templates.AddExercise(app.DB, templateID, benchID, 3, 5)
+	templates.AddExercise(app.DB, templateID, rowID, 2, 10)
+
+	date := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	firstID, _ := workouts.CreateFromTemplate(app.DB, "Upper", date, templateID)
+	first, _ := workouts.GetByID(app.DB, firstID)
+	workouts.AddSet(app.DB, first.Exercises[0].ID, 5, 200)
+	workouts.Finish(app.DB, firstID)
+
+	secondID, _ := workouts.CreateFromTemplate(app.DB, "Upper", date.AddDate(0, 0, 3), templateID)
+	second, _ := workouts.GetByID(app.DB, secondID)
+	for i := 0; i < 3; i++ {
+		workouts.AddSet(app.DB, second.Exercises[0].ID, 5, 210)
+	}
+	workouts.AddSet(app.DB, second.Exercises[1].ID, 8, 100) // Misses 2 x 10
+	workouts.Finish(app.DB, secondID)
+
+	summary, err := workouts.ComputeFinishSummary(app.DB, secondID)
+	if err != nil {
+		t.Fatalf("failed to compute summary: %v", err)
+	}
+
+	if summary.TotalVolume != 3*5*210+8*100 {
+		t.Errorf("expected volume %d, got %v", 3*5*210+8*100, summary.TotalVolume)
+	}
+	if summary.SetCount != 4 || summary.RepCount != 23 {
+		t.Errorf("expected 4 sets and 23 reps, got %d and %d", summary.SetCount, summary.RepCount)
+	}
+	if summary.TargetsMet != 1 || summary.TargetsTotal != 2 {
+		t.Errorf("expected 1 of 2 targets met, got %d of %d", summary.TargetsMet, summary.TargetsTotal)
+	}
+	if !summary.Exercises[0].TargetsMet || summary.Exercises[1].TargetsMet {
+		t.Error("expected bench to meet and row to miss targets")
+	}
+
+	// Bench beat its previous weight and e1RM; the row had no earlier sets
+	if len(summary.PRs) != 2 || summary.PRs[0].Exercise != "Bench Press" || summary.PRs[0].Previous != 200 {
+		t.Errorf("expected 2 bench PRs over 200, got %+v", summary.PRs)
+	}
+
+	if summary.Previous == nil || summary.Previous.WorkoutID != firstID || summary.Previous.TotalVolume != 1000 {
+		t.Errorf("expected comparison with the first session, got %+v", summary.Previous)
+	}
+
+	// The totals are stored for the history list
+	result, _ := workouts.ListHistory(app.DB, workouts.HistoryFilter{}, nil, 10)
+	if result.Workouts[0].ID != secondID || result.Workouts[0].Stats == nil {
+		t.Fatalf("expected stored stats on the latest workout, got %+v", result.Workouts[0])
+	}
+	if result.Workouts[0].Stats.PRCount != 2 || result.Workouts[0].Stats.TotalVolume != summary.TotalVolume {
+		t.Errorf("expected stored stats to match the summary, got %+v", result.Workouts[0].Stats)
+	}
+}
+
+func TestHandleSummary(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	id, _ := workouts.Create(app.DB, "Quick Session", time.Now(), nil)
+
+	// Unfinished workouts send you back to the workout
+	resp := app.Request("GET", "/workouts/"+strconv.FormatInt(id, 10)+"/summary", "")
+	if resp.StatusCode != http.StatusSeeOther {
+		t.Errorf("expected status 303, got %d", resp.StatusCode)
+	}
+
+	workouts.Finish(app.DB, id)
+
+	resp = app.Request("GET", "/workouts/"+strconv.FormatInt(id, 10)+"/summary", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "Workout Complete") || !strings.Contains(body, "Quick Session") {
+		t.Error("expected summary page for the workout")
+	}
 }
 
 func TestHandleShow_Finished(t *testing.T) {
@@ -196,6 +357,273 @@ func TestHandleHistory(t *testing.T) {
 	}
 }
 
+func TestListHistory_Pagination(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	// Several workouts share a date so the cursor has to break ties by ID
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 7; i++ {
+		id, _ := workouts.Create(app.DB, "Workout "+strconv.Itoa(i), start.AddDate(0, 0, i/2), nil)
+		workouts.Finish(app.DB, id)
+	}
+
+	var seen []int64
+	var after *workouts.HistoryCursor
+	pages := 0
+	for {
+		result, err := workouts.ListHistory(app.DB, workouts.HistoryFilter{}, after, 3)
+		if err != nil {
+			t.Fatalf("failed to list history: %v", err)
+		}
+		pages++
+		for _, w := range result.Workouts {
+			seen = append(seen, w.ID)
+		}
+		if result.Next == nil {
+			break
+		}
+		after = result.Next
+	}
+
+	if pages != 3 {
+		t.Errorf("expected 3 pages, got %d", pages)
+	}
+	if len(seen) != 7 {
+		t.Fatalf("expected 7 workouts across pages, got %d", len(seen))
+	}
+	for i := 1; i < len(seen); i++ {
+		if seen[i] >= seen[i-1] {
+			t.Errorf("expected newest first without repeats, got %v", seen)
+			break
+		}
+	}
+}
+
+func TestListHistory_Filters(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	squatID, _ := exercises.Create(app.DB, "Squat")
+	templateID, _ := templates.Create(app.DB, "Leg Day")
+	routineID, _ := routines.Create(app.DB, "Strength Block")
+	routines.AddTemplate(app.DB, routineID, templateID)
+
+	legsID, _ := workouts.Create(app.DB, "Legs", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), &templateID)
+	workouts.AddExercise(app.DB, legsID, squatID)
+	workouts.Finish(app.DB, legsID)
+
+	armsID, _ := workouts.Create(app.DB, "Arms", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), nil)
+	workouts.Update(app.DB, armsID, "Arms", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), "felt strong")
+	workouts.Finish(app.DB, armsID)
+
+	from := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		filter workouts.HistoryFilter
+		want   int64
+	}{
+		{"template", workouts.HistoryFilter{TemplateID: templateID}, legsID},
+		{"routine", workouts.HistoryFilter{RoutineID: routineID}, legsID},
+		{"exercise", workouts.HistoryFilter{ExerciseID: squatID}, legsID},
+		{"date range", workouts.HistoryFilter{From: &from}, armsID},
+		{"notes search", workouts.HistoryFilter{Query: "strong"}, armsID},
+		{"exercise name search", workouts.HistoryFilter{Query: "squat"}, legsID},
+		{"word prefix search", workouts.HistoryFilter{Query: "strong fel"}, armsID},
+		{"exercise name prefix search", workouts.HistoryFilter{Query: "squ"}, legsID},
+	}
+
+	for _, tt := range tests {
+		result, err := workouts.ListHistory(app.DB, tt.filter, nil, 20)
+		if err != nil {
+			t.Fatalf("%s: failed to list history: %v", tt.name, err)
+		}
+		if len(result.Workouts) != 1 || result.Workouts[0].ID != tt.want {
+			t.Errorf("%s: expected only workout %d, got %v", tt.name, tt.want, result.Workouts)
+		}
+	}
+}
+
+func TestHandleHistoryPage_Fragment(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 25; i++ {
+		id, _ := workouts.Create(app.DB, "Session "+strconv.Itoa(i), date, nil)
+		workouts.Finish(app.DB, id)
+	}
+
+	resp := app.HTMXRequest("GET", "/workouts/history/page?q=Session", "")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	body := testutil.ReadBody(t, resp)
+	if strings.Contains(body, "<html") {
+		t.Error("expected a fragment")
+	}
+	if strings.Count(body, `id="history-`) != 20 {
+		t.Errorf("expected a page of 20 workouts, got %d", strings.Count(body, `id="history-`))
+	}
+	if !strings.Contains(body, `hx-trigger="revealed"`) || !strings.Contains(body, "q=Session") {
+		t.Error("expected a sentinel that loads the next page with the same filters")
+	}
+}
+
+func TestHandleHistoryPage_InvalidCursor(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	resp := app.HTMXRequest("GET", "/workouts/history/page?after=nope", "")
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestHandleUpdate_Times(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	id, _ := workouts.Create(app.DB, "Logged Later", time.Now(), nil)
+
+	resp := app.HTMXRequest("PUT", "/workouts/"+strconv.FormatInt(id, 10),
+		"name=Logged+Later&date=2024-01-15&started_at=2024-01-15T09:00&ended_at=2024-01-15T10:15")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	// A rejected time leaves the name alone too
+	resp = app.HTMXRequest("PUT", "/workouts/"+strconv.FormatInt(id, 10),
+		"name=Bad&date=2024-01-15&started_at=2024-01-15T10:00&ended_at=2024-01-15T09:00")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an end before the start, got %d", resp.StatusCode)
+	}
+
+	workouts.Finish(app.DB, id)
+	workout, _ := workouts.GetByID(app.DB, id)
+	if got := workout.Duration(time.Now()); got != 75*time.Minute {
+		t.Errorf("expected the entered times to give 75 minutes, got %v", got)
+	}
+	if workout.Name != "Logged Later" {
+		t.Errorf("expected the rejected edit not to rename the workout, got %q", workout.Name)
+	}
+
+	// Finished workouts are read-only apart from their times
+	resp = app.HTMXRequest("PUT", "/workouts/"+strconv.FormatInt(id, 10),
+		"name=Renamed&date=2024-01-15")
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected status 409 for editing a finished workout, got %d", resp.StatusCode)
+	}
+}
+
+func TestHandleUpdateTimes_Finished(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	id, _ := workouts.Create(app.DB, "Logged Later", time.Now(), nil)
+	idStr := strconv.FormatInt(id, 10)
+	app.HTMXRequest("PUT", "/workouts/"+idStr,
+		"name=Logged+Later&date=2024-01-15&started_at=2024-01-15T09:00")
+	workouts.Finish(app.DB, id)
+
+	// The end can be corrected on its own once the workout is finished
+	resp := app.HTMXRequest("PUT", "/workouts/"+idStr+"/times", "ended_at=2024-01-15T10:30")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	workout, _ := workouts.GetByID(app.DB, id)
+	if got := workout.Duration(time.Now()); got != 90*time.Minute {
+		t.Errorf("expected the corrected end to give 90 minutes, got %v", got)
+	}
+	var seconds int64
+	app.DB.QueryRow(`SELECT duration_seconds FROM workout_summaries WHERE workout_id = ?`, id).Scan(&seconds)
+	if seconds != 90*60 {
+		t.Errorf("expected the saved summary to be recomputed, got %d seconds", seconds)
+	}
+
+	resp = app.HTMXRequest("PUT", "/workouts/"+idStr+"/times", "ended_at=2024-01-15T08:00")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an end before the saved start, got %d", resp.StatusCode)
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	id, _ := workouts.Create(app.DB, "Session", time.Now(), nil)
+	idStr := strconv.FormatInt(id, 10)
+
+	resp := app.HTMXRequest("POST", "/workouts/"+idStr+"/pause", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+	workout, _ := workouts.GetByID(app.DB, id)
+	if !workout.IsPaused() {
+		t.Fatal("expected workout to be paused")
+	}
+
+	// Pretend the pause began ten minutes ago
+	app.DB.Exec(`UPDATE workouts SET paused_at = datetime('now', '-10 minutes') WHERE id = ?`, id)
+
+	app.HTMXRequest("POST", "/workouts/"+idStr+"/resume", "")
+	workout, _ = workouts.GetByID(app.DB, id)
+	if workout.IsPaused() {
+		t.Error("expected workout to be resumed")
+	}
+	if workout.PausedFor < 10*time.Minute || workout.PausedFor > 11*time.Minute {
+		t.Errorf("expected about 10 minutes paused, got %v", workout.PausedFor)
+	}
+}
+
+func TestWorkoutDuration(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	paused := start.Add(50 * time.Minute)
+	now := start.Add(2 * time.Hour)
+
+	w := workouts.Workout{StartedAt: &start, PausedFor: 10 * time.Minute}
+	if got := w.Duration(now); got != 110*time.Minute {
+		t.Errorf("expected running time minus pauses, got %v", got)
+	}
+
+	w.PausedAt = &paused
+	if got := w.Duration(now); got != 40*time.Minute {
+		t.Errorf("expected the clock to stop at the pause, got %v", got)
+	}
+}
+
+func TestWorkoutExerciseTiming(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	we := workouts.WorkoutExercise{Sets: []workouts.LoggedSet{
+		{CreatedAt: start},
+		{CreatedAt: start.Add(2 * time.Minute)},
+		{CreatedAt: start.Add(5 * time.Minute)},
+	}}
+
+	if we.SetSpan() != 5*time.Minute {
+		t.Errorf("expected 5 minute span, got %v", we.SetSpan())
+	}
+	if we.AverageRest() != 150*time.Second {
+		t.Errorf("expected 2:30 average rest, got %v", we.AverageRest())
+	}
+}
+
 func TestHandleDelete(t *testing.T) {
 	t.Parallel()
 	app := testutil.NewTestApp(t)
@@ -421,6 +849,90 @@ func TestCreateFromTemplate(t *testing.T) {
 	}
 }
 
+func TestCreateFromTemplate_LinksVersion(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	templateID, _ := templates.Create(app.DB, "Legs")
+	squatID, _ := exercises.Create(app.DB, "Squat")
+	templates.AddExercise(app.DB, templateID, squatID, 3, 5)
+
+	workoutID, err := workouts.CreateFromTemplate(app.DB, "Legs", time.Now(), templateID)
+	if err != nil {
+		t.Fatalf("failed to create workout: %v", err)
+	}
+	templates.Update(app.DB, templateID, "Leg Day")
+
+	workout, _ := workouts.GetByID(app.DB, workoutID)
+	if workout.TemplateVersion == nil || workout.TemplateVersion.Version != 2 {
+		t.Fatalf("expected workout started from version 2, got %+v", workout.TemplateVersion)
+	}
+
+	// The version stays linked and viewable after the template is deleted
+	templates.Delete(app.DB, templateID)
+	versionPath := "/templates/" + strconv.FormatInt(templateID, 10) + "/versions/2"
+
+	resp := app.Request("GET", "/workouts/"+strconv.FormatInt(workoutID, 10), "")
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, versionPath) {
+		t.Error("expected workout page to link to its template version")
+	}
+
+	resp = app.Request("GET", versionPath, "")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected version page to load after template deletion, got %d", resp.StatusCode)
+	}
+}
+
+func TestCreateFromTemplate_SnapshotsTargets(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	templateID, _ := templates.Create(app.DB, "Legs")
+	squatID, _ := exercises.Create(app.DB, "Squat")
+	heavyID, _ := templates.AddExercise(app.DB, templateID, squatID, 3, 5)
+	backoffID, _ := templates.AddExercise(app.DB, templateID, squatID, 2, 10)
+
+	workoutID, err := workouts.CreateFromTemplate(app.DB, "Legs", time.Now(), templateID)
+	if err != nil {
+		t.Fatalf("failed to create workout: %v", err)
+	}
+
+	// Editing the template afterwards must not change the workout's targets
+	if err := templates.UpdateExercise(app.DB, heavyID, 5, 3); err != nil {
+		t.Fatalf("failed to update template exercise: %v", err)
+	}
+
+	workout, _ := workouts.GetByID(app.DB, workoutID)
+	if len(workout.Exercises) != 2 {
+		t.Fatalf("expected 2 exercises, got %d", len(workout.Exercises))
+	}
+	heavy, backoff := workout.Exercises[0], workout.Exercises[1]
+	if heavy.TemplateExerciseID == nil || *heavy.TemplateExerciseID != heavyID {
+		t.Errorf("expected first exercise linked to template exercise %d, got %v", heavyID, heavy.TemplateExerciseID)
+	}
+	if backoff.TemplateExerciseID == nil || *backoff.TemplateExerciseID != backoffID {
+		t.Errorf("expected second exercise linked to template exercise %d, got %v", backoffID, backoff.TemplateExerciseID)
+	}
+	if *heavy.TargetSets != 3 || *heavy.TargetReps != 5 {
+		t.Errorf("expected heavy targets 3 x 5, got %d x %d", *heavy.TargetSets, *heavy.TargetReps)
+	}
+	if *backoff.TargetSets != 2 || *backoff.TargetReps != 10 {
+		t.Errorf("expected back-off targets 2 x 10, got %d x %d", *backoff.TargetSets, *backoff.TargetReps)
+	}
+
+	resp := app.Request("GET", "/workouts/"+strconv.FormatInt(workoutID, 10), "")
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "Target: 3 x 5") || !strings.Contains(body, "Target: 2 x 10") {
+		t.Error("expected both snapshotted targets on the workout page")
+	}
+	if strings.Contains(body, "Target: 5 x 3") {
+		t.Error("expected template edits not to show on an existing workout")
+	}
+}
+
 func TestLastWeight(t *testing.T) {
 	t.Parallel()
 	app := testutil.NewTestApp(t)
@@ -448,6 +960,459 @@ func TestLastWeight(t *testing.T) {
 	}
 }
 
+func TestPreviousSessionPrefersTemplate(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	exerciseID, _ := exercises.Create(app.DB, "Squat")
+	templateID, _ := templates.Create(app.DB, "Legs")
+	templates.AddExercise(app.DB, templateID, exerciseID, 3, 5)
+
+	// Templated session two weeks ago, then a more recent ad-hoc session
+	templated, _ := workouts.CreateFromTemplate(app.DB, "Legs", time.Now().AddDate(0, 0, -14), templateID)
+	w, _ := workouts.GetByID(app.DB, templated)
+	workouts.AddSet(app.DB, w.Exercises[0].ID, 5, 225)
+	workouts.AddSet(app.DB, w.Exercises[0].ID, 5, 235)
+	workouts.Finish(app.DB, templated)
+
+	adhoc, _ := workouts.Create(app.DB, "Extra", time.Now().AddDate(0, 0, -3), nil)
+	adhocWE, _ := workouts.AddExercise(app.DB, adhoc, exerciseID)
+	workouts.AddSet(app.DB, adhocWE, 8, 185)
+	workouts.Finish(app.DB, adhoc)
+
+	// A blank workout compares against the most recent session
+	blank, _ := workouts.Create(app.DB, "Blank", time.Now(), nil)
+	blankWE, _ := workouts.AddExercise(app.DB, blank, exerciseID)
+	we, _ := workouts.GetWorkoutExerciseByID(app.DB, blankWE)
+	if we.Previous == nil || we.Previous.WorkoutID != adhoc {
+		t.Fatalf("expected previous session from ad-hoc workout, got %+v", we.Previous)
+	}
+
+	// A templated workout compares against the same template
+	next, _ := workouts.CreateFromTemplate(app.DB, "Legs", time.Now(), templateID)
+	w, _ = workouts.GetByID(app.DB, next)
+	prev := w.Exercises[0].Previous
+	if prev == nil || prev.WorkoutID != templated || prev.Date.IsZero() {
+		t.Fatalf("expected previous session from templated workout, got %+v", prev)
+	}
+	if len(prev.Sets) != 2 || prev.Sets[1].Weight != 235 {
+		t.Errorf("expected previous sets 225, 235, got %+v", prev.Sets)
+	}
+	if pending := w.Exercises[0].PendingPrevious(); len(pending) != 2 {
+		t.Errorf("expected 2 pending previous sets, got %d", len(pending))
+	}
+}
+
+func TestHandleAddSet_AlignsWithPrevious(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	exerciseID, _ := exercises.Create(app.DB, "Squat")
+	w1, _ := workouts.Create(app.DB, "Workout 1", time.Now().AddDate(0, 0, -7), nil)
+	we1, _ := workouts.AddExercise(app.DB, w1, exerciseID)
+	workouts.AddSet(app.DB, we1, 5, 225)
+	workouts.AddSet(app.DB, we1, 3, 245)
+	workouts.Finish(app.DB, w1)
+
+	w2, _ := workouts.Create(app.DB, "Workout 2", time.Now(), nil)
+	we2, _ := workouts.AddExercise(app.DB, w2, exerciseID)
+	path := "/workouts/exercises/" + strconv.FormatInt(we2, 10) + "/sets"
+
+	resp := app.HTMXRequest(http.MethodPost, path, "reps=5&weight=230")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	body := testutil.ReadBody(t, resp)
+	if !strings.Contains(body, "5 x 225.0") {
+		t.Error("expected new set to show the aligned previous set")
+	}
+	if !strings.Contains(body, `hx-swap-oob="true"`) || !strings.Contains(body, "Previous: 3 x 245.0") {
+		t.Error("expected remaining previous sets to be refreshed out of band")
+	}
+}
+
+func TestHandleCopyPreviousSet(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	exerciseID, _ := exercises.Create(app.DB, "Squat")
+	w1, _ := workouts.Create(app.DB, "Workout 1", time.Now().AddDate(0, 0, -7), nil)
+	we1, _ := workouts.AddExercise(app.DB, w1, exerciseID)
+	workouts.AddSet(app.DB, we1, 5, 225)
+	workouts.Finish(app.DB, w1)
+
+	w2, _ := workouts.Create(app.DB, "Workout 2", time.Now(), nil)
+	we2, _ := workouts.AddExercise(app.DB, w2, exerciseID)
+	first, _ := workouts.AddSet(app.DB, we2, 0, 0)
+	second, _ := workouts.AddSet(app.DB, we2, 0, 0)
+
+	resp := app.HTMXRequest(http.MethodPost, "/workouts/sets/"+strconv.FormatInt(first, 10)+"/copy-previous", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	set, _ := workouts.GetSetByID(app.DB, first)
+	if set.Reps != 5 || set.Weight != 225 {
+		t.Errorf("expected copied set 5 x 225, got %d x %.1f", set.Reps, set.Weight)
+	}
+
+	// The second set has no counterpart in the previous session
+	resp = app.HTMXRequest(http.MethodPost, "/workouts/sets/"+strconv.FormatInt(second, 10)+"/copy-previous", "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestHandleAddWarmups(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	exerciseID, _ := exercises.Create(app.DB, "Squat")
+	exercises.SetTags(app.DB, exerciseID, nil, nil, []string{"barbell"})
+	workoutID, _ := workouts.Create(app.DB, "Legs", time.Now(), nil)
+	weID, _ := workouts.AddExercise(app.DB, workoutID, exerciseID)
+	path := "/workouts/exercises/" + strconv.FormatInt(weID, 10) + "/warmups"
+
+	// Nothing to ramp up to yet
+	resp := app.HTMXRequest(http.MethodPost, path, "")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400 without a working set, got %d", resp.StatusCode)
+	}
+
+	workouts.AddSet(app.DB, weID, 5, 225)
+
+	// Generating twice replaces the earlier warm-ups
+	for range 2 {
+		resp = app.HTMXRequest(http.MethodPost, path, "")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+	}
+
+	we, _ := workouts.GetWorkoutExerciseByID(app.DB, weID)
+	if len(we.Sets) != 5 {
+		t.Fatalf("expected 4 warm-ups and 1 working set, got %d sets", len(we.Sets))
+	}
+	for i, s := range we.Sets[:4] {
+		if !s.IsWarmup() || s.Position != i+1 {
+			t.Errorf("expected warm-up at position %d, got %+v", i+1, s)
+		}
+	}
+	if last := we.Sets[4]; last.IsWarmup() || last.Position != 5 || last.Weight != 225 {
+		t.Errorf("expected working set last, got %+v", last)
+	}
+
+	// Plate breakdowns are shown for barbell exercises
+	resp = app.Request(http.MethodGet, "/workouts/"+strconv.FormatInt(workoutID, 10), "")
+	if body := testutil.ReadBody(t, resp); !strings.Contains(body, "45 + 45") {
+		t.Error("expected plate breakdown for 225 lbs")
+	}
+
+	// Warm-ups are left out of the summary
+	workouts.Finish(app.DB, workoutID)
+	summary, _ := workouts.ComputeFinishSummary(app.DB, workoutID)
+	if summary.SetCount != 1 || summary.TotalVolume != 1125 {
+		t.Errorf("expected 1 set and 1125 volume, got %d sets and %.0f", summary.SetCount, summary.TotalVolume)
+	}
+}
+
+func TestHandleAddSet_ClientIDIsIdempotent(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	workoutID, _ := workouts.Create(app.DB, "Workout", time.Now(), nil)
+	exerciseID, _ := exercises.Create(app.DB, "Squat")
+	weID, _ := workouts.AddExercise(app.DB, workoutID, exerciseID)
+	path := "/workouts/exercises/" + strconv.FormatInt(weID, 10) + "/sets"
+
+	// A double-tapped submission carries the same client ID twice
+	for range 2 {
+		resp := app.HTMXRequest(http.MethodPost, path, "reps=5&weight=225&client_id=3f2a9c")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+	}
+
+	sets, _ := workouts.GetLoggedSets(app.DB, weID)
+	if len(sets) != 1 || sets[0].ClientID != "3f2a9c" {
+		t.Fatalf("expected a single set with the client ID, got %+v", sets)
+	}
+
+	// Resubmitting after the set was deleted doesn't bring it back
+	workouts.DeleteSet(app.DB, sets[0].ID)
+	resp := app.HTMXRequest(http.MethodPost, path, "reps=5&weight=225&client_id=3f2a9c")
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected status 409, got %d", resp.StatusCode)
+	}
+	if sets, _ := workouts.GetLoggedSets(app.DB, weID); len(sets) != 0 {
+		t.Errorf("expected the deleted set to stay deleted, got %+v", sets)
+	}
+}
+
+func TestHandleSyncSet(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	workoutID, _ := workouts.Create(app.DB, "Workout", time.Now(), nil)
+	exerciseID, _ := exercises.Create(app.DB, "Squat")
+	weID, _ := workouts.AddExercise(app.DB, workoutID, exerciseID)
+	we := strconv.FormatInt(weID, 10)
+	now := time.Now().UnixMilli()
+	at := func(offset time.Duration) string {
+		return strconv.FormatInt(now+offset.Milliseconds(), 10)
+	}
+
+	// Replaying the same offline add creates the set once
+	for range 2 {
+		resp := app.Request(http.MethodPut, "/workouts/sync/sets/abc123",
+			"workout_exercise_id="+we+"&reps=5&weight=225&updated_at="+at(0))
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+	}
+	sets, _ := workouts.GetLoggedSets(app.DB, weID)
+	if len(sets) != 1 {
+		t.Fatalf("expected 1 set, got %d", len(sets))
+	}
+
+	// A later edit wins
+	resp := app.Request(http.MethodPut, "/workouts/sync/sets/abc123",
+		"workout_exercise_id="+we+"&reps=6&weight=225&updated_at="+at(time.Minute))
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	// An edit made before the latest one is stale and ignored
+	resp = app.Request(http.MethodPut, "/workouts/sync/sets/abc123",
+		"workout_exercise_id="+we+"&reps=3&weight=200&updated_at="+at(30*time.Second))
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected status 409 for a stale edit, got %d", resp.StatusCode)
+	}
+	if body := testutil.ReadBody(t, resp); !strings.Contains(body, `"status":"stale"`) || !strings.Contains(body, `"reps":6`) {
+		t.Errorf("expected stale status with the server's set, got %s", body)
+	}
+
+	set, _ := workouts.GetSetByClientID(app.DB, "abc123")
+	if set.Reps != 6 || set.Weight != 225 {
+		t.Errorf("expected 6 x 225, got %d x %.1f", set.Reps, set.Weight)
+	}
+}
+
+// Two replays of one change can both find no set and both insert it
+func TestAddClientSet_SameClientID(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	workoutID, _ := workouts.Create(app.DB, "Workout", time.Now(), nil)
+	exerciseID, _ := exercises.Create(app.DB, "Squat")
+	weID, _ := workouts.AddExercise(app.DB, workoutID, exerciseID)
+	change := workouts.SetChange{ClientID: "abc123", Reps: 5, Weight: 225, Type: workouts.SetWorking, UpdatedAt: time.Now()}
+
+	first, err := workouts.AddClientSet(app.DB, weID, change)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := workouts.AddClientSet(app.DB, weID, change)
+	if err != nil {
+		t.Fatalf("expected the second insert to succeed, got %v", err)
+	}
+	if second != first {
+		t.Errorf("expected the same set, got %d and %d", first, second)
+	}
+	if sets, _ := workouts.GetLoggedSets(app.DB, weID); len(sets) != 1 {
+		t.Errorf("expected 1 set, got %d", len(sets))
+	}
+
+	// An older edit doesn't overwrite the saved one
+	change.Reps = 3
+	change.UpdatedAt = change.UpdatedAt.Add(-time.Minute)
+	if id, err := workouts.AddClientSet(app.DB, weID, change); err != nil || id != 0 {
+		t.Errorf("expected nothing saved for an older edit, got %d, %v", id, err)
+	}
+	if set, _ := workouts.GetSetByClientID(app.DB, "abc123"); set.Reps != 5 {
+		t.Errorf("expected 5 reps, got %d", set.Reps)
+	}
+
+	// The client ID can't be reused for another exercise's set
+	benchID, _ := exercises.Create(app.DB, "Bench Press")
+	otherID, _ := workouts.AddExercise(app.DB, workoutID, benchID)
+	change.Reps = 8
+	change.UpdatedAt = time.Now().Add(time.Minute)
+	if id, err := workouts.AddClientSet(app.DB, otherID, change); err != nil || id != 0 {
+		t.Errorf("expected nothing saved for another exercise, got %d, %v", id, err)
+	}
+	resp := app.HTMXRequest("POST", "/workouts/exercises/"+strconv.FormatInt(otherID, 10)+"/sets",
+		"reps=8&weight=135&client_id=abc123")
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected status 409 for a client ID of another exercise's set, got %d", resp.StatusCode)
+	}
+	if set, _ := workouts.GetSetByClientID(app.DB, "abc123"); set.Reps != 5 || set.WorkoutExerciseID != weID {
+		t.Errorf("expected the squat set to keep 5 reps, got %+v", set)
+	}
+}
+
+func TestHandleSyncDeleteSet(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	workoutID, _ := workouts.Create(app.DB, "Workout", time.Now(), nil)
+	exerciseID, _ := exercises.Create(app.DB, "Squat")
+	weID, _ := workouts.AddExercise(app.DB, workoutID, exerciseID)
+	we := strconv.FormatInt(weID, 10)
+	earlier := strconv.FormatInt(time.Now().Add(-time.Hour).UnixMilli(), 10)
+
+	setID, _ := workouts.AddSet(app.DB, weID, 5, 225)
+	set, _ := workouts.GetSetByID(app.DB, setID)
+
+	// Deleting is idempotent
+	for range 2 {
+		resp := app.Request(http.MethodDelete, "/workouts/sync/sets/"+set.ClientID, "")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+	}
+
+	// A replayed change made before the deletion can't bring the set back
+	resp := app.Request(http.MethodPut, "/workouts/sync/sets/"+set.ClientID,
+		"workout_exercise_id="+we+"&reps=5&weight=225&updated_at="+earlier)
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected status 409, got %d", resp.StatusCode)
+	}
+	if sets, _ := workouts.GetLoggedSets(app.DB, weID); len(sets) != 0 {
+		t.Errorf("expected the set to stay deleted, got %d sets", len(sets))
+	}
+}
+
+func TestHandleSyncSet_FinishedWorkout(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	workoutID, _ := workouts.Create(app.DB, "Workout", time.Now(), nil)
+	exerciseID, _ := exercises.Create(app.DB, "Squat")
+	weID, _ := workouts.AddExercise(app.DB, workoutID, exerciseID)
+	workouts.Finish(app.DB, workoutID)
+
+	resp := app.Request(http.MethodPut, "/workouts/sync/sets/def456",
+		"workout_exercise_id="+strconv.FormatInt(weID, 10)+"&reps=5&weight=225")
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected status 409, got %d", resp.StatusCode)
+	}
+	if body := testutil.ReadBody(t, resp); !strings.Contains(body, `"status":"rejected"`) {
+		t.Errorf("expected rejected status, got %s", body)
+	}
+}
+
+func TestHandleSyncSet_OtherExercise(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	workoutID, _ := workouts.Create(app.DB, "Workout", time.Now(), nil)
+	squatID, _ := exercises.Create(app.DB, "Squat")
+	benchID, _ := exercises.Create(app.DB, "Bench Press")
+	squatWE, _ := workouts.AddExercise(app.DB, workoutID, squatID)
+	benchWE, _ := workouts.AddExercise(app.DB, workoutID, benchID)
+
+	resp := app.Request(http.MethodPut, "/workouts/sync/sets/abc123",
+		"workout_exercise_id="+strconv.FormatInt(squatWE, 10)+"&reps=5&weight=225")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	// Replaying the client ID against another exercise leaves the squat set alone
+	resp = app.Request(http.MethodPut, "/workouts/sync/sets/abc123",
+		"workout_exercise_id="+strconv.FormatInt(benchWE, 10)+"&reps=8&weight=135")
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected status 409, got %d", resp.StatusCode)
+	}
+	if body := testutil.ReadBody(t, resp); !strings.Contains(body, `"status":"rejected"`) {
+		t.Errorf("expected rejected status, got %s", body)
+	}
+
+	set, _ := workouts.GetSetByClientID(app.DB, "abc123")
+	if set.WorkoutExerciseID != squatWE || set.Reps != 5 || set.Weight != 225 {
+		t.Errorf("expected the squat set to keep 5 x 225, got %+v", set)
+	}
+	if sets, _ := workouts.GetLoggedSets(app.DB, benchWE); len(sets) != 0 {
+		t.Errorf("expected no bench sets, got %d", len(sets))
+	}
+}
+
+func TestIdempotencyKey_ReplaysAddSet(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	workoutID, _ := workouts.Create(app.DB, "Workout", time.Now(), nil)
+	exerciseID, _ := exercises.Create(app.DB, "Squat")
+	weID, _ := workouts.AddExercise(app.DB, workoutID, exerciseID)
+	path := "/workouts/exercises/" + strconv.FormatInt(weID, 10) + "/sets"
+
+	var bodies []string
+	for range 2 {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("reps=5&weight=225"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("HX-Request", "true")
+		req.Header.Set(middleware.IdempotencyHeader, "tap-1")
+		resp, err := app.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+		bodies = append(bodies, testutil.ReadBody(t, resp))
+		if len(bodies) == 2 && resp.Header.Get("Idempotent-Replayed") != "true" {
+			t.Error("expected the second response to be replayed")
+		}
+	}
+
+	if bodies[0] != bodies[1] {
+		t.Error("expected the replayed response to match the original")
+	}
+	if sets, _ := workouts.GetLoggedSets(app.DB, weID); len(sets) != 1 {
+		t.Errorf("expected 1 set, got %d", len(sets))
+	}
+}
+
+func TestIdempotencyKey_FormField(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	var locations []string
+	for range 2 {
+		resp := app.Request(http.MethodPost, "/workouts", "name=Legs&date=2024-01-15&idempotency_key=create-1")
+		if resp.StatusCode != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d", resp.StatusCode)
+		}
+		locations = append(locations, resp.Header.Get("Location"))
+	}
+	if locations[0] != locations[1] {
+		t.Errorf("expected the replay to redirect to the same workout, got %v", locations)
+	}
+	if list, _ := workouts.ListInProgress(app.DB); len(list) != 1 {
+		t.Errorf("expected 1 workout, got %d", len(list))
+	}
+
+	// Keys are scoped to the request, so the same key elsewhere is a new request
+	resp := app.HTMXRequest(http.MethodPost, "/exercises", "name=Bench&idempotency_key=create-1")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Idempotent-Replayed") != "" {
+		t.Errorf("expected the exercise to be created, got status %d", resp.StatusCode)
+	}
+	if list, _ := exercises.ListAll(app.DB); len(list) != 1 {
+		t.Errorf("expected 1 exercise, got %d", len(list))
+	}
+}
+
 func TestMultipleSetsWithPositions(t *testing.T) {
 	t.Parallel()
 	app := testutil.NewTestApp(t)
@@ -478,3 +1443,144 @@ func TestMultipleSetsWithPositions(t *testing.T) {
 		t.Errorf("expected position 3, got %d", we.Sets[2].Position)
 	}
 }
+
+func TestLive_AddSetPushesCardToOtherPages(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	workoutID, _ := workouts.Create(app.DB, "Workout", time.Now(), nil)
+	exerciseID, _ := exercises.Create(app.DB, "Squat")
+	weID, _ := workouts.AddExercise(app.DB, workoutID, exerciseID)
+	topic := "workout:" + strconv.FormatInt(workoutID, 10)
+
+	tablet, unsubscribeTablet := app.Broker.Subscribe(topic, "tablet")
+	defer unsubscribeTablet()
+	phone, unsubscribePhone := app.Broker.Subscribe(topic, "phone")
+	defer unsubscribePhone()
+
+	req := httptest.NewRequest(http.MethodPost, "/workouts/exercises/"+strconv.FormatInt(weID, 10)+"/sets", strings.NewReader("reps=5&weight=225"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("HX-Request", "true")
+	req.Header.Set("X-Live-Client", "phone")
+	resp, err := app.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	select {
+	case msg := <-tablet:
+		if msg.Event != "exercise-"+strconv.FormatInt(weID, 10) {
+			t.Errorf("expected exercise event, got %q", msg.Event)
+		}
+		if !strings.Contains(msg.Data, "225") {
+			t.Error("expected the pushed card to include the new set")
+		}
+	default:
+		t.Fatal("expected the tablet to receive the updated card")
+	}
+
+	select {
+	case msg := <-phone:
+		t.Errorf("expected the page making the change to be skipped, got %q", msg.Event)
+	default:
+	}
+}
+
+func TestLive_FinishPushesWorkout(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	workoutID, _ := workouts.Create(app.DB, "Workout", time.Now(), nil)
+	messages, unsubscribe := app.Broker.Subscribe("workout:"+strconv.FormatInt(workoutID, 10), "tablet")
+	defer unsubscribe()
+
+	resp := app.HTMXRequest("POST", "/workouts/"+strconv.FormatInt(workoutID, 10)+"/finish", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	select {
+	case msg := <-messages:
+		if msg.Event != "workout" || !strings.Contains(msg.Data, "Completed") {
+			t.Errorf("expected the finished workout to be pushed, got %q", msg.Event)
+		}
+	default:
+		t.Fatal("expected a workout update")
+	}
+}
+
+func TestLive_DetailPageConnects(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	workoutID, _ := workouts.Create(app.DB, "Workout", time.Now(), nil)
+	path := "/workouts/" + strconv.FormatInt(workoutID, 10)
+
+	body := testutil.ReadBody(t, app.Request("GET", path, ""))
+	if !strings.Contains(body, `sse-connect="`+path+`/events?client=`) {
+		t.Error("expected an in-progress workout to subscribe to live updates")
+	}
+
+	workouts.Finish(app.DB, workoutID)
+	body = testutil.ReadBody(t, app.Request("GET", path, ""))
+	if strings.Contains(body, "sse-connect") {
+		t.Error("expected a finished workout not to subscribe")
+	}
+}
+
+func TestWorkoutWebhookEvents(t *testing.T) {
+	t.Parallel()
+	app := testutil.NewTestApp(t)
+	defer app.Close()
+
+	webhooks.Create(app.DB, "https://example.com/hook", "s", webhooks.Events)
+	exerciseID, _ := exercises.Create(app.DB, "Squat")
+
+	// An earlier session so the new one sets records
+	earlierID, _ := workouts.Create(app.DB, "Earlier", time.Now().AddDate(0, 0, -7), nil)
+	earlierWE, _ := workouts.AddExercise(app.DB, earlierID, exerciseID)
+	workouts.AddSet(app.DB, earlierWE, 5, 200)
+	workouts.Finish(app.DB, earlierID)
+
+	resp := app.Request("POST", "/workouts", "name=Leg+Day&date="+time.Now().Format("2006-01-02"))
+	if resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("expected status 303, got %d", resp.StatusCode)
+	}
+	id, _ := strconv.ParseInt(strings.TrimPrefix(resp.Header.Get("Location"), "/workouts/"), 10, 64)
+	weID, _ := workouts.AddExercise(app.DB, id, exerciseID)
+
+	path := "/workouts/exercises/" + strconv.FormatInt(weID, 10) + "/sets"
+	app.HTMXRequest("POST", path, "reps=5&weight=225&client_id=5e7-1")
+	app.HTMXRequest("POST", path, "reps=5&weight=225&client_id=5e7-1") // resubmitted
+	app.HTMXRequest("POST", "/workouts/"+strconv.FormatInt(id, 10)+"/finish", "")
+	app.HTMXRequest("POST", "/workouts/"+strconv.FormatInt(id, 10)+"/finish", "") // already finished
+
+	deliveries, _ := webhooks.ListDeliveries(app.DB, 0, 20)
+	counts := make(map[webhooks.Event]int)
+	for _, d := range deliveries {
+		counts[d.Event]++
+	}
+	want := map[webhooks.Event]int{
+		webhooks.EventWorkoutCreated:  1,
+		webhooks.EventSetLogged:       1,
+		webhooks.EventWorkoutFinished: 1,
+		webhooks.EventPRAchieved:      2, // Heaviest weight and best e1RM
+	}
+	for event, n := range want {
+		if counts[event] != n {
+			t.Errorf("expected %d %s deliveries, got %d", n, event, counts[event])
+		}
+	}
+
+	for _, d := range deliveries {
+		if d.Event == webhooks.EventWorkoutFinished && !strings.Contains(d.Payload, `"set_count":1`) {
+			t.Errorf("expected the finished payload to carry the totals, got %s", d.Payload)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package workouts
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"phobos/internal/features/exercises"
+	"phobos/internal/features/plates"
+	"phobos/internal/shared/middleware"
+	"phobos/internal/shared/pubsub"
+
+	"github.com/a-h/templ"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Live updates keep a workout open on several devices in step. Each open
+// page subscribes to its workout's topic over Server-Sent Events, and every
+// change made through the handlers pushes the affected fragment to the other
+// pages, which swap it in with htmx's SSE extension. The page that made the
+// change sends its live client ID with each request and is skipped, since its
+// own response already updated it.
+
+const (
+	// liveClientHeader carries the live client ID of the page making a change
+	liveClientHeader = "X-Live-Client"
+	// liveHeartbeat is how often an idle stream is pinged, so dead connections
+	// are noticed and proxies don't time them out
+	liveHeartbeat = 20 * time.Second
+)
+
+func liveTopic(workoutID int64) string {
+	return "workout:" + strconv.FormatInt(workoutID, 10)
+}
+
+// exerciseEvent is the event name for changes to one exercise card
+func exerciseEvent(workoutExerciseID int64) string {
+	return "exercise-" + strconv.FormatInt(workoutExerciseID, 10)
+}
+
+// HandleEvents streams live updates for a workout until the client disconnects
+func HandleEvents(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	clientID := c.Query("client")
+	if clientID != "" && !validClientID(clientID) {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid client ID")
+	}
+
+	messages, unsubscribe := middleware.GetBroker(c).Subscribe(liveTopic(id), clientID)
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		heartbeat := time.NewTicker(liveHeartbeat)
+		defer heartbeat.Stop()
+
+		fmt.Fprint(w, ": connected\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				writeEvent(w, msg)
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": ping\n\n")
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// writeEvent writes a message in the event stream format, one data line per
+// line of the fragment
+func writeEvent(w *bufio.Writer, msg pubsub.Message) {
+	fmt.Fprintf(w, "event: %s\n", msg.Event)
+	for _, line := range strings.Split(msg.Data, "\n") {
+		fmt.Fprintf(w, "data: %s\n", line)
+	}
+	fmt.Fprint(w, "\n")
+}
+
+// hasLiveSubscribers reports whether any other page is showing the workout, so
+// handlers can skip loading and rendering fragments nobody will receive
+func hasLiveSubscribers(c *fiber.Ctx, workoutID int64) bool {
+	return middleware.GetBroker(c).HasSubscribers(liveTopic(workoutID), c.Get(liveClientHeader))
+}
+
+// publishFailed logs that a live update couldn't be built. Like webhooks,
+// live updates are best effort and never fail the request.
+func publishFailed(workoutID int64, event string, err error) {
+	slog.Warn("Failed to publish live update", "workout_id", workoutID, "event", event, "error", err)
+}
+
+// publish renders a fragment and pushes it to the other pages showing the workout
+func publish(c *fiber.Ctx, workoutID int64, event string, component templ.Component) {
+	var buf strings.Builder
+	if err := component.Render(c.Context(), &buf); err != nil {
+		publishFailed(workoutID, event, err)
+		return
+	}
+	middleware.GetBroker(c).Publish(liveTopic(workoutID), pubsub.Message{Event: event, Data: buf.String()}, c.Get(liveClientHeader))
+}
+
+// publishWorkout pushes the whole workout, for changes to its details or
+// status. A deleted workout is replaced with a notice.
+func publishWorkout(c *fiber.Ctx, workoutID int64) {
+	if !hasLiveSubscribers(c, workoutID) {
+		return
+	}
+	db := middleware.GetDB(c)
+
+	w, err := GetByID(db, workoutID)
+	if err != nil {
+		publishFailed(workoutID, "workout", err)
+		return
+	}
+	if w == nil {
+		publish(c, workoutID, "workout", WorkoutDeleted())
+		return
+	}
+
+	allExercises, err := exercises.ListAll(db)
+	if err != nil {
+		publishFailed(workoutID, "workout", err)
+		return
+	}
+	kit, err := plates.GetKit(db)
+	if err != nil {
+		publishFailed(workoutID, "workout", err)
+		return
+	}
+
+	publish(c, workoutID, "workout", WorkoutDetail(w, allExercises, kit))
+}
+
+// publishExercise pushes an exercise card as it now stands, after its sets change
+func publishExercise(c *fiber.Ctx, workoutID, workoutExerciseID int64) {
+	publishExerciseCard(c, workoutID, workoutExerciseID, exerciseEvent(workoutExerciseID))
+}
+
+// publishSetChange pushes the card of the exercise a set belongs to, for
+// handlers that only know the set
+func publishSetChange(c *fiber.Ctx, workoutExerciseID int64) {
+	workoutID, err := GetExerciseWorkoutID(middleware.GetDB(c), workoutExerciseID)
+	if err != nil {
+		publishFailed(workoutID, exerciseEvent(workoutExerciseID), err)
+		return
+	}
+	if workoutID == 0 {
+		return
+	}
+	publishExercise(c, workoutID, workoutExerciseID)
+}
+
+// publishExerciseAdded pushes a card for an exercise added to the workout
+func publishExerciseAdded(c *fiber.Ctx, workoutID, workoutExerciseID int64) {
+	publishExerciseCard(c, workoutID, workoutExerciseID, "exercise-added")
+}
+
+// publishExerciseRemoved tells other pages to drop an exercise card. Event
+// streams skip messages without data, so the ID is sent as the payload.
+func publishExerciseRemoved(c *fiber.Ctx, workoutID, workoutExerciseID int64) {
+	if !hasLiveSubscribers(c, workoutID) {
+		return
+	}
+	id := strconv.FormatInt(workoutExerciseID, 10)
+	middleware.GetBroker(c).Publish(liveTopic(workoutID), pubsub.Message{
+		Event: exerciseEvent(workoutExerciseID) + "-removed",
+		Data:  id,
+	}, c.Get(liveClientHeader))
+}
+
+func publishExerciseCard(c *fiber.Ctx, workoutID, workoutExerciseID int64, event string) {
+	if !hasLiveSubscribers(c, workoutID) {
+		return
+	}
+	db := middleware.GetDB(c)
+
+	w, err := GetByID(db, workoutID)
+	if err != nil {
+		publishFailed(workoutID, event, err)
+		return
+	}
+	we, err := GetWorkoutExerciseByID(db, workoutExerciseID)
+	if err != nil {
+		publishFailed(workoutID, event, err)
+		return
+	}
+	// Nothing to show if the workout or exercise was deleted meanwhile
+	if w == nil || we == nil {
+		return
+	}
+	kit, err := plates.GetKit(db)
+	if err != nil {
+		publishFailed(workoutID, event, err)
+		return
+	}
+
+	publish(c, workoutID, event, WorkoutExerciseCard(*we, w.IsFinished(), w.TemplateID, w.Bodyweight, kit))
+}
@@ -1,7 +1,12 @@
 package workouts
 
 import (
+	"fmt"
+	"net/url"
 	"phobos/internal/features/exercises"
+	"phobos/internal/shared/dates"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -23,7 +28,32 @@ type Workout struct {
 	TemplateID *int64
 	CreatedAt  time.Time
 	FinishedAt *time.Time
+	StartedAt  *time.Time    // When the session began; editable for workouts logged after the fact
+	EndedAt    *time.Time    // When the session ended; set on finish unless entered
+	PausedAt   *time.Time    // Set while an in-progress session is paused
+	PausedFor  time.Duration // Total time spent paused before the current pause
+	Bodyweight *float64      // Latest logged bodyweight on or before the workout date
 	Exercises  []WorkoutExercise
+
+	TemplateVersion *TemplateVersionRef // Template version the workout was started from, if known
+	Comments        []Comment           // Coach feedback, oldest first
+}
+
+// Comment is a coach's feedback on a workout
+type Comment struct {
+	ID        int64
+	WorkoutID int64
+	CoachName string
+	Body      string
+	CreatedAt time.Time
+}
+
+// TemplateVersionRef identifies a template version. It outlives the template,
+// so the workout can still link to it after the template is deleted.
+type TemplateVersionRef struct {
+	ID         int64
+	TemplateID int64
+	Version    int
 }
 
 // IsFinished returns true if the workout is finished
@@ -31,6 +61,31 @@ func (w *Workout) IsFinished() bool {
 	return w.Status == StatusFinished
 }
 
+// IsPaused returns true if the session is currently paused
+func (w *Workout) IsPaused() bool {
+	return w.PausedAt != nil
+}
+
+// Duration returns the time trained: from start to end (or now, or the
+// current pause for unfinished sessions), minus time spent paused
+func (w *Workout) Duration(now time.Time) time.Duration {
+	if w.StartedAt == nil {
+		return 0
+	}
+	end := now
+	switch {
+	case w.EndedAt != nil:
+		end = *w.EndedAt
+	case w.PausedAt != nil:
+		end = *w.PausedAt
+	}
+	d := end.Sub(*w.StartedAt) - w.PausedFor
+	if d < 0 {
+		return 0
+	}
+	return d.Round(time.Second)
+}
+
 // WorkoutExercise represents an exercise in a workout
 type WorkoutExercise struct {
 	ID         int64
@@ -40,8 +95,106 @@ type WorkoutExercise struct {
 	Position   int
 	Sets       []LoggedSet
 	LastWeight *float64 // Most recent weight used for this exercise
-	TargetSets *int     // From template, if applicable
-	TargetReps *int     // From template, if applicable
+
+	TemplateExerciseID *int64 // Template entry the exercise was created from, if any
+	TargetSets         *int   // Copied from the template entry when the workout was created
+	TargetReps         *int   // Copied from the template entry when the workout was created
+
+	UsesBodyweight bool // Exercise is tagged with bodyweight equipment
+	UsesBarbell    bool // Exercise is tagged with barbell equipment
+
+	Previous *PreviousSession // Last finished session of this exercise, if any
+}
+
+// PreviousSession holds the sets logged for an exercise in an earlier finished
+// workout, preferring the same template when the workout has one
+type PreviousSession struct {
+	WorkoutID int64
+	Date      time.Time
+	Sets      []LoggedSet
+}
+
+// PreviousSet returns the previous session's set aligned with the set at index i
+func (we WorkoutExercise) PreviousSet(i int) *LoggedSet {
+	if we.Previous == nil || i < 0 || i >= len(we.Previous.Sets) {
+		return nil
+	}
+	return &we.Previous.Sets[i]
+}
+
+// PendingPrevious returns the previous session's sets not yet matched by a current set
+func (we WorkoutExercise) PendingPrevious() []LoggedSet {
+	if we.Previous == nil || len(we.Previous.Sets) <= len(we.Sets) {
+		return nil
+	}
+	return we.Previous.Sets[len(we.Sets):]
+}
+
+// SetIndex returns the index of a set within the exercise's sets, or -1
+func (we WorkoutExercise) SetIndex(setID int64) int {
+	for i, s := range we.Sets {
+		if s.ID == setID {
+			return i
+		}
+	}
+	return -1
+}
+
+// WorkingSets returns the sets that are not warm-ups
+func (we WorkoutExercise) WorkingSets() []LoggedSet {
+	var sets []LoggedSet
+	for _, s := range we.Sets {
+		if !s.IsWarmup() {
+			sets = append(sets, s)
+		}
+	}
+	return sets
+}
+
+// SetSpan returns the time from the first to the last logged working set
+func (we WorkoutExercise) SetSpan() time.Duration {
+	sets := we.WorkingSets()
+	if len(sets) < 2 {
+		return 0
+	}
+	first, last := sets[0].CreatedAt, sets[0].CreatedAt
+	for _, s := range sets[1:] {
+		if s.CreatedAt.Before(first) {
+			first = s.CreatedAt
+		}
+		if s.CreatedAt.After(last) {
+			last = s.CreatedAt
+		}
+	}
+	return last.Sub(first)
+}
+
+// AverageRest returns the mean time between consecutive logged working sets
+func (we WorkoutExercise) AverageRest() time.Duration {
+	sets := we.WorkingSets()
+	if len(sets) < 2 {
+		return 0
+	}
+	return (we.SetSpan() / time.Duration(len(sets)-1)).Round(time.Second)
+}
+
+// TopWeight returns the heaviest working weight logged for the exercise
+func (we WorkoutExercise) TopWeight() float64 {
+	top := 0.0
+	for _, s := range we.WorkingSets() {
+		top = max(top, s.Weight)
+	}
+	return top
+}
+
+// FirstWorkingWeight returns the weight of the first working set, or 0 if none is logged
+func (we WorkoutExercise) FirstWorkingWeight() float64 {
+	for _, s := range we.WorkingSets() {
+		if s.Weight > 0 {
+			return s.Weight
+		}
+	}
+	return 0
 }
 
 // LoggedSet represents an individual set performed
@@ -51,9 +204,25 @@ type LoggedSet struct {
 	Reps              int
 	Weight            float64
 	Position          int
+	Type              SetType
+	ClientID          string // Generated by the client that logged the set, for offline replay
 	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// IsWarmup returns true for warm-up sets, which don't count toward volume or records
+func (s LoggedSet) IsWarmup() bool {
+	return s.Type == SetWarmup
 }
 
+// SetType distinguishes warm-up sets from working sets
+type SetType string
+
+const (
+	SetWorking SetType = "working"
+	SetWarmup  SetType = "warmup"
+)
+
 // WorkoutSummary is a condensed view for listing workouts
 type WorkoutSummary struct {
 	ID            int64
@@ -62,4 +231,156 @@ type WorkoutSummary struct {
 	Status        WorkoutStatus
 	ExerciseCount int
 	SetCount      int
+	Stats         *SummaryStats // Stored when the workout was finished
+}
+
+// SummaryStats are the totals of a finished workout
+type SummaryStats struct {
+	Duration     time.Duration // From creation to finish
+	TotalVolume  float64       // Sum of reps x weight
+	SetCount     int
+	RepCount     int
+	PRCount      int
+	TargetsMet   int // Exercises that met their template targets
+	TargetsTotal int // Exercises that had template targets
+}
+
+// PRKind names the kind of personal record a set beat
+type PRKind string
+
+const (
+	PRWeight PRKind = "Heaviest weight"
+	PRE1RM   PRKind = "Best estimated 1RM"
+)
+
+// PR is a personal record set during a workout
+type PR struct {
+	Exercise string
+	Kind     PRKind
+	Value    float64
+	Previous float64
+}
+
+// ExerciseResult is how one exercise went in a finished workout
+type ExerciseResult struct {
+	Name       string
+	SetCount   int
+	RepCount   int
+	Volume     float64
+	TopWeight  float64
+	TargetSets *int
+	TargetReps *int
+	TargetsMet bool // Whether at least TargetSets sets reached TargetReps
+}
+
+// HasTargets returns true if the exercise came from a template with targets
+func (r ExerciseResult) HasTargets() bool {
+	return r.TargetSets != nil && r.TargetReps != nil
+}
+
+// SessionComparison is a previous workout from the same template
+type SessionComparison struct {
+	WorkoutID   int64
+	Date        time.Time
+	Duration    time.Duration
+	TotalVolume float64
+	SetCount    int
+	RepCount    int
+}
+
+// FinishSummary describes a finished workout for the summary screen
+type FinishSummary struct {
+	SummaryStats
+	Workout   *Workout
+	Exercises []ExerciseResult
+	PRs       []PR
+	Previous  *SessionComparison // nil without a template or an earlier session
+}
+
+// HistoryFilter narrows the workout history. Zero values mean "any".
+type HistoryFilter struct {
+	Query      string
+	From       *time.Time
+	To         *time.Time
+	TemplateID int64
+	RoutineID  int64
+	ExerciseID int64
+}
+
+// IsEmpty returns true if no filter is applied
+func (f HistoryFilter) IsEmpty() bool {
+	return f.Query == "" && f.From == nil && f.To == nil &&
+		f.TemplateID == 0 && f.RoutineID == 0 && f.ExerciseID == 0
+}
+
+// Values encodes the filter as query parameters, so further pages keep it
+func (f HistoryFilter) Values() url.Values {
+	v := url.Values{}
+	if f.Query != "" {
+		v.Set("q", f.Query)
+	}
+	if f.From != nil {
+		v.Set("from", f.From.Format(dates.Layout))
+	}
+	if f.To != nil {
+		v.Set("to", f.To.Format(dates.Layout))
+	}
+	if f.TemplateID != 0 {
+		v.Set("template_id", strconv.FormatInt(f.TemplateID, 10))
+	}
+	if f.RoutineID != 0 {
+		v.Set("routine_id", strconv.FormatInt(f.RoutineID, 10))
+	}
+	if f.ExerciseID != 0 {
+		v.Set("exercise_id", strconv.FormatInt(f.ExerciseID, 10))
+	}
+	return v
+}
+
+// HistoryCursor points at the last workout of a history page.
+// History is ordered by date then ID, newest first.
+type HistoryCursor struct {
+	Date time.Time
+	ID   int64
+}
+
+// String encodes the cursor as "2006-01-02_<id>"
+func (c HistoryCursor) String() string {
+	return c.Date.Format(dates.Layout) + "_" + strconv.FormatInt(c.ID, 10)
+}
+
+// ParseHistoryCursor decodes a cursor produced by HistoryCursor.String
+func ParseHistoryCursor(s string) (*HistoryCursor, error) {
+	date, id, ok := strings.Cut(s, "_")
+	if !ok {
+		return nil, fmt.Errorf("invalid cursor %q", s)
+	}
+	d, err := time.Parse(dates.Layout, date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor date: %w", err)
+	}
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor id: %w", err)
+	}
+	return &HistoryCursor{Date: d, ID: n}, nil
+}
+
+// HistoryResult is one page of workout history
+type HistoryResult struct {
+	Workouts []WorkoutSummary
+	Next     *HistoryCursor // nil on the last page
+}
+
+// FilterOption is a selectable value in the history filters
+type FilterOption struct {
+	ID   int64
+	Name string
+}
+
+// HistoryFilterOptions lists the values offered by the history filters
+type HistoryFilterOptions struct {
+	Templates []FilterOption
+	Routines  []FilterOption
+	Exercises []FilterOption
 }
@@ -2,19 +2,228 @@ package workouts
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
+
+	"phobos/internal/features/metrics"
+	"phobos/internal/features/plates"
+	"phobos/internal/features/search"
+	"phobos/internal/features/strength"
+	"phobos/internal/shared/dates"
 )
 
+// querier is a *sql.DB or a *sql.Tx, so reads can run inside a transaction
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// ErrEndBeforeStart is returned when a session would end before it started
+var ErrEndBeforeStart = errors.New("workout ends before it starts")
+
+// ErrFinished is returned when editing a finished workout, which is read-only
+var ErrFinished = errors.New("workout is finished")
+
 // ListInProgress returns all in-progress workouts
 func ListInProgress(db *sql.DB) ([]WorkoutSummary, error) {
 	return listByStatus(db, StatusInProgress)
 }
 
-// ListFinished returns all finished workouts (newest first)
-func ListFinished(db *sql.DB) ([]WorkoutSummary, error) {
-	return listByStatus(db, StatusFinished)
+// ListRecent returns the most recent finished workouts, up to limit
+func ListRecent(db *sql.DB, limit int) ([]WorkoutSummary, error) {
+	result, err := ListHistory(db, HistoryFilter{}, nil, limit)
+	if err != nil {
+		return nil, err
+	}
+	return result.Workouts, nil
+}
+
+// ListHistory returns a page of finished workouts (newest first) matching the filter.
+// Pages are keyset-paginated: pass the previous page's Next cursor to continue.
+func ListHistory(db *sql.DB, filter HistoryFilter, after *HistoryCursor, limit int) (*HistoryResult, error) {
+	conditions := []string{"w.status = ?"}
+	args := []interface{}{StatusFinished}
+
+	if after != nil {
+		date := after.Date.Format(dates.Layout)
+		conditions = append(conditions, "(w.date < ? OR (w.date = ? AND w.id < ?))")
+		args = append(args, date, date, after.ID)
+	}
+	if match := search.MatchExpression(filter.Query); match != "" {
+		// Every word must start a word of the workout's name or notes, or of
+		// the name of one of its exercises
+		conditions = append(conditions, `(w.id IN (
+			SELECT ref_id FROM search_index WHERE search_index MATCH ? AND kind = 'workout'
+		) OR EXISTS (
+			SELECT 1 FROM workout_exercises fwe
+			WHERE fwe.workout_id = w.id AND fwe.exercise_id IN (
+				SELECT ref_id FROM search_index WHERE search_index MATCH ? AND kind = 'exercise'
+			)
+		))`)
+		args = append(args, match, "title : ("+match+")")
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "w.date >= ?")
+		args = append(args, filter.From.Format(dates.Layout))
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "w.date <= ?")
+		args = append(args, filter.To.Format(dates.Layout))
+	}
+	if filter.TemplateID != 0 {
+		conditions = append(conditions, "w.template_id = ?")
+		args = append(args, filter.TemplateID)
+	}
+	if filter.RoutineID != 0 {
+		conditions = append(conditions, "w.template_id IN (SELECT template_id FROM routine_templates WHERE routine_id = ?)")
+		args = append(args, filter.RoutineID)
+	}
+	if filter.ExerciseID != 0 {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM workout_exercises fwe WHERE fwe.workout_id = w.id AND fwe.exercise_id = ?)")
+		args = append(args, filter.ExerciseID)
+	}
+
+	// Fetch one extra row to know whether there is another page
+	args = append(args, limit+1)
+	query := fmt.Sprintf(`
+		SELECT w.id, w.name, w.date, w.status,
+		       COUNT(DISTINCT we.id) as exercise_count,
+		       COUNT(ls.id) as set_count,
+		       ws.duration_seconds, ws.total_volume, ws.rep_count, ws.pr_count,
+		       ws.targets_met, ws.targets_total
+		FROM workouts w
+		LEFT JOIN workout_exercises we ON we.workout_id = w.id
+		LEFT JOIN logged_sets ls ON ls.workout_exercise_id = we.id
+		LEFT JOIN workout_summaries ws ON ws.workout_id = w.id
+		WHERE %s
+		GROUP BY w.id, w.name, w.date, w.status
+		ORDER BY w.date DESC, w.id DESC
+		LIMIT ?
+	`, strings.Join(conditions, " AND "))
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workout history: %w", err)
+	}
+	defer rows.Close()
+
+	var result HistoryResult
+	for rows.Next() {
+		var w WorkoutSummary
+		var duration, repCount, prCount, targetsMet, targetsTotal sql.NullInt64
+		var volume sql.NullFloat64
+		if err := rows.Scan(
+			&w.ID, &w.Name, &w.Date, &w.Status, &w.ExerciseCount, &w.SetCount,
+			&duration, &volume, &repCount, &prCount, &targetsMet, &targetsTotal,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan workout: %w", err)
+		}
+		if duration.Valid {
+			w.Stats = &SummaryStats{
+				Duration:     time.Duration(duration.Int64) * time.Second,
+				TotalVolume:  volume.Float64,
+				SetCount:     w.SetCount,
+				RepCount:     int(repCount.Int64),
+				PRCount:      int(prCount.Int64),
+				TargetsMet:   int(targetsMet.Int64),
+				TargetsTotal: int(targetsTotal.Int64),
+			}
+		}
+		result.Workouts = append(result.Workouts, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(result.Workouts) > limit {
+		result.Workouts = result.Workouts[:limit]
+		last := result.Workouts[limit-1]
+		result.Next = &HistoryCursor{Date: last.Date, ID: last.ID}
+	}
+
+	return &result, nil
+}
+
+// ListHistoryFilterOptions returns the templates, routines and performed exercises to filter history by
+func ListHistoryFilterOptions(db *sql.DB) (*HistoryFilterOptions, error) {
+	var opts HistoryFilterOptions
+	var err error
+
+	opts.Templates, err = listFilterOptions(db, `SELECT id, name FROM workout_templates ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list template options: %w", err)
+	}
+
+	opts.Routines, err = listFilterOptions(db, `SELECT id, name FROM routines ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list routine options: %w", err)
+	}
+
+	opts.Exercises, err = listFilterOptions(db, `
+		SELECT DISTINCT e.id, e.name
+		FROM exercises e
+		JOIN workout_exercises we ON we.exercise_id = e.id
+		JOIN workouts w ON w.id = we.workout_id
+		WHERE w.status = ?
+		ORDER BY e.name
+	`, StatusFinished)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exercise options: %w", err)
+	}
+
+	return &opts, nil
+}
+
+func listFilterOptions(db *sql.DB, query string, args ...interface{}) ([]FilterOption, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var options []FilterOption
+	for rows.Next() {
+		var o FilterOption
+		if err := rows.Scan(&o.ID, &o.Name); err != nil {
+			return nil, err
+		}
+		options = append(options, o)
+	}
+
+	return options, rows.Err()
+}
+
+// ListOnDate returns all workouts (in progress and finished) on a calendar day
+func ListOnDate(db *sql.DB, date time.Time) ([]WorkoutSummary, error) {
+	rows, err := db.Query(`
+		SELECT w.id, w.name, w.date, w.status,
+		       COUNT(DISTINCT we.id) as exercise_count,
+		       COUNT(ls.id) as set_count
+		FROM workouts w
+		LEFT JOIN workout_exercises we ON we.workout_id = w.id
+		LEFT JOIN logged_sets ls ON ls.workout_exercise_id = we.id
+		WHERE w.date = ?
+		GROUP BY w.id, w.name, w.date, w.status, w.created_at
+		ORDER BY w.created_at ASC
+	`, date.Format(dates.Layout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workouts: %w", err)
+	}
+	defer rows.Close()
+
+	var workouts []WorkoutSummary
+	for rows.Next() {
+		var w WorkoutSummary
+		if err := rows.Scan(&w.ID, &w.Name, &w.Date, &w.Status, &w.ExerciseCount, &w.SetCount); err != nil {
+			return nil, fmt.Errorf("failed to scan workout: %w", err)
+		}
+		workouts = append(workouts, w)
+	}
+
+	return workouts, rows.Err()
 }
 
 func listByStatus(db *sql.DB, status WorkoutStatus) ([]WorkoutSummary, error) {
@@ -47,17 +256,24 @@ func listByStatus(db *sql.DB, status WorkoutStatus) ([]WorkoutSummary, error) {
 }
 
 // GetByID returns a single workout with all exercises and sets
-func GetByID(db *sql.DB, id int64) (*Workout, error) {
+func GetByID(db querier, id int64) (*Workout, error) {
 	var w Workout
 	var notes sql.NullString
 	var templateID sql.NullInt64
-	var finishedAt sql.NullTime
+	var finishedAt, startedAt, endedAt, pausedAt sql.NullTime
+	var pausedSeconds int64
+	var versionID, versionTemplateID, version sql.NullInt64
 
 	err := db.QueryRow(`
-		SELECT id, name, date, notes, status, template_id, created_at, finished_at
-		FROM workouts
-		WHERE id = ?
-	`, id).Scan(&w.ID, &w.Name, &w.Date, &notes, &w.Status, &templateID, &w.CreatedAt, &finishedAt)
+		SELECT w.id, w.name, w.date, w.notes, w.status, w.template_id, w.created_at, w.finished_at,
+		       w.started_at, w.ended_at, w.paused_at, w.paused_seconds,
+		       tv.id, tv.template_id, tv.version
+		FROM workouts w
+		LEFT JOIN template_versions tv ON w.template_version_id = tv.id
+		WHERE w.id = ?
+	`, id).Scan(&w.ID, &w.Name, &w.Date, &notes, &w.Status, &templateID, &w.CreatedAt, &finishedAt,
+		&startedAt, &endedAt, &pausedAt, &pausedSeconds,
+		&versionID, &versionTemplateID, &version)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -73,6 +289,28 @@ func GetByID(db *sql.DB, id int64) (*Workout, error) {
 	if finishedAt.Valid {
 		w.FinishedAt = &finishedAt.Time
 	}
+	if startedAt.Valid {
+		w.StartedAt = &startedAt.Time
+	}
+	if endedAt.Valid {
+		w.EndedAt = &endedAt.Time
+	}
+	if pausedAt.Valid {
+		w.PausedAt = &pausedAt.Time
+	}
+	w.PausedFor = time.Duration(pausedSeconds) * time.Second
+	if versionID.Valid {
+		w.TemplateVersion = &TemplateVersionRef{
+			ID:         versionID.Int64,
+			TemplateID: versionTemplateID.Int64,
+			Version:    int(version.Int64),
+		}
+	}
+
+	w.Bodyweight, err = metrics.BodyweightOn(db, w.Date)
+	if err != nil {
+		return nil, err
+	}
 
 	// Load exercises
 	w.Exercises, err = GetWorkoutExercises(db, id)
@@ -80,9 +318,40 @@ func GetByID(db *sql.DB, id int64) (*Workout, error) {
 		return nil, err
 	}
 
+	w.Comments, err = GetComments(db, id)
+	if err != nil {
+		return nil, err
+	}
+
 	return &w, nil
 }
 
+// GetComments returns the coach comments on a workout, oldest first
+func GetComments(db querier, workoutID int64) ([]Comment, error) {
+	rows, err := db.Query(`
+		SELECT wc.id, wc.workout_id, c.name, wc.body, wc.created_at
+		FROM workout_comments wc
+		JOIN coaches c ON wc.coach_id = c.id
+		WHERE wc.workout_id = ?
+		ORDER BY wc.created_at ASC, wc.id ASC
+	`, workoutID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workout comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []Comment
+	for rows.Next() {
+		var c Comment
+		if err := rows.Scan(&c.ID, &c.WorkoutID, &c.CoachName, &c.Body, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan workout comment: %w", err)
+		}
+		comments = append(comments, c)
+	}
+
+	return comments, rows.Err()
+}
+
 // Create inserts a new workout and returns its ID
 func Create(db *sql.DB, name string, date time.Time, templateID *int64) (int64, error) {
 	var result sql.Result
@@ -90,14 +359,14 @@ func Create(db *sql.DB, name string, date time.Time, templateID *int64) (int64,
 
 	if templateID != nil {
 		result, err = db.Exec(`
-			INSERT INTO workouts (name, date, template_id, status)
-			VALUES (?, ?, ?, ?)
-		`, name, date.Format("2006-01-02"), *templateID, StatusInProgress)
+			INSERT INTO workouts (name, date, template_id, status, started_at)
+			VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		`, name, date.Format(dates.Layout), *templateID, StatusInProgress)
 	} else {
 		result, err = db.Exec(`
-			INSERT INTO workouts (name, date, status)
-			VALUES (?, ?, ?)
-		`, name, date.Format("2006-01-02"), StatusInProgress)
+			INSERT INTO workouts (name, date, status, started_at)
+			VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		`, name, date.Format(dates.Layout), StatusInProgress)
 	}
 
 	if err != nil {
@@ -107,46 +376,234 @@ func Create(db *sql.DB, name string, date time.Time, templateID *int64) (int64,
 	return result.LastInsertId()
 }
 
-// Update modifies a workout's details
+// Update modifies an in-progress workout. It returns ErrFinished for a
+// finished workout, which is read-only.
 func Update(db *sql.DB, id int64, name string, date time.Time, notes string) error {
-	_, err := db.Exec(`
+	return UpdateWithTimes(db, id, name, date, notes, nil, nil)
+}
+
+// UpdateWithTimes modifies an in-progress workout and sets its times like
+// SetTimes, in one transaction so a rejected time leaves it untouched. It
+// returns ErrFinished for a finished workout.
+func UpdateWithTimes(db *sql.DB, id int64, name string, date time.Time, notes string, startedAt, endedAt *time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status WorkoutStatus
+	err = tx.QueryRow(`SELECT status FROM workouts WHERE id = ?`, id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get workout status: %w", err)
+	}
+	if status == StatusFinished {
+		return ErrFinished
+	}
+
+	if _, err := tx.Exec(`
 		UPDATE workouts
 		SET name = ?, date = ?, notes = ?
-		WHERE id = ? AND status = ?
-	`, name, date.Format("2006-01-02"), notes, id, StatusInProgress)
-	if err != nil {
+		WHERE id = ?
+	`, name, date.Format(dates.Layout), notes, id); err != nil {
 		return fmt.Errorf("failed to update workout: %w", err)
 	}
+
+	if err := setTimes(tx, id, startedAt, endedAt); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
-// Delete removes a workout by ID
-func Delete(db *sql.DB, id int64) error {
-	_, err := db.Exec(`DELETE FROM workouts WHERE id = ?`, id)
+// SetTimes sets when a session started and ended. A nil start keeps the
+// current one. A nil end leaves an in-progress session to end when it is
+// finished and keeps the end of a finished one, whose summary is recomputed
+// for the new duration.
+func SetTimes(db *sql.DB, id int64, startedAt, endedAt *time.Time) error {
+	tx, err := db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to delete workout: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := setTimes(tx, id, startedAt, endedAt); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// setTimes sets a session's times as part of a caller's transaction
+func setTimes(tx *sql.Tx, id int64, startedAt, endedAt *time.Time) error {
+	if startedAt == nil && endedAt == nil {
+		return nil
+	}
+
+	if endedAt != nil {
+		start := startedAt
+		if start == nil {
+			var saved sql.NullTime
+			err := tx.QueryRow(`SELECT started_at FROM workouts WHERE id = ?`, id).Scan(&saved)
+			if err != nil && err != sql.ErrNoRows {
+				return fmt.Errorf("failed to get workout times: %w", err)
+			}
+			if saved.Valid {
+				start = &saved.Time
+			}
+		}
+		if start != nil && endedAt.Before(*start) {
+			return ErrEndBeforeStart
+		}
+	}
+
+	var started, ended interface{}
+	if startedAt != nil {
+		started = formatTimestamp(*startedAt)
+	}
+	if endedAt != nil {
+		ended = formatTimestamp(*endedAt)
+	}
+	_, err := tx.Exec(`
+		UPDATE workouts
+		SET started_at = COALESCE(?, started_at),
+		    ended_at = CASE WHEN status = ? THEN COALESCE(?, ended_at) ELSE ? END
+		WHERE id = ?
+	`, started, StatusFinished, ended, ended, id)
+	if err != nil {
+		return fmt.Errorf("failed to set workout times: %w", err)
+	}
+
+	var status WorkoutStatus
+	err = tx.QueryRow(`SELECT status FROM workouts WHERE id = ?`, id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get workout status: %w", err)
+	}
+	if status != StatusFinished {
+		return nil
+	}
+
+	summary, err := ComputeFinishSummary(tx, id)
+	if err != nil || summary == nil {
+		return err
+	}
+	return SaveSummaryStats(tx, id, summary.SummaryStats)
+}
+
+// Pause stops the clock on an in-progress session
+func Pause(db *sql.DB, id int64) error {
+	_, err := db.Exec(`
+		UPDATE workouts
+		SET paused_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = ? AND paused_at IS NULL
+	`, id, StatusInProgress)
+	if err != nil {
+		return fmt.Errorf("failed to pause workout: %w", err)
 	}
 	return nil
 }
 
-// Finish marks a workout as complete
-func Finish(db *sql.DB, id int64) error {
+// Resume restarts the clock on a paused session, adding the pause to the paused total
+func Resume(db *sql.DB, id int64) error {
 	_, err := db.Exec(`
 		UPDATE workouts
-		SET status = ?, finished_at = CURRENT_TIMESTAMP
+		SET paused_seconds = paused_seconds + MAX(0, CAST(strftime('%s', 'now') AS INTEGER) - CAST(strftime('%s', paused_at) AS INTEGER)),
+		    paused_at = NULL
+		WHERE id = ? AND status = ? AND paused_at IS NOT NULL
+	`, id, StatusInProgress)
+	if err != nil {
+		return fmt.Errorf("failed to resume workout: %w", err)
+	}
+	return nil
+}
+
+// formatTimestamp formats a time the way SQLite's CURRENT_TIMESTAMP does (UTC)
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format(dates.TimestampLayout)
+}
+
+// Delete removes a workout by ID. Its exercises, sets, summary and coach
+// comments go with it.
+func Delete(db *sql.DB, id int64) error {
+	if _, err := db.Exec(`DELETE FROM workouts WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete workout: %w", err)
+	}
+
+	// A lift whose record came from this workout falls back to the next best
+	return strength.RefreshForWorkout(db, id)
+}
+
+// Finish marks a workout as complete and stores its summary and lift records
+// in one transaction. It reports whether the workout was finished by this call;
+// finishing a workout that isn't in progress does nothing.
+func Finish(db *sql.DB, id int64) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE workouts
+		SET status = ?, finished_at = CURRENT_TIMESTAMP,
+		    ended_at = COALESCE(ended_at, paused_at, CURRENT_TIMESTAMP),
+		    paused_at = NULL
 		WHERE id = ? AND status = ?
 	`, StatusFinished, id, StatusInProgress)
 	if err != nil {
-		return fmt.Errorf("failed to finish workout: %w", err)
+		return false, fmt.Errorf("failed to finish workout: %w", err)
 	}
-	return nil
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	// Lift records only count finished workouts
+	if err := strength.RefreshForWorkoutTx(tx, id); err != nil {
+		return false, err
+	}
+
+	summary, err := ComputeFinishSummary(tx, id)
+	if err != nil {
+		return false, err
+	}
+	if summary != nil {
+		if err := SaveSummaryStats(tx, id, summary.SummaryStats); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
 }
 
 // GetWorkoutExercises returns all exercises for a workout with sets and last weight
-func GetWorkoutExercises(db *sql.DB, workoutID int64) ([]WorkoutExercise, error) {
+func GetWorkoutExercises(db querier, workoutID int64) ([]WorkoutExercise, error) {
 	rows, err := db.Query(`
 		SELECT we.id, we.workout_id, we.exercise_id, we.position,
-		       e.id, e.name, e.created_at
+		       we.template_exercise_id, we.target_sets, we.target_reps,
+		       e.id, e.name, e.created_at,
+		       EXISTS (
+		         SELECT 1 FROM exercise_equipment ee
+		         WHERE ee.exercise_id = e.id AND ee.equipment = 'bodyweight'
+		       ),
+		       EXISTS (
+		         SELECT 1 FROM exercise_equipment ee
+		         WHERE ee.exercise_id = e.id AND ee.equipment = 'barbell'
+		       )
 		FROM workout_exercises we
 		JOIN exercises e ON we.exercise_id = e.id
 		WHERE we.workout_id = ?
@@ -161,11 +618,8 @@ func GetWorkoutExercises(db *sql.DB, workoutID int64) ([]WorkoutExercise, error)
 	var workoutExerciseIDs []int64
 	var exerciseIDs []int64
 	for rows.Next() {
-		var we WorkoutExercise
-		if err := rows.Scan(
-			&we.ID, &we.WorkoutID, &we.ExerciseID, &we.Position,
-			&we.Exercise.ID, &we.Exercise.Name, &we.Exercise.CreatedAt,
-		); err != nil {
+		we, err := scanWorkoutExercise(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan workout exercise: %w", err)
 		}
 		exercises = append(exercises, we)
@@ -193,17 +647,46 @@ func GetWorkoutExercises(db *sql.DB, workoutID int64) ([]WorkoutExercise, error)
 		return nil, err
 	}
 
-	// Assign sets and last weights to exercises
+	// Batch load previous sessions for all exercises
+	previousMap, err := getPreviousSessionsBatch(db, exerciseIDs, workoutID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Assign sets, last weights and previous sessions to exercises
 	for i := range exercises {
 		exercises[i].Sets = setsMap[exercises[i].ID]
 		exercises[i].LastWeight = lastWeightsMap[exercises[i].ExerciseID]
+		exercises[i].Previous = previousMap[exercises[i].ExerciseID]
 	}
 
 	return exercises, nil
 }
 
+// scanWorkoutExercise scans a workout exercise with the targets copied from
+// its template when the workout was created
+func scanWorkoutExercise(row interface{ Scan(...any) error }) (WorkoutExercise, error) {
+	var we WorkoutExercise
+	var templateExerciseID, targetSets, targetReps sql.NullInt64
+	if err := row.Scan(
+		&we.ID, &we.WorkoutID, &we.ExerciseID, &we.Position,
+		&templateExerciseID, &targetSets, &targetReps,
+		&we.Exercise.ID, &we.Exercise.Name, &we.Exercise.CreatedAt, &we.UsesBodyweight, &we.UsesBarbell,
+	); err != nil {
+		return we, err
+	}
+	if templateExerciseID.Valid {
+		we.TemplateExerciseID = &templateExerciseID.Int64
+	}
+	if targetSets.Valid && targetReps.Valid {
+		sets, reps := int(targetSets.Int64), int(targetReps.Int64)
+		we.TargetSets, we.TargetReps = &sets, &reps
+	}
+	return we, nil
+}
+
 // getLoggedSetsBatch fetches all logged sets for multiple workout exercises in one query
-func getLoggedSetsBatch(db *sql.DB, workoutExerciseIDs []int64) (map[int64][]LoggedSet, error) {
+func getLoggedSetsBatch(db querier, workoutExerciseIDs []int64) (map[int64][]LoggedSet, error) {
 	if len(workoutExerciseIDs) == 0 {
 		return make(map[int64][]LoggedSet), nil
 	}
@@ -217,7 +700,8 @@ func getLoggedSetsBatch(db *sql.DB, workoutExerciseIDs []int64) (map[int64][]Log
 	}
 
 	query := fmt.Sprintf(`
-		SELECT id, workout_exercise_id, reps, weight, position, created_at
+		SELECT id, workout_exercise_id, reps, weight, position, set_type,
+		       COALESCE(client_id, ''), created_at, updated_at
 		FROM logged_sets
 		WHERE workout_exercise_id IN (%s)
 		ORDER BY workout_exercise_id, position ASC
@@ -231,8 +715,8 @@ func getLoggedSetsBatch(db *sql.DB, workoutExerciseIDs []int64) (map[int64][]Log
 
 	result := make(map[int64][]LoggedSet)
 	for rows.Next() {
-		var s LoggedSet
-		if err := rows.Scan(&s.ID, &s.WorkoutExerciseID, &s.Reps, &s.Weight, &s.Position, &s.CreatedAt); err != nil {
+		s, err := scanLoggedSet(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan logged set: %w", err)
 		}
 		result[s.WorkoutExerciseID] = append(result[s.WorkoutExerciseID], s)
@@ -242,7 +726,7 @@ func getLoggedSetsBatch(db *sql.DB, workoutExerciseIDs []int64) (map[int64][]Log
 }
 
 // getLastWeightsBatch fetches the most recent weight for multiple exercises in one query
-func getLastWeightsBatch(db *sql.DB, exerciseIDs []int64, excludeWorkoutID int64) (map[int64]*float64, error) {
+func getLastWeightsBatch(db querier, exerciseIDs []int64, excludeWorkoutID int64) (map[int64]*float64, error) {
 	if len(exerciseIDs) == 0 {
 		return make(map[int64]*float64), nil
 	}
@@ -273,6 +757,7 @@ func getLastWeightsBatch(db *sql.DB, exerciseIDs []int64, excludeWorkoutID int64
 		    WHERE we2.exercise_id = we.exercise_id
 		      AND w2.status = ?
 		      AND w2.id != ?
+		      AND ls2.set_type = 'working'
 		    ORDER BY w2.date DESC, ls2.created_at DESC
 		    LIMIT 1
 		  )
@@ -302,24 +787,12 @@ func getLastWeightsBatch(db *sql.DB, exerciseIDs []int64, excludeWorkoutID int64
 
 // AddExercise adds an exercise to a workout
 func AddExercise(db *sql.DB, workoutID, exerciseID int64) (int64, error) {
-	// Get the next position
-	var maxPos sql.NullInt64
-	err := db.QueryRow(`
-		SELECT MAX(position) FROM workout_exercises WHERE workout_id = ?
-	`, workoutID).Scan(&maxPos)
-	if err != nil {
-		return 0, fmt.Errorf("failed to get max position: %w", err)
-	}
-
-	nextPos := 1
-	if maxPos.Valid {
-		nextPos = int(maxPos.Int64) + 1
-	}
-
 	result, err := db.Exec(`
 		INSERT INTO workout_exercises (workout_id, exercise_id, position)
-		VALUES (?, ?, ?)
-	`, workoutID, exerciseID, nextPos)
+		SELECT ?, ?, COALESCE(MAX(position), 0) + 1
+		FROM workout_exercises
+		WHERE workout_id = ?
+	`, workoutID, exerciseID, workoutID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to add exercise to workout: %w", err)
 	}
@@ -329,17 +802,23 @@ func AddExercise(db *sql.DB, workoutID, exerciseID int64) (int64, error) {
 
 // RemoveExercise removes an exercise from a workout
 func RemoveExercise(db *sql.DB, id int64) error {
-	_, err := db.Exec(`DELETE FROM workout_exercises WHERE id = ?`, id)
+	exerciseID, err := finishedExerciseID(db, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`DELETE FROM workout_exercises WHERE id = ?`, id)
 	if err != nil {
 		return fmt.Errorf("failed to remove exercise from workout: %w", err)
 	}
-	return nil
+	return refreshLifts(db, exerciseID)
 }
 
 // GetLoggedSets returns all sets for a workout exercise
 func GetLoggedSets(db *sql.DB, workoutExerciseID int64) ([]LoggedSet, error) {
 	rows, err := db.Query(`
-		SELECT id, workout_exercise_id, reps, weight, position, created_at
+		SELECT id, workout_exercise_id, reps, weight, position, set_type,
+		       COALESCE(client_id, ''), created_at, updated_at
 		FROM logged_sets
 		WHERE workout_exercise_id = ?
 		ORDER BY position ASC
@@ -351,8 +830,8 @@ func GetLoggedSets(db *sql.DB, workoutExerciseID int64) ([]LoggedSet, error) {
 
 	var sets []LoggedSet
 	for rows.Next() {
-		var s LoggedSet
-		if err := rows.Scan(&s.ID, &s.WorkoutExerciseID, &s.Reps, &s.Weight, &s.Position, &s.CreatedAt); err != nil {
+		s, err := scanLoggedSet(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan logged set: %w", err)
 		}
 		sets = append(sets, s)
@@ -361,63 +840,350 @@ func GetLoggedSets(db *sql.DB, workoutExerciseID int64) ([]LoggedSet, error) {
 	return sets, rows.Err()
 }
 
-// AddSet adds a new set to a workout exercise
+// AddSet adds a new working set to a workout exercise
 func AddSet(db *sql.DB, workoutExerciseID int64, reps int, weight float64) (int64, error) {
-	// Get the next position
-	var maxPos sql.NullInt64
+	return AddSetOfType(db, workoutExerciseID, reps, weight, SetWorking)
+}
+
+// AddSetOfType adds a new set of the given type to the end of a workout exercise
+func AddSetOfType(db *sql.DB, workoutExerciseID int64, reps int, weight float64, setType SetType) (int64, error) {
+	clientID, err := NewClientID()
+	if err != nil {
+		return 0, err
+	}
+	return AddClientSet(db, workoutExerciseID, SetChange{
+		ClientID:  clientID,
+		Reps:      reps,
+		Weight:    weight,
+		Type:      setType,
+		UpdatedAt: time.Now(),
+	})
+}
+
+// AddClientSet adds a set under its client-generated ID to the end of a
+// workout exercise and returns its ID. If a set with that client ID was saved
+// in the meantime, it is updated instead, unless it holds a newer edit or
+// belongs to another workout exercise, in which case nothing changes and 0 is
+// returned.
+func AddClientSet(db *sql.DB, workoutExerciseID int64, c SetChange) (int64, error) {
+	var id int64
 	err := db.QueryRow(`
-		SELECT MAX(position) FROM logged_sets WHERE workout_exercise_id = ?
-	`, workoutExerciseID).Scan(&maxPos)
+		INSERT INTO logged_sets (workout_exercise_id, reps, weight, position, set_type, client_id, updated_at)
+		SELECT ?, ?, ?, COALESCE(MAX(position), 0) + 1, ?, ?, ?
+		FROM logged_sets
+		WHERE workout_exercise_id = ?
+		ON CONFLICT (client_id) DO UPDATE
+		SET reps = excluded.reps, weight = excluded.weight, updated_at = excluded.updated_at
+		WHERE excluded.updated_at >= logged_sets.updated_at
+		  AND logged_sets.workout_exercise_id = excluded.workout_exercise_id
+		RETURNING id
+	`, workoutExerciseID, c.Reps, c.Weight, c.Type, c.ClientID, formatTimestamp(c.UpdatedAt), workoutExerciseID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to add set: %w", err)
+	}
+
+	exerciseID, err := finishedExerciseID(db, workoutExerciseID)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get max position: %w", err)
+		return 0, err
 	}
+	return id, refreshLifts(db, exerciseID)
+}
 
-	nextPos := 1
-	if maxPos.Valid {
-		nextPos = int(maxPos.Int64) + 1
+// ReplaceWarmups removes a workout exercise's warm-up sets and inserts the
+// given ones ahead of its working sets, renumbering positions from 1
+func ReplaceWarmups(db *sql.DB, workoutExerciseID int64, warmups []plates.WarmupSet) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	result, err := db.Exec(`
-		INSERT INTO logged_sets (workout_exercise_id, reps, weight, position)
-		VALUES (?, ?, ?, ?)
-	`, workoutExerciseID, reps, weight, nextPos)
+	_, err = tx.Exec(`
+		DELETE FROM logged_sets WHERE workout_exercise_id = ? AND set_type = ?
+	`, workoutExerciseID, SetWarmup)
 	if err != nil {
-		return 0, fmt.Errorf("failed to add set: %w", err)
+		return fmt.Errorf("failed to clear warm-up sets: %w", err)
 	}
 
-	return result.LastInsertId()
+	rows, err := tx.Query(`
+		SELECT id FROM logged_sets WHERE workout_exercise_id = ? ORDER BY position ASC
+	`, workoutExerciseID)
+	if err != nil {
+		return fmt.Errorf("failed to get working sets: %w", err)
+	}
+	var workingIDs []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan working set: %w", err)
+		}
+		workingIDs = append(workingIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Move working sets out of the way first since positions are unique per exercise
+	_, err = tx.Exec(`
+		UPDATE logged_sets SET position = -position WHERE workout_exercise_id = ?
+	`, workoutExerciseID)
+	if err != nil {
+		return fmt.Errorf("failed to reorder sets: %w", err)
+	}
+	for i, id := range workingIDs {
+		if _, err := tx.Exec(`UPDATE logged_sets SET position = ? WHERE id = ?`, len(warmups)+i+1, id); err != nil {
+			return fmt.Errorf("failed to reorder sets: %w", err)
+		}
+	}
+
+	for i, w := range warmups {
+		clientID, err := NewClientID()
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+			INSERT INTO logged_sets (workout_exercise_id, reps, weight, position, set_type, client_id, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		`, workoutExerciseID, w.Reps, w.Weight, i+1, SetWarmup, clientID)
+		if err != nil {
+			return fmt.Errorf("failed to add warm-up set: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
 }
 
 // UpdateSet modifies an existing set
 func UpdateSet(db *sql.DB, id int64, reps int, weight float64) error {
-	_, err := db.Exec(`
+	return UpdateSetAt(db, id, reps, weight, time.Now())
+}
+
+// UpdateSetAt modifies an existing set, recording when the change was made
+func UpdateSetAt(db *sql.DB, id int64, reps int, weight float64, updatedAt time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
 		UPDATE logged_sets
-		SET reps = ?, weight = ?
+		SET reps = ?, weight = ?, updated_at = ?
 		WHERE id = ?
-	`, reps, weight, id)
+	`, reps, weight, formatTimestamp(updatedAt), id)
 	if err != nil {
 		return fmt.Errorf("failed to update set: %w", err)
 	}
+
+	workoutID, exerciseID, err := finishedSet(tx, id)
+	if err != nil {
+		return err
+	}
+	if err := refreshFinished(tx, workoutID, exerciseID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
 // DeleteSet removes a set
 func DeleteSet(db *sql.DB, id int64) error {
-	_, err := db.Exec(`DELETE FROM logged_sets WHERE id = ?`, id)
+	return DeleteSetAt(db, id, time.Now())
+}
+
+// DeleteSetAt removes a set and leaves a tombstone for its client ID so that
+// replayed offline changes can't bring it back
+func DeleteSetAt(db *sql.DB, id int64, deletedAt time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	workoutID, exerciseID, err := finishedSet(tx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO deleted_sets (client_id, deleted_at)
+		SELECT client_id, ? FROM logged_sets WHERE id = ? AND client_id IS NOT NULL
+		ON CONFLICT (client_id) DO UPDATE SET deleted_at = excluded.deleted_at
+	`, formatTimestamp(deletedAt), id)
 	if err != nil {
+		return fmt.Errorf("failed to record deleted set: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM logged_sets WHERE id = ?`, id); err != nil {
 		return fmt.Errorf("failed to delete set: %w", err)
 	}
+
+	if err := refreshFinished(tx, workoutID, exerciseID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
+// finishedSet returns the workout and exercise of a set if its workout is
+// finished, or zeros otherwise
+func finishedSet(db querier, setID int64) (workoutID, exerciseID int64, err error) {
+	err = db.QueryRow(`
+		SELECT w.id, we.exercise_id
+		FROM logged_sets s
+		JOIN workout_exercises we ON we.id = s.workout_exercise_id
+		JOIN workouts w ON w.id = we.workout_id
+		WHERE s.id = ? AND w.status = ?
+	`, setID, StatusFinished).Scan(&workoutID, &exerciseID)
+	if err == sql.ErrNoRows {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get set: %w", err)
+	}
+	return workoutID, exerciseID, nil
+}
+
+// refreshFinished recomputes what the sets of a finished workout feed into
+// after one changes: the records of lifts done with the set's exercise and the
+// workout's stored summary. A workoutID of 0 means nothing to refresh.
+func refreshFinished(tx *sql.Tx, workoutID, exerciseID int64) error {
+	if workoutID == 0 {
+		return nil
+	}
+	if err := strength.RefreshForExerciseTx(tx, exerciseID); err != nil {
+		return err
+	}
+
+	summary, err := ComputeFinishSummary(tx, workoutID)
+	if err != nil || summary == nil {
+		return err
+	}
+	return SaveSummaryStats(tx, workoutID, summary.SummaryStats)
+}
+
+// finishedExerciseID returns the exercise of a workout exercise if its workout
+// is finished, or 0 otherwise. Lift records only count finished workouts, so
+// changes to other workouts can't move them.
+func finishedExerciseID(db *sql.DB, workoutExerciseID int64) (int64, error) {
+	var exerciseID int64
+	err := db.QueryRow(`
+		SELECT we.exercise_id
+		FROM workout_exercises we
+		JOIN workouts w ON w.id = we.workout_id
+		WHERE we.id = ? AND w.status = ?
+	`, workoutExerciseID, StatusFinished).Scan(&exerciseID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get workout exercise: %w", err)
+	}
+	return exerciseID, nil
+}
+
+// refreshLifts recomputes the records of any big lift counted from an exercise
+// whose sets in a finished workout changed; 0 means nothing to refresh
+func refreshLifts(db *sql.DB, exerciseID int64) error {
+	if exerciseID == 0 {
+		return nil
+	}
+	return strength.RefreshForExercise(db, exerciseID)
+}
+
+// GetSetByClientID returns the set with a client-generated ID
+func GetSetByClientID(db *sql.DB, clientID string) (*LoggedSet, error) {
+	var id int64
+	err := db.QueryRow(`SELECT id FROM logged_sets WHERE client_id = ?`, clientID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get set: %w", err)
+	}
+	return GetSetByID(db, id)
+}
+
+// GetSetDeletedAt returns when the set with a client-generated ID was deleted,
+// or nil if it never was
+func GetSetDeletedAt(db *sql.DB, clientID string) (*time.Time, error) {
+	var deletedAt time.Time
+	err := db.QueryRow(`SELECT deleted_at FROM deleted_sets WHERE client_id = ?`, clientID).Scan(&deletedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted set: %w", err)
+	}
+	return &deletedAt, nil
+}
+
+// PurgeDeletedSets forgets sets deleted before the given time and returns how
+// many were forgotten. A change to one of them replayed after this would
+// bring the set back, so keep tombstones well past any likely offline spell.
+func PurgeDeletedSets(db *sql.DB, before time.Time) (int64, error) {
+	result, err := db.Exec(`DELETE FROM deleted_sets WHERE deleted_at < ?`, formatTimestamp(before))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted sets: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// GetExerciseWorkoutStatus returns the status of the workout a workout exercise
+// belongs to, or an empty status if the workout exercise does not exist
+func GetExerciseWorkoutStatus(db *sql.DB, workoutExerciseID int64) (WorkoutStatus, error) {
+	var status WorkoutStatus
+	err := db.QueryRow(`
+		SELECT w.status
+		FROM workout_exercises we
+		JOIN workouts w ON w.id = we.workout_id
+		WHERE we.id = ?
+	`, workoutExerciseID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get workout status: %w", err)
+	}
+	return status, nil
+}
+
+// GetExerciseWorkoutID returns the workout a workout exercise belongs to, or 0 if it doesn't exist
+func GetExerciseWorkoutID(db *sql.DB, workoutExerciseID int64) (int64, error) {
+	var workoutID int64
+	err := db.QueryRow(`SELECT workout_id FROM workout_exercises WHERE id = ?`, workoutExerciseID).Scan(&workoutID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get workout exercise: %w", err)
+	}
+	return workoutID, nil
+}
+
 // GetSetByID returns a single set
 func GetSetByID(db *sql.DB, id int64) (*LoggedSet, error) {
-	var s LoggedSet
-	err := db.QueryRow(`
-		SELECT id, workout_exercise_id, reps, weight, position, created_at
+	s, err := scanLoggedSet(db.QueryRow(`
+		SELECT id, workout_exercise_id, reps, weight, position, set_type,
+		       COALESCE(client_id, ''), created_at, updated_at
 		FROM logged_sets
 		WHERE id = ?
-	`, id).Scan(&s.ID, &s.WorkoutExerciseID, &s.Reps, &s.Weight, &s.Position, &s.CreatedAt)
+	`, id))
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -429,6 +1195,20 @@ func GetSetByID(db *sql.DB, id int64) (*LoggedSet, error) {
 	return &s, nil
 }
 
+// scanLoggedSet scans a logged set, treating sets never edited as updated when created
+func scanLoggedSet(row interface{ Scan(...any) error }) (LoggedSet, error) {
+	var s LoggedSet
+	var updatedAt sql.NullTime
+	if err := row.Scan(&s.ID, &s.WorkoutExerciseID, &s.Reps, &s.Weight, &s.Position, &s.Type, &s.ClientID, &s.CreatedAt, &updatedAt); err != nil {
+		return s, err
+	}
+	s.UpdatedAt = s.CreatedAt
+	if updatedAt.Valid {
+		s.UpdatedAt = updatedAt.Time
+	}
+	return s, nil
+}
+
 // GetLastWeight returns the most recent weight used for an exercise from finished workouts
 func GetLastWeight(db *sql.DB, exerciseID, excludeWorkoutID int64) (*float64, error) {
 	var weight sql.NullFloat64
@@ -440,6 +1220,7 @@ func GetLastWeight(db *sql.DB, exerciseID, excludeWorkoutID int64) (*float64, er
 		WHERE we.exercise_id = ?
 		  AND w.status = ?
 		  AND w.id != ?
+		  AND ls.set_type = 'working'
 		ORDER BY w.date DESC, ls.created_at DESC
 		LIMIT 1
 	`, exerciseID, StatusFinished, excludeWorkoutID).Scan(&weight)
@@ -456,17 +1237,22 @@ func GetLastWeight(db *sql.DB, exerciseID, excludeWorkoutID int64) (*float64, er
 
 // GetWorkoutExerciseByID returns a single workout exercise
 func GetWorkoutExerciseByID(db *sql.DB, id int64) (*WorkoutExercise, error) {
-	var we WorkoutExercise
-	err := db.QueryRow(`
+	we, err := scanWorkoutExercise(db.QueryRow(`
 		SELECT we.id, we.workout_id, we.exercise_id, we.position,
-		       e.id, e.name, e.created_at
+		       we.template_exercise_id, we.target_sets, we.target_reps,
+		       e.id, e.name, e.created_at,
+		       EXISTS (
+		         SELECT 1 FROM exercise_equipment ee
+		         WHERE ee.exercise_id = e.id AND ee.equipment = 'bodyweight'
+		       ),
+		       EXISTS (
+		         SELECT 1 FROM exercise_equipment ee
+		         WHERE ee.exercise_id = e.id AND ee.equipment = 'barbell'
+		       )
 		FROM workout_exercises we
 		JOIN exercises e ON we.exercise_id = e.id
 		WHERE we.id = ?
-	`, id).Scan(
-		&we.ID, &we.WorkoutID, &we.ExerciseID, &we.Position,
-		&we.Exercise.ID, &we.Exercise.Name, &we.Exercise.CreatedAt,
-	)
+	`, id))
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -487,10 +1273,100 @@ func GetWorkoutExerciseByID(db *sql.DB, id int64) (*WorkoutExercise, error) {
 	}
 	we.LastWeight = lastWeight
 
+	previous, err := GetPreviousSession(db, we.ExerciseID, we.WorkoutID)
+	if err != nil {
+		return nil, err
+	}
+	we.Previous = previous
+
 	return &we, nil
 }
 
-// CreateFromTemplate creates a workout from a template
+// GetPreviousSession returns the sets from the last finished session of an exercise.
+// When the workout was started from a template, sessions of the same template are
+// preferred over more recent sessions of other workouts.
+func GetPreviousSession(db *sql.DB, exerciseID, excludeWorkoutID int64) (*PreviousSession, error) {
+	previous, err := getPreviousSessionsBatch(db, []int64{exerciseID}, excludeWorkoutID)
+	if err != nil {
+		return nil, err
+	}
+	return previous[exerciseID], nil
+}
+
+// getPreviousSessionsBatch fetches the previous session of multiple exercises,
+// chosen as GetPreviousSession describes, in two queries
+func getPreviousSessionsBatch(db querier, exerciseIDs []int64, excludeWorkoutID int64) (map[int64]*PreviousSession, error) {
+	if len(exerciseIDs) == 0 {
+		return make(map[int64]*PreviousSession), nil
+	}
+
+	// Build placeholders for IN clause
+	placeholders := make([]string, len(exerciseIDs))
+	args := []interface{}{excludeWorkoutID}
+	for i, id := range exerciseIDs {
+		placeholders[i] = "?"
+		args = append(args, id)
+	}
+	args = append(args, StatusFinished, excludeWorkoutID)
+
+	// Rank each exercise's sessions and keep the first
+	query := fmt.Sprintf(`
+		SELECT exercise_id, workout_exercise_id, workout_id, date
+		FROM (
+		  SELECT we.exercise_id, we.id AS workout_exercise_id, w.id AS workout_id, w.date,
+		         ROW_NUMBER() OVER (
+		           PARTITION BY we.exercise_id
+		           ORDER BY COALESCE(w.template_id = (SELECT template_id FROM workouts WHERE id = ?), 0) DESC,
+		                    w.date DESC, w.id DESC, we.position ASC
+		         ) AS rank
+		  FROM workout_exercises we
+		  JOIN workouts w ON we.workout_id = w.id
+		  WHERE we.exercise_id IN (%s)
+		    AND w.status = ?
+		    AND w.id != ?
+		    AND EXISTS (SELECT 1 FROM logged_sets ls WHERE ls.workout_exercise_id = we.id)
+		)
+		WHERE rank = 1
+	`, strings.Join(placeholders, ","))
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to batch get previous sessions: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[int64]*PreviousSession)
+	sessions := make(map[int64]*PreviousSession) // By workout exercise
+	var workoutExerciseIDs []int64
+	for rows.Next() {
+		var exerciseID, workoutExerciseID int64
+		var prev PreviousSession
+		if err := rows.Scan(&exerciseID, &workoutExerciseID, &prev.WorkoutID, &prev.Date); err != nil {
+			return nil, fmt.Errorf("failed to scan previous session: %w", err)
+		}
+		result[exerciseID] = &prev
+		sessions[workoutExerciseID] = &prev
+		workoutExerciseIDs = append(workoutExerciseIDs, workoutExerciseID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	setsMap, err := getLoggedSetsBatch(db, workoutExerciseIDs)
+	if err != nil {
+		return nil, err
+	}
+	for id, prev := range sessions {
+		prev.Sets = setsMap[id]
+	}
+
+	return result, nil
+}
+
+// CreateFromTemplate creates a workout from the latest version of a template.
+// Each exercise keeps a link to its template entry and a copy of the entry's
+// targets, so later edits to the template don't change the workout.
 func CreateFromTemplate(db *sql.DB, workoutName string, date time.Time, templateID int64) (int64, error) {
 	// Create the workout
 	workoutID, err := Create(db, workoutName, date, &templateID)
@@ -498,10 +1374,22 @@ func CreateFromTemplate(db *sql.DB, workoutName string, date time.Time, template
 		return 0, err
 	}
 
+	// The template's exercises always match its latest version, which the
+	// workout keeps a reference to
+	_, err = db.Exec(`
+		UPDATE workouts SET template_version_id = (
+			SELECT id FROM template_versions WHERE template_id = ? ORDER BY version DESC LIMIT 1
+		)
+		WHERE id = ?
+	`, templateID, workoutID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to link template version: %w", err)
+	}
+
 	// Copy exercises from template - first collect all exercises to avoid holding
 	// the cursor open while doing inserts (which would deadlock with MaxOpenConns=1)
 	rows, err := db.Query(`
-		SELECT exercise_id, target_sets, target_reps, position
+		SELECT id, exercise_id, target_sets, target_reps, position
 		FROM template_exercises
 		WHERE template_id = ?
 		ORDER BY position ASC
@@ -511,19 +1399,21 @@ func CreateFromTemplate(db *sql.DB, workoutName string, date time.Time, template
 	}
 
 	type templateExercise struct {
+		id         int64
 		exerciseID int64
+		targetSets int
+		targetReps int
 		position   int
 	}
 	var exercises []templateExercise
 
 	for rows.Next() {
-		var exerciseID int64
-		var targetSets, targetReps, position int
-		if err := rows.Scan(&exerciseID, &targetSets, &targetReps, &position); err != nil {
+		var te templateExercise
+		if err := rows.Scan(&te.id, &te.exerciseID, &te.targetSets, &te.targetReps, &te.position); err != nil {
 			rows.Close()
 			return 0, fmt.Errorf("failed to scan template exercise: %w", err)
 		}
-		exercises = append(exercises, templateExercise{exerciseID: exerciseID, position: position})
+		exercises = append(exercises, te)
 	}
 	rows.Close()
 
@@ -534,9 +1424,9 @@ func CreateFromTemplate(db *sql.DB, workoutName string, date time.Time, template
 	// Now insert the exercises
 	for _, ex := range exercises {
 		_, err := db.Exec(`
-			INSERT INTO workout_exercises (workout_id, exercise_id, position)
-			VALUES (?, ?, ?)
-		`, workoutID, ex.exerciseID, ex.position)
+			INSERT INTO workout_exercises (workout_id, exercise_id, position, template_exercise_id, target_sets, target_reps)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, workoutID, ex.exerciseID, ex.position, ex.id, ex.targetSets, ex.targetReps)
 		if err != nil {
 			return 0, fmt.Errorf("failed to copy exercise: %w", err)
 		}
@@ -544,22 +1434,3 @@ func CreateFromTemplate(db *sql.DB, workoutName string, date time.Time, template
 
 	return workoutID, nil
 }
-
-// GetTemplateTargets returns the target sets/reps for an exercise from a template
-func GetTemplateTargets(db *sql.DB, templateID, exerciseID int64) (*int, *int, error) {
-	var targetSets, targetReps int
-	err := db.QueryRow(`
-		SELECT target_sets, target_reps
-		FROM template_exercises
-		WHERE template_id = ? AND exercise_id = ?
-	`, templateID, exerciseID).Scan(&targetSets, &targetReps)
-
-	if err == sql.ErrNoRows {
-		return nil, nil, nil
-	}
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get template targets: %w", err)
-	}
-
-	return &targetSets, &targetReps, nil
-}
@@ -9,10 +9,16 @@ func RegisterRoutes(app *fiber.App) {
 	app.Get("/workouts/new", HandleNew)
 	app.Post("/workouts", HandleCreate)
 	app.Get("/workouts/history", HandleHistory)
+	app.Get("/workouts/history/page", HandleHistoryPage)
 	app.Get("/workouts/:id", HandleShow)
 	app.Put("/workouts/:id", HandleUpdate)
+	app.Put("/workouts/:id/times", HandleUpdateTimes)
 	app.Delete("/workouts/:id", HandleDelete)
 	app.Post("/workouts/:id/finish", HandleFinish)
+	app.Get("/workouts/:id/summary", HandleSummary)
+	app.Post("/workouts/:id/pause", HandlePause)
+	app.Post("/workouts/:id/resume", HandleResume)
+	app.Get("/workouts/:id/events", HandleEvents)
 
 	// Workout exercises
 	app.Post("/workouts/:id/exercises", HandleAddExercise)
@@ -20,6 +26,12 @@ func RegisterRoutes(app *fiber.App) {
 
 	// Logged sets
 	app.Post("/workouts/exercises/:id/sets", HandleAddSet)
+	app.Post("/workouts/exercises/:id/warmups", HandleAddWarmups)
 	app.Put("/workouts/sets/:id", HandleUpdateSet)
+	app.Post("/workouts/sets/:id/copy-previous", HandleCopyPreviousSet)
 	app.Delete("/workouts/sets/:id", HandleDeleteSet)
+
+	// Offline outbox replay
+	app.Put("/workouts/sync/sets/:clientID", HandleSyncSet)
+	app.Delete("/workouts/sync/sets/:clientID", HandleSyncDeleteSet)
 }
@@ -0,0 +1,184 @@
+package workouts
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"phobos/internal/features/strength"
+	"phobos/internal/shared/dates"
+)
+
+// ComputeFinishSummary works out the totals, PRs, template targets and
+// comparison with the previous session for a workout. It returns nil if the
+// workout does not exist.
+func ComputeFinishSummary(db querier, workoutID int64) (*FinishSummary, error) {
+	w, err := GetByID(db, workoutID)
+	if err != nil || w == nil {
+		return nil, err
+	}
+
+	summary := &FinishSummary{Workout: w}
+	summary.Duration = w.Duration(time.Now())
+
+	for _, we := range w.Exercises {
+		result := ExerciseResult{Name: we.Exercise.Name, TargetSets: we.TargetSets, TargetReps: we.TargetReps}
+		for _, s := range we.WorkingSets() {
+			result.SetCount++
+			result.RepCount += s.Reps
+			result.Volume += float64(s.Reps) * s.Weight
+		}
+		result.TopWeight = we.TopWeight()
+
+		if result.HasTargets() {
+			summary.TargetsTotal++
+			hit := 0
+			for _, s := range we.WorkingSets() {
+				if s.Reps >= *result.TargetReps {
+					hit++
+				}
+			}
+			result.TargetsMet = hit >= *result.TargetSets
+			if result.TargetsMet {
+				summary.TargetsMet++
+			}
+		}
+
+		prs, err := exercisePRs(db, w, we)
+		if err != nil {
+			return nil, err
+		}
+		summary.PRs = append(summary.PRs, prs...)
+
+		summary.SetCount += result.SetCount
+		summary.RepCount += result.RepCount
+		summary.TotalVolume += result.Volume
+		summary.Exercises = append(summary.Exercises, result)
+	}
+	summary.PRCount = len(summary.PRs)
+
+	summary.Previous, err = previousSession(db, w)
+	if err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// exercisePRs compares an exercise's sets with the same exercise in earlier
+// finished workouts. The first time an exercise is done sets no records.
+func exercisePRs(db querier, w *Workout, we WorkoutExercise) ([]PR, error) {
+	rows, err := db.Query(`
+		SELECT ls.weight, ls.reps
+		FROM logged_sets ls
+		JOIN workout_exercises we ON ls.workout_exercise_id = we.id
+		JOIN workouts w ON we.workout_id = w.id
+		WHERE we.exercise_id = ?
+		  AND w.status = ?
+		  AND ls.set_type = 'working'
+		  AND (w.date < ? OR (w.date = ? AND w.id < ?))
+	`, we.ExerciseID, StatusFinished, w.Date.Format(dates.Layout), w.Date.Format(dates.Layout), w.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get previous sets: %w", err)
+	}
+	defer rows.Close()
+
+	found := false
+	var prevWeight, prevE1RM float64
+	for rows.Next() {
+		var weight float64
+		var reps int
+		if err := rows.Scan(&weight, &reps); err != nil {
+			return nil, fmt.Errorf("failed to scan previous set: %w", err)
+		}
+		found = true
+		if reps > 0 {
+			prevWeight = max(prevWeight, weight)
+		}
+		prevE1RM = max(prevE1RM, strength.EstimateOneRepMax(weight, reps))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, nil
+	}
+
+	var weight, e1rm float64
+	for _, s := range we.WorkingSets() {
+		if s.Reps > 0 {
+			weight = max(weight, s.Weight)
+		}
+		e1rm = max(e1rm, strength.EstimateOneRepMax(s.Weight, s.Reps))
+	}
+
+	var prs []PR
+	if weight > prevWeight {
+		prs = append(prs, PR{Exercise: we.Exercise.Name, Kind: PRWeight, Value: weight, Previous: prevWeight})
+	}
+	if e1rm > prevE1RM {
+		prs = append(prs, PR{Exercise: we.Exercise.Name, Kind: PRE1RM, Value: e1rm, Previous: prevE1RM})
+	}
+	return prs, nil
+}
+
+// previousSession returns the most recent earlier finished workout from the same template
+func previousSession(db querier, w *Workout) (*SessionComparison, error) {
+	if w.TemplateID == nil {
+		return nil, nil
+	}
+
+	var prev SessionComparison
+	var startedAt, endedAt sql.NullTime
+	var pausedSeconds int64
+	err := db.QueryRow(`
+		SELECT w.id, w.date, w.started_at, w.ended_at, w.paused_seconds,
+		       COALESCE(SUM(ls.reps * ls.weight), 0),
+		       COUNT(ls.id),
+		       COALESCE(SUM(ls.reps), 0)
+		FROM workouts w
+		LEFT JOIN workout_exercises we ON we.workout_id = w.id
+		LEFT JOIN logged_sets ls ON ls.workout_exercise_id = we.id AND ls.set_type = 'working'
+		WHERE w.template_id = ?
+		  AND w.status = ?
+		  AND (w.date < ? OR (w.date = ? AND w.id < ?))
+		GROUP BY w.id
+		ORDER BY w.date DESC, w.id DESC
+		LIMIT 1
+	`, *w.TemplateID, StatusFinished, w.Date.Format(dates.Layout), w.Date.Format(dates.Layout), w.ID).Scan(
+		&prev.WorkoutID, &prev.Date, &startedAt, &endedAt, &pausedSeconds, &prev.TotalVolume, &prev.SetCount, &prev.RepCount,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get previous session: %w", err)
+	}
+
+	if startedAt.Valid && endedAt.Valid {
+		prev.Duration = endedAt.Time.Sub(startedAt.Time) - time.Duration(pausedSeconds)*time.Second
+	}
+	return &prev, nil
+}
+
+// SaveSummaryStats stores a finished workout's totals for the history list
+func SaveSummaryStats(db querier, workoutID int64, s SummaryStats) error {
+	_, err := db.Exec(`
+		INSERT INTO workout_summaries
+			(workout_id, duration_seconds, total_volume, set_count, rep_count, pr_count, targets_met, targets_total)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (workout_id) DO UPDATE SET
+			duration_seconds = excluded.duration_seconds,
+			total_volume = excluded.total_volume,
+			set_count = excluded.set_count,
+			rep_count = excluded.rep_count,
+			pr_count = excluded.pr_count,
+			targets_met = excluded.targets_met,
+			targets_total = excluded.targets_total
+	`, workoutID, int64(s.Duration.Seconds()), s.TotalVolume, s.SetCount, s.RepCount, s.PRCount, s.TargetsMet, s.TargetsTotal)
+	if err != nil {
+		return fmt.Errorf("failed to save workout summary: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package workouts
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// SetChange is a set as logged or edited on a client, possibly while offline
+type SetChange struct {
+	ClientID          string
+	WorkoutExerciseID int64
+	Reps              int
+	Weight            float64
+	Type              SetType
+	UpdatedAt         time.Time // When the change was made on the client
+}
+
+// SyncStatus reports how a replayed change was resolved
+type SyncStatus string
+
+const (
+	SyncApplied  SyncStatus = "applied"  // The change was saved
+	SyncStale    SyncStatus = "stale"    // The server already has a newer version of the set
+	SyncRejected SyncStatus = "rejected" // The set was deleted, or its workout finished or removed
+)
+
+// SyncResult is the outcome of a replayed change and the set as the server now has it
+type SyncResult struct {
+	Status SyncStatus
+	Set    *LoggedSet
+}
+
+// NewClientID returns a random ID for a set logged on the server
+func NewClientID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate client ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ApplySetChange saves a change made on a client. Replaying the same change
+// is harmless: sets are matched by client ID, and the most recent edit wins.
+// Changes older than a deletion of the set, to a workout that has since been
+// finished, or naming a set of another workout exercise, are rejected. Two replays of the same change racing each
+// other are settled by AddClientSet, which saves a set only once.
+func ApplySetChange(db *sql.DB, c SetChange) (SyncResult, error) {
+	existing, err := GetSetByClientID(db, c.ClientID)
+	if err != nil {
+		return SyncResult{}, err
+	}
+
+	// A client ID names one set, which can't move to another exercise
+	if existing != nil && existing.WorkoutExerciseID != c.WorkoutExerciseID {
+		return SyncResult{Status: SyncRejected, Set: existing}, nil
+	}
+
+	status, err := GetExerciseWorkoutStatus(db, c.WorkoutExerciseID)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	if status != StatusInProgress {
+		return SyncResult{Status: SyncRejected, Set: existing}, nil
+	}
+
+	if existing != nil {
+		if existing.UpdatedAt.After(c.UpdatedAt) {
+			return SyncResult{Status: SyncStale, Set: existing}, nil
+		}
+		if err := UpdateSetAt(db, existing.ID, c.Reps, c.Weight, c.UpdatedAt); err != nil {
+			return SyncResult{}, err
+		}
+		set, err := GetSetByID(db, existing.ID)
+		return SyncResult{Status: SyncApplied, Set: set}, err
+	}
+
+	deletedAt, err := GetSetDeletedAt(db, c.ClientID)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	if deletedAt != nil {
+		return SyncResult{Status: SyncRejected}, nil
+	}
+
+	id, err := AddClientSet(db, c.WorkoutExerciseID, c)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	if id == 0 {
+		// The set was saved since it was looked up, with a newer edit or
+		// under another exercise
+		set, err := GetSetByClientID(db, c.ClientID)
+		if err != nil {
+			return SyncResult{}, err
+		}
+		if set != nil && set.WorkoutExerciseID != c.WorkoutExerciseID {
+			return SyncResult{Status: SyncRejected, Set: set}, nil
+		}
+		return SyncResult{Status: SyncStale, Set: set}, nil
+	}
+	set, err := GetSetByID(db, id)
+	return SyncResult{Status: SyncApplied, Set: set}, err
+}
+
+// ApplySetDeletion deletes a set on behalf of a client. Deleting a set that is
+// already gone succeeds; a set edited after the deletion was made is kept.
+func ApplySetDeletion(db *sql.DB, clientID string, deletedAt time.Time) (SyncResult, error) {
+	existing, err := GetSetByClientID(db, clientID)
+	if err != nil || existing == nil {
+		return SyncResult{Status: SyncApplied}, err
+	}
+
+	status, err := GetExerciseWorkoutStatus(db, existing.WorkoutExerciseID)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	if status != StatusInProgress {
+		return SyncResult{Status: SyncRejected, Set: existing}, nil
+	}
+	if existing.UpdatedAt.After(deletedAt) {
+		return SyncResult{Status: SyncStale, Set: existing}, nil
+	}
+
+	if err := DeleteSetAt(db, existing.ID, deletedAt); err != nil {
+		return SyncResult{}, err
+	}
+	return SyncResult{Status: SyncApplied}, nil
+}
@@ -0,0 +1,199 @@
+package workouts
+
+import (
+	"database/sql"
+	"log/slog"
+
+	"phobos/internal/features/webhooks"
+	"phobos/internal/shared/dates"
+	"phobos/internal/shared/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// workoutPayload is the webhook data for workout.created
+type workoutPayload struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Date       string `json:"date"`
+	TemplateID *int64 `json:"template_id"`
+}
+
+// setPayload is the webhook data for set.logged
+type setPayload struct {
+	ID                int64   `json:"id"`
+	WorkoutID         int64   `json:"workout_id"`
+	WorkoutExerciseID int64   `json:"workout_exercise_id"`
+	Exercise          string  `json:"exercise"`
+	Reps              int     `json:"reps"`
+	Weight            float64 `json:"weight"`
+	SetType           SetType `json:"set_type"`
+}
+
+// finishedPayload is the webhook data for workout.finished
+type finishedPayload struct {
+	ID              int64             `json:"id"`
+	Name            string            `json:"name"`
+	Date            string            `json:"date"`
+	DurationSeconds int64             `json:"duration_seconds"`
+	TotalVolume     float64           `json:"total_volume"`
+	SetCount        int               `json:"set_count"`
+	RepCount        int               `json:"rep_count"`
+	PRCount         int               `json:"pr_count"`
+	Exercises       []exercisePayload `json:"exercises"`
+}
+
+// exercisePayload is one exercise of a finishedPayload
+type exercisePayload struct {
+	Name      string  `json:"name"`
+	SetCount  int     `json:"set_count"`
+	RepCount  int     `json:"rep_count"`
+	Volume    float64 `json:"volume"`
+	TopWeight float64 `json:"top_weight"`
+}
+
+// prPayload is the webhook data for pr.achieved
+type prPayload struct {
+	WorkoutID int64   `json:"workout_id"`
+	Exercise  string  `json:"exercise"`
+	Kind      string  `json:"kind"`
+	Value     float64 `json:"value"`
+	Previous  float64 `json:"previous"`
+}
+
+// prKindNames are the names of PR kinds in webhook payloads
+var prKindNames = map[PRKind]string{
+	PRWeight: "weight",
+	PRE1RM:   "e1rm",
+}
+
+// subscribed reports whether any webhook is notified of an event. A failed
+// lookup is logged and treated as no subscribers.
+func subscribed(db *sql.DB, event webhooks.Event) bool {
+	ok, err := webhooks.Subscribed(db, event)
+	if err != nil {
+		slog.Warn("Failed to check webhook subscriptions", "event", event, "error", err)
+	}
+	return ok
+}
+
+// enqueue queues an event, logging the error if it can't
+func enqueue(db *sql.DB, event webhooks.Event, data any) {
+	if err := webhooks.Enqueue(db, event, data); err != nil {
+		slog.Warn("Failed to queue webhook event", "event", event, "error", err)
+	}
+}
+
+// payloadFailed logs that the data for an event couldn't be loaded
+func payloadFailed(event webhooks.Event, err error) {
+	slog.Warn("Failed to build webhook payload", "event", event, "error", err)
+}
+
+// notifyWorkoutCreated queues workout.created for a new workout. Like live
+// updates, webhooks are best effort: a change is never refused because its
+// event couldn't be queued, but the failure is logged.
+func notifyWorkoutCreated(c *fiber.Ctx, workoutID int64) {
+	db := middleware.GetDB(c)
+	if !subscribed(db, webhooks.EventWorkoutCreated) {
+		return
+	}
+
+	w, err := GetByID(db, workoutID)
+	if err != nil {
+		payloadFailed(webhooks.EventWorkoutCreated, err)
+		return
+	}
+	if w == nil {
+		return
+	}
+
+	enqueue(db, webhooks.EventWorkoutCreated, workoutPayload{
+		ID:         w.ID,
+		Name:       w.Name,
+		Date:       w.Date.Format(dates.Layout),
+		TemplateID: w.TemplateID,
+	})
+}
+
+// notifySetLogged queues set.logged for a newly logged set
+func notifySetLogged(c *fiber.Ctx, set LoggedSet) {
+	db := middleware.GetDB(c)
+	if !subscribed(db, webhooks.EventSetLogged) {
+		return
+	}
+
+	we, err := GetWorkoutExerciseByID(db, set.WorkoutExerciseID)
+	if err != nil {
+		payloadFailed(webhooks.EventSetLogged, err)
+		return
+	}
+	if we == nil {
+		return
+	}
+
+	enqueue(db, webhooks.EventSetLogged, setPayload{
+		ID:                set.ID,
+		WorkoutID:         we.WorkoutID,
+		WorkoutExerciseID: we.ID,
+		Exercise:          we.Exercise.Name,
+		Reps:              set.Reps,
+		Weight:            set.Weight,
+		SetType:           set.Type,
+	})
+}
+
+// notifyFinished queues workout.finished with the workout's totals, and
+// pr.achieved for each record it set
+func notifyFinished(c *fiber.Ctx, workoutID int64) {
+	db := middleware.GetDB(c)
+	finished := subscribed(db, webhooks.EventWorkoutFinished)
+	prs := subscribed(db, webhooks.EventPRAchieved)
+	if !finished && !prs {
+		return
+	}
+
+	summary, err := ComputeFinishSummary(db, workoutID)
+	if err != nil {
+		payloadFailed(webhooks.EventWorkoutFinished, err)
+		return
+	}
+	if summary == nil {
+		return
+	}
+
+	if finished {
+		payload := finishedPayload{
+			ID:              summary.Workout.ID,
+			Name:            summary.Workout.Name,
+			Date:            summary.Workout.Date.Format(dates.Layout),
+			DurationSeconds: int64(summary.Duration.Seconds()),
+			TotalVolume:     summary.TotalVolume,
+			SetCount:        summary.SetCount,
+			RepCount:        summary.RepCount,
+			PRCount:         summary.PRCount,
+			Exercises:       []exercisePayload{},
+		}
+		for _, e := range summary.Exercises {
+			payload.Exercises = append(payload.Exercises, exercisePayload{
+				Name:      e.Name,
+				SetCount:  e.SetCount,
+				RepCount:  e.RepCount,
+				Volume:    e.Volume,
+				TopWeight: e.TopWeight,
+			})
+		}
+		enqueue(db, webhooks.EventWorkoutFinished, payload)
+	}
+
+	if prs {
+		for _, pr := range summary.PRs {
+			enqueue(db, webhooks.EventPRAchieved, prPayload{
+				WorkoutID: workoutID,
+				Exercise:  pr.Exercise,
+				Kind:      prKindNames[pr.Kind],
+				Value:     pr.Value,
+				Previous:  pr.Previous,
+			})
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package backoff
+
+import "time"
+
+// Delay returns how long to wait after the given number of failures in a
+// row: first after one failure, doubling with each failure after that, up
+// to limit
+func Delay(failures int, first, limit time.Duration) time.Duration {
+	delay := first
+	for i := 1; i < failures && delay < limit; i++ {
+		delay *= 2
+	}
+	return min(delay, limit)
+}
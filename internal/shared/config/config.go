@@ -0,0 +1,421 @@
+// Package config loads the server's settings. Each setting can come from a
+// TOML file, a PHOBOS_* environment variable or a flag; flags win over the
+// environment, which wins over the file, which wins over the defaults.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"phobos/internal/shared/cron"
+)
+
+// EnvPrefix starts the name of every environment variable read
+const EnvPrefix = "PHOBOS_"
+
+// Config is every setting of the server
+type Config struct {
+	Server   Server
+	DB       DB
+	Static   Static
+	Log      Log
+	Backup   Backup
+	Features Features
+	Webhooks Webhooks
+}
+
+// Server is how the server listens and answers
+type Server struct {
+	Addr       string // host:port; an empty host listens on every interface
+	ShowErrors bool   // Send internal error messages to clients instead of a generic one
+}
+
+// DB is where the database lives and how connections are set up
+type DB struct {
+	Path        string
+	JournalMode string // SQLite journal_mode; empty keeps SQLite's default
+	Synchronous string // SQLite synchronous; empty keeps SQLite's default
+	BusyTimeout time.Duration
+}
+
+// Static is where files served as they are come from
+type Static struct {
+	Dir       string // Served at /static; sw.js is served from here at the root
+	AssetsDir string // Served at /assets
+}
+
+// Log is how the server logs
+type Log struct {
+	Level  string // debug, info, warn or error
+	Format string // text or json
+}
+
+// Backup is how the database is backed up
+type Backup struct {
+	Enabled  bool
+	Dir      string
+	Schedule string // Cron expression; empty to only back up on demand
+	Keep     int    // 0 keeps every backup
+	Gzip     bool
+}
+
+// Features turns optional parts of the app on and off
+type Features struct {
+	Coaching bool // Coach links and the coach portal
+	Shares   bool // Public share links and import
+	Webhooks bool // Outgoing webhooks and their delivery
+}
+
+// Webhooks is where webhook deliveries may go
+type Webhooks struct {
+	// AllowNetworks lists IP addresses and CIDR ranges, separated by commas,
+	// that deliveries may reach even though they aren't public, such as a
+	// receiver on the local network. Empty allows public addresses only.
+	AllowNetworks string
+}
+
+// Default returns the settings used when nothing else is given
+func Default() Config {
+	return Config{
+		Server: Server{Addr: ":3000"},
+		DB:     DB{Path: "phobos.db", BusyTimeout: 5 * time.Second},
+		Static: Static{Dir: "static", AssetsDir: "assets"},
+		Log:    Log{Level: "info", Format: "text"},
+		Backup: Backup{
+			Enabled:  true,
+			Dir:      "backups",
+			Schedule: "0 2 * * *",
+			Keep:     7,
+		},
+		Features: Features{Coaching: true, Shares: true, Webhooks: true},
+	}
+}
+
+// field is one setting, found under the same name in every source
+type field struct {
+	key   string // section.name in the file; PHOBOS_SECTION_NAME in the environment
+	flag  string
+	usage string
+	ptr   any // *string, *bool, *int or *time.Duration
+}
+
+// fields lists the settings of c in the order they're printed
+func (c *Config) fields() []field {
+	return []field{
+		{"server.addr", "addr", "Address to listen on", &c.Server.Addr},
+		{"server.show_errors", "show-errors", "Send internal error messages to clients", &c.Server.ShowErrors},
+		{"db.path", "db", "Database file path", &c.DB.Path},
+		{"db.journal_mode", "db-journal-mode", "SQLite journal mode, such as wal", &c.DB.JournalMode},
+		{"db.synchronous", "db-synchronous", "SQLite synchronous setting, such as normal", &c.DB.Synchronous},
+		{"db.busy_timeout", "db-busy-timeout", "How long to wait for a locked database", &c.DB.BusyTimeout},
+		{"static.dir", "static-dir", "Directory served at /static", &c.Static.Dir},
+		{"static.assets_dir", "assets-dir", "Directory served at /assets", &c.Static.AssetsDir},
+		{"log.level", "log-level", "Log level: debug, info, warn or error", &c.Log.Level},
+		{"log.format", "log-format", "Log format: text or json", &c.Log.Format},
+		{"backup.enabled", "backup", "Back up the database on a schedule", &c.Backup.Enabled},
+		{"backup.dir", "backup-dir", "Directory for database backups", &c.Backup.Dir},
+		{"backup.schedule", "backup-schedule", "Cron schedule for backups; empty to only back up on demand", &c.Backup.Schedule},
+		{"backup.keep", "backup-keep", "Number of backups to keep; 0 keeps them all", &c.Backup.Keep},
+		{"backup.gzip", "backup-gzip", "Gzip backups", &c.Backup.Gzip},
+		{"features.coaching", "coaching", "Enable coach links and the coach portal", &c.Features.Coaching},
+		{"features.shares", "shares", "Enable public share links", &c.Features.Shares},
+		{"features.webhooks", "webhooks", "Enable outgoing webhooks", &c.Features.Webhooks},
+		{"webhooks.allow_networks", "webhook-allow-networks", "Comma-separated private IPs and CIDR ranges webhooks may be sent to", &c.Webhooks.AllowNetworks},
+	}
+}
+
+// envName returns the environment variable for a setting
+func (f field) envName() string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(f.key, ".", "_"))
+}
+
+// set parses s into the setting
+func (f field) set(s string) error {
+	switch p := f.ptr.(type) {
+	case *string:
+		*p = s
+	case *bool:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("%s must be true or false", f.key)
+		}
+		*p = v
+	case *int:
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("%s must be a whole number", f.key)
+		}
+		*p = v
+	case *time.Duration:
+		v, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("%s must be a duration such as 5s", f.key)
+		}
+		*p = v
+	}
+	return nil
+}
+
+// quoted reports whether the setting is written as a string in the file
+func (f field) quoted() bool {
+	switch f.ptr.(type) {
+	case *string, *time.Duration:
+		return true
+	}
+	return false
+}
+
+// format returns the setting as it would be written in the file
+func (f field) format() string {
+	switch p := f.ptr.(type) {
+	case *string:
+		return strconv.Quote(*p)
+	case *bool:
+		return strconv.FormatBool(*p)
+	case *int:
+		return strconv.Itoa(*p)
+	case *time.Duration:
+		return strconv.Quote(p.String())
+	}
+	return ""
+}
+
+// Load adds the config flags to fs, parses args with it and returns the
+// settings from every source. The file is named by -config or PHOBOS_CONFIG;
+// without one only the environment and flags are read. The settings are
+// validated before they're returned. lookupEnv is normally os.LookupEnv.
+func Load(fs *flag.FlagSet, args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := Default()
+	defaults := cfg.fields()
+
+	path := fs.String("config", "", "Config file path (or "+EnvPrefix+"CONFIG)")
+	port := fs.String("port", "", "Port to listen on; shorthand for -addr :PORT")
+
+	// Flags are only recorded while parsing so they can be applied last
+	var flagged []string
+	values := make(map[string]string)
+	for _, f := range defaults {
+		usage := f.usage + " (default " + f.format() + ")"
+		record := func(s string) error {
+			if _, ok := values[f.key]; !ok {
+				flagged = append(flagged, f.key)
+			}
+			values[f.key] = s
+			return nil
+		}
+		if _, ok := f.ptr.(*bool); ok {
+			fs.BoolFunc(f.flag, usage, record)
+		} else {
+			fs.Func(f.flag, usage, record)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *path == "" {
+		*path, _ = lookupEnv(EnvPrefix + "CONFIG")
+	}
+	if *path != "" {
+		if err := cfg.readFile(*path); err != nil {
+			return nil, err
+		}
+	}
+
+	var errs []error
+	for _, f := range cfg.fields() {
+		if s, ok := lookupEnv(f.envName()); ok {
+			if err := f.set(s); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", f.envName(), err))
+			}
+		}
+	}
+
+	if *port != "" {
+		if n, err := strconv.Atoi(*port); err != nil || n < 1 || n > 65535 {
+			errs = append(errs, fmt.Errorf("-port: %q must be a number from 1 to 65535", *port))
+		} else {
+			cfg.Server.Addr = ":" + strconv.Itoa(n)
+		}
+	}
+	fields := cfg.fields()
+	for _, key := range flagged {
+		i := slices.IndexFunc(fields, func(f field) bool { return f.key == key })
+		if err := fields[i].set(values[key]); err != nil {
+			errs = append(errs, fmt.Errorf("-%s: %w", fields[i].flag, err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// readFile applies the settings in a TOML config file
+func (c *Config) readFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	entries, err := parseTOML(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	fields := c.fields()
+	var errs []error
+	for _, e := range entries {
+		i := slices.IndexFunc(fields, func(f field) bool { return f.key == e.key })
+		if i < 0 {
+			errs = append(errs, fmt.Errorf("%s:%d: unknown setting %s", path, e.line, e.key))
+			continue
+		}
+		f := fields[i]
+		if e.quoted != f.quoted() {
+			if f.quoted() {
+				errs = append(errs, fmt.Errorf("%s:%d: %s must be a quoted string", path, e.line, e.key))
+			} else {
+				errs = append(errs, fmt.Errorf("%s:%d: %s must not be quoted", path, e.line, e.key))
+			}
+			continue
+		}
+		if err := f.set(e.value); err != nil {
+			errs = append(errs, fmt.Errorf("%s:%d: %w", path, e.line, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+var (
+	logLevels    = []string{"debug", "info", "warn", "error"}
+	logFormats   = []string{"text", "json"}
+	journalModes = []string{"", "delete", "truncate", "persist", "memory", "wal", "off"}
+	syncModes    = []string{"", "off", "normal", "full", "extra"}
+)
+
+// Validate returns every problem with the settings joined into one error
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if _, port, err := net.SplitHostPort(c.Server.Addr); err != nil || port == "" {
+		invalid("server.addr %q must be host:port", c.Server.Addr)
+	}
+	if c.DB.Path == "" {
+		invalid("db.path is required")
+	}
+	if !slices.Contains(journalModes, strings.ToLower(c.DB.JournalMode)) {
+		invalid("db.journal_mode %q must be one of %s", c.DB.JournalMode, strings.Join(journalModes[1:], ", "))
+	}
+	if !slices.Contains(syncModes, strings.ToLower(c.DB.Synchronous)) {
+		invalid("db.synchronous %q must be one of %s", c.DB.Synchronous, strings.Join(syncModes[1:], ", "))
+	}
+	if c.DB.BusyTimeout < 0 {
+		invalid("db.busy_timeout must not be negative")
+	}
+	if c.Static.Dir == "" || c.Static.AssetsDir == "" {
+		invalid("static.dir and static.assets_dir are required")
+	}
+	if !slices.Contains(logLevels, c.Log.Level) {
+		invalid("log.level %q must be one of %s", c.Log.Level, strings.Join(logLevels, ", "))
+	}
+	if !slices.Contains(logFormats, c.Log.Format) {
+		invalid("log.format %q must be one of %s", c.Log.Format, strings.Join(logFormats, ", "))
+	}
+	if c.Backup.Dir == "" {
+		invalid("backup.dir is required")
+	}
+	if c.Backup.Keep < 0 {
+		invalid("backup.keep must not be negative")
+	}
+	if c.Backup.Schedule != "" {
+		if _, err := cron.Parse(c.Backup.Schedule); err != nil {
+			invalid("backup.schedule: %v", err)
+		}
+	}
+	if _, err := parseNetworks(c.Webhooks.AllowNetworks); err != nil {
+		invalid("webhooks.allow_networks: %v", err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Pragmas returns the SQLite pragmas each database connection starts with
+func (d DB) Pragmas() []string {
+	var pragmas []string
+	if d.JournalMode != "" {
+		pragmas = append(pragmas, "journal_mode("+strings.ToLower(d.JournalMode)+")")
+	}
+	if d.Synchronous != "" {
+		pragmas = append(pragmas, "synchronous("+strings.ToLower(d.Synchronous)+")")
+	}
+	if d.BusyTimeout > 0 {
+		pragmas = append(pragmas, "busy_timeout("+strconv.FormatInt(d.BusyTimeout.Milliseconds(), 10)+")")
+	}
+	return pragmas
+}
+
+// Networks returns the ranges webhook deliveries may reach besides public
+// addresses. The setting is checked by Validate, so it parses.
+func (w Webhooks) Networks() []netip.Prefix {
+	networks, _ := parseNetworks(w.AllowNetworks)
+	return networks
+}
+
+// parseNetworks parses a comma-separated list of CIDR ranges and IP
+// addresses, taking an address as a range of its own
+func parseNetworks(s string) ([]netip.Prefix, error) {
+	var networks []netip.Prefix
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(item); err == nil {
+			networks = append(networks, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IP address or CIDR range", item)
+		}
+		networks = append(networks, prefix.Masked())
+	}
+	return networks, nil
+}
+
+// Write writes the settings as a config file Load can read back
+func (c *Config) Write(w io.Writer) error {
+	section := ""
+	for _, f := range c.fields() {
+		s, name, _ := strings.Cut(f.key, ".")
+		if s != section {
+			if section != "" {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "[%s]\n", s)
+			section = s
+		}
+		if _, err := fmt.Fprintf(w, "%s = %s\n", name, f.format()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
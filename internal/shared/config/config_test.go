@@ -0,0 +1,236 @@
+package config_test
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"phobos/internal/shared/config"
+)
+
+// load runs config.Load with a fresh flag set and the given environment
+func load(t *testing.T, args []string, env map[string]string) (*config.Config, error) {
+	t.Helper()
+
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return config.Load(fs, args, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	})
+}
+
+// writeFile writes a config file and returns its path
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "phobos.toml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := load(t, nil, nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if *cfg != config.Default() {
+		t.Errorf("expected the defaults, got %+v", cfg)
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	t.Parallel()
+
+	path := writeFile(t, `
+# Production settings
+[server]
+addr = "127.0.0.1:8080"
+
+[db]
+path = '/var/lib/phobos/phobos.db'
+journal_mode = "wal" # readers don't block the writer
+busy_timeout = "10s"
+
+[backup]
+keep = 14
+gzip = true
+schedule = "0 4 * * *"
+
+[features]
+webhooks = false
+`)
+
+	cfg, err := load(t,
+		[]string{"-config", path, "-backup-keep", "3", "-shares=false", "-port", "4000"},
+		map[string]string{
+			"PHOBOS_BACKUP_KEEP":     "30",
+			"PHOBOS_BACKUP_SCHEDULE": "",
+			"PHOBOS_LOG_FORMAT":      "json",
+		},
+	)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if cfg.DB.Path != "/var/lib/phobos/phobos.db" || cfg.DB.JournalMode != "wal" || cfg.DB.BusyTimeout != 10*time.Second {
+		t.Errorf("expected the database settings from the file, got %+v", cfg.DB)
+	}
+	if !cfg.Backup.Gzip || cfg.Features.Webhooks {
+		t.Error("expected the file to turn on gzip and turn off webhooks")
+	}
+	if cfg.Backup.Schedule != "" || cfg.Log.Format != "json" {
+		t.Error("expected the environment to override the file, even with an empty value")
+	}
+	if cfg.Backup.Keep != 3 || cfg.Features.Shares || cfg.Server.Addr != ":4000" {
+		t.Errorf("expected flags to override everything, got keep %d, shares %v, addr %q", cfg.Backup.Keep, cfg.Features.Shares, cfg.Server.Addr)
+	}
+	if !cfg.Features.Coaching || cfg.Log.Level != "info" {
+		t.Error("expected settings given nowhere to keep their defaults")
+	}
+}
+
+func TestLoad_ConfigFromEnvironment(t *testing.T) {
+	t.Parallel()
+
+	path := writeFile(t, "[static]\ndir = \"/srv/static\"\n")
+
+	cfg, err := load(t, []string{"-addr", "localhost:9000", "-port", "4000"}, map[string]string{"PHOBOS_CONFIG": path})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Static.Dir != "/srv/static" {
+		t.Errorf("expected the file named by PHOBOS_CONFIG to be read, got %q", cfg.Static.Dir)
+	}
+	if cfg.Server.Addr != "localhost:9000" {
+		t.Errorf("expected -addr to win over -port, got %q", cfg.Server.Addr)
+	}
+}
+
+func TestLoad_FileErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"[server]\nport = 3000\n", "phobos.toml:2: unknown setting server.port"},
+		{"[backup]\nkeep = \"7\"\n", "phobos.toml:2: backup.keep must not be quoted"},
+		{"[db]\n\nbusy_timeout = 5\n", "phobos.toml:3: db.busy_timeout must be a quoted string"},
+		{"[db]\nbusy_timeout = \"soon\"\n", "phobos.toml:2: db.busy_timeout must be a duration"},
+		{"[log\n", "line 1: invalid section header"},
+		{"[log]\nlevel\n", "line 2: expected key = value"},
+		{"[log]\nlevel = \"debug\n", "line 2: unterminated string"},
+		{"[log]\nlevel = \"debug\" extra\n", "line 2: unexpected extra after value"},
+		{"[log]\nlevel = \"debug\"\nlevel = \"info\"\n", "line 3: log.level is set twice"},
+	}
+	for _, tt := range tests {
+		_, err := load(t, []string{"-config", writeFile(t, tt.content)}, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: expected error containing %q, got %v", tt.content, tt.want, err)
+		}
+	}
+}
+
+func TestLoad_Validation(t *testing.T) {
+	t.Parallel()
+
+	_, err := load(t,
+		[]string{"-log-level", "verbose", "-db-journal-mode", "fast", "-addr", "3000", "-backup-keep", "-1"},
+		map[string]string{"PHOBOS_FEATURES_SHARES": "maybe"},
+	)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.Contains(err.Error(), "PHOBOS_FEATURES_SHARES: features.shares must be true or false") {
+		t.Errorf("expected the bad environment variable to be reported, got %v", err)
+	}
+
+	_, err = load(t, []string{"-log-level", "verbose", "-db-journal-mode", "fast", "-addr", "3000", "-backup-keep", "-1", "-backup-schedule", "0 25 * * *", "-webhook-allow-networks", "192.168.1.0/33"}, nil)
+	for _, want := range []string{"log.level", "db.journal_mode", "server.addr", "backup.keep", "backup.schedule", "webhooks.allow_networks"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %s to be reported, got %v", want, err)
+		}
+	}
+}
+
+func TestWebhooks_Networks(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := load(t, nil, map[string]string{"PHOBOS_WEBHOOKS_ALLOW_NETWORKS": "192.168.1.0/24, 10.0.0.5,fd00::1/64"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := []netip.Prefix{
+		netip.MustParsePrefix("192.168.1.0/24"),
+		netip.MustParsePrefix("10.0.0.5/32"),
+		netip.MustParsePrefix("fd00::/64"),
+	}
+	if got := cfg.Webhooks.Networks(); !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if got := config.Default().Webhooks.Networks(); len(got) != 0 {
+		t.Errorf("expected no networks allowed by default, got %v", got)
+	}
+}
+
+func TestLoad_InvalidPort(t *testing.T) {
+	t.Parallel()
+
+	for _, port := range []string{"abc", "0", "99999"} {
+		_, err := load(t, []string{"-port", port}, nil)
+		if err == nil || !strings.Contains(err.Error(), "-port") {
+			t.Errorf("expected -port %s to be reported, got %v", port, err)
+		}
+	}
+}
+
+func TestWrite_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := load(t, []string{"-db", `C:\data\"phobos".db`, "-backup-schedule", "", "-db-busy-timeout", "1m30s", "-coaching=false"}, nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := cfg.Write(&buf); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if !strings.Contains(buf.String(), "[backup]\nenabled = true\n") {
+		t.Errorf("expected settings grouped in sections, got:\n%s", buf.String())
+	}
+
+	again, err := load(t, []string{"-config", writeFile(t, buf.String())}, nil)
+	if err != nil {
+		t.Fatalf("Load of written config: %v", err)
+	}
+	if *again != *cfg {
+		t.Errorf("expected the written config to load the same settings, got %+v, want %+v", again, cfg)
+	}
+}
+
+func TestPragmas(t *testing.T) {
+	t.Parallel()
+
+	db := config.DB{JournalMode: "WAL", Synchronous: "normal", BusyTimeout: 2500 * time.Millisecond}
+	got := strings.Join(db.Pragmas(), " ")
+	if got != "journal_mode(wal) synchronous(normal) busy_timeout(2500)" {
+		t.Errorf("unexpected pragmas %q", got)
+	}
+	if len(config.DB{}.Pragmas()) != 0 {
+		t.Error("expected no pragmas when nothing is set")
+	}
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// entry is one key = value line of a config file
+type entry struct {
+	key    string // section.name
+	value  string // Unquoted for strings, as written otherwise
+	quoted bool
+	line   int
+}
+
+var (
+	sectionPattern = regexp.MustCompile(`^\[([A-Za-z0-9_-]+)\]$`)
+	keyPattern     = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// parseTOML reads the subset of TOML the config file needs: [section]
+// headers, comments, and key = value pairs whose values are strings,
+// integers or booleans. Keys before the first section have no section.
+func parseTOML(r io.Reader) ([]entry, error) {
+	var entries []entry
+	section := ""
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			m := sectionPattern.FindStringSubmatch(stripComment(line))
+			if m == nil {
+				return nil, fmt.Errorf("line %d: invalid section header", n)
+			}
+			section = m[1]
+			continue
+		}
+
+		name, rest, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !keyPattern.MatchString(name) {
+			return nil, fmt.Errorf("line %d: expected key = value", n)
+		}
+
+		e := entry{key: name, line: n}
+		if section != "" {
+			e.key = section + "." + name
+		}
+		if seen[e.key] {
+			return nil, fmt.Errorf("line %d: %s is set twice", n, e.key)
+		}
+		seen[e.key] = true
+
+		value, quoted, err := parseValue(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		e.value, e.quoted = value, quoted
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// parseValue reads a value and any trailing comment
+func parseValue(s string) (value string, quoted bool, err error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		end := closingQuote(s)
+		if end < 0 {
+			return "", false, fmt.Errorf("unterminated string")
+		}
+		value, err = strconv.Unquote(s[:end+1])
+		if err != nil {
+			return "", false, fmt.Errorf("invalid string %s", s[:end+1])
+		}
+		return value, true, trailing(s[end+1:])
+	case strings.HasPrefix(s, "'"):
+		end := strings.Index(s[1:], "'")
+		if end < 0 {
+			return "", false, fmt.Errorf("unterminated string")
+		}
+		return s[1 : end+1], true, trailing(s[end+2:])
+	}
+
+	value = strings.TrimSpace(stripComment(s))
+	switch {
+	case value == "":
+		return "", false, fmt.Errorf("missing value")
+	case value == "true" || value == "false":
+		return value, false, nil
+	}
+	if _, err := strconv.ParseInt(strings.ReplaceAll(value, "_", ""), 10, 64); err != nil {
+		return "", false, fmt.Errorf("unsupported value %s", value)
+	}
+	return strings.ReplaceAll(value, "_", ""), false, nil
+}
+
+// closingQuote returns the index of the quote ending the basic string at the
+// start of s, or -1
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// trailing checks that nothing but a comment follows a value
+func trailing(s string) error {
+	if rest := strings.TrimSpace(s); rest != "" && !strings.HasPrefix(rest, "#") {
+		return fmt.Errorf("unexpected %s after value", rest)
+	}
+	return nil
+}
+
+// stripComment drops a comment from a line without strings
+func stripComment(s string) string {
+	if i := strings.Index(s, "#"); i >= 0 {
+		return strings.TrimSpace(s[:i])
+	}
+	return s
+}
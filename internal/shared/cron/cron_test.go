@@ -0,0 +1,50 @@
+package cron_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"phobos/internal/shared/cron"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2024, 3, 12, 10, 7, 30, 0, time.UTC) // Tuesday
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 3, 12, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 3, 12, 10, 15, 0, 0, time.UTC)},
+		{"30 3 * * *", time.Date(2024, 3, 13, 3, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * 1-5", time.Date(2024, 3, 12, 13, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.Date(2024, 3, 13, 0, 0, 0, 0, time.UTC)}, // 13th or a Friday
+		{"@monthly", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", from.Add(90 * time.Second)},
+	}
+	for _, tt := range tests {
+		s, err := cron.Parse(tt.spec)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q: expected %v, got %v", tt.spec, tt.want, got)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "@every", "@every 10ms", "@often"} {
+		if _, err := cron.Parse(spec); !errors.Is(err, cron.ErrInvalidSchedule) {
+			t.Errorf("Parse(%q): expected ErrInvalidSchedule, got %v", spec, err)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package dates
+
+import "time"
+
+// Layout is the format used for DATE columns and date query parameters
+const Layout = "2006-01-02"
+
+// TimestampLayout is how DATETIME columns are stored: UTC, matching SQLite's
+// CURRENT_TIMESTAMP so stored times compare correctly as text
+const TimestampLayout = "2006-01-02 15:04:05"
+
+// Day returns midnight UTC of t's calendar day
+func Day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// WeekStart returns midnight UTC on the Monday of t's week
+func WeekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7 // Days since Monday
+	return Day(t.AddDate(0, 0, -offset))
+}
@@ -1,18 +1,49 @@
 package db
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 
 	"github.com/pressly/goose/v3"
-	_ "modernc.org/sqlite"
+	"modernc.org/sqlite"
 )
 
-// Open creates a new database connection
-func Open(path string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite", path+"?_foreign_keys=on")
+// sha256_hex(text) returns the hex SHA-256 of its argument, the way the app
+// hashes link tokens, so migrations can hash tokens stored in plaintext
+func init() {
+	sqlite.MustRegisterDeterministicScalarFunction("sha256_hex", 1, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		var b []byte
+		switch v := args[0].(type) {
+		case nil:
+			return nil, nil
+		case string:
+			b = []byte(v)
+		case []byte:
+			b = v
+		default:
+			b = []byte(fmt.Sprint(v))
+		}
+		sum := sha256.Sum256(b)
+		return hex.EncodeToString(sum[:]), nil
+	})
+}
+
+// Open creates a new database connection. Each pragma, such as
+// "busy_timeout(5000)", is run on every new connection, after foreign keys
+// are turned on.
+func Open(path string, pragmas ...string) (*sql.DB, error) {
+	dsn := path + "?_pragma=foreign_keys(1)"
+	for _, p := range pragmas {
+		dsn += "&_pragma=" + url.QueryEscape(p)
+	}
+
+	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -18,6 +18,14 @@ func Render(c *fiber.Ctx, component templ.Component) error {
 	return handler(c)
 }
 
+// RenderStatus renders a templ component with a status other than 200, such
+// as a form shown again with a validation error
+func RenderStatus(c *fiber.Ctx, status int, component templ.Component) error {
+	c.Set("Content-Type", "text/html; charset=utf-8")
+	handler := adaptor.HTTPHandler(templ.Handler(component, templ.WithStatus(status)))
+	return handler(c)
+}
+
 // Redirect sends an HX-Redirect header for HTMX requests, or a standard redirect otherwise
 func Redirect(c *fiber.Ctx, path string) error {
 	if IsHTMX(c) {
@@ -0,0 +1,212 @@
+package middleware
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// IdempotencyHeader is the request header carrying an idempotency key
+	IdempotencyHeader = "Idempotency-Key"
+	// IdempotencyField is the form field alternative to the header, for plain forms
+	IdempotencyField = "idempotency_key"
+	// IdempotencyTTL is how long a stored response is replayed for its key
+	IdempotencyTTL = 24 * time.Hour
+	// IdempotencyReservationTTL is how long a key stays claimed by a request
+	// that never stored a response, such as one cut short by a crash
+	IdempotencyReservationTTL = time.Minute
+
+	maxIdempotencyKeyLength = 255
+)
+
+// replayedHeaders are the response headers stored and replayed with the body
+var replayedHeaders = []string{
+	fiber.HeaderContentType,
+	fiber.HeaderLocation,
+	"HX-Redirect",
+	"HX-Refresh",
+	"HX-Trigger",
+	"HX-Retarget",
+	"HX-Reswap",
+}
+
+// idempotency replays the stored response when a POST, PUT, PATCH or DELETE is
+// retried with a key it has already seen for the same method and path, so
+// double submissions and retries after a lost response don't repeat their
+// effect. Keys are scoped to the method and path, so the same key sent to
+// another endpoint is a new request. Requests without a key are handled as
+// usual. Server errors and panics aren't stored, so they can be retried.
+func idempotency(holder *dbHolder) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete:
+		default:
+			return c.Next()
+		}
+
+		key := c.Get(IdempotencyHeader)
+		if key == "" {
+			key = c.FormValue(IdempotencyField)
+		}
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return c.Status(fiber.StatusBadRequest).SendString("Idempotency key is too long")
+		}
+
+		db := holder.db
+		scope := idempotencyScope{key: key, method: c.Method(), path: c.Path()}
+		stored, err := getIdempotentResponse(db, scope)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString("Failed to check idempotency key")
+		}
+		if stored != nil {
+			if !stored.status.Valid {
+				return c.Status(fiber.StatusConflict).SendString("A request with this idempotency key is still in progress")
+			}
+			return replayResponse(c, stored)
+		}
+
+		reserved, err := reserveIdempotencyKey(db, scope)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString("Failed to store idempotency key")
+		}
+		if !reserved {
+			return c.Status(fiber.StatusConflict).SendString("A request with this idempotency key is still in progress")
+		}
+
+		// A panic skips the rest of this handler, so release the key on the
+		// way out and let the recover middleware answer
+		defer func() {
+			if r := recover(); r != nil {
+				releaseIdempotencyKey(db, scope)
+				panic(r)
+			}
+		}()
+
+		if err := c.Next(); err != nil {
+			releaseIdempotencyKey(db, scope)
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			releaseIdempotencyKey(db, scope)
+			return nil
+		}
+		if err := saveIdempotentResponse(db, scope, c); err != nil {
+			releaseIdempotencyKey(db, scope)
+		}
+		return nil
+	}
+}
+
+// idempotencyScope is an idempotency key and the request it was sent with
+type idempotencyScope struct {
+	key    string
+	method string
+	path   string
+}
+
+// idempotentResponse is a stored response for an idempotency key
+type idempotentResponse struct {
+	status  sql.NullInt64
+	headers sql.NullString
+	body    []byte
+}
+
+func getIdempotentResponse(db *sql.DB, s idempotencyScope) (*idempotentResponse, error) {
+	var r idempotentResponse
+	err := db.QueryRow(`
+		SELECT status, headers, body
+		FROM idempotency_keys
+		WHERE key = ? AND method = ? AND path = ? AND created_at > datetime('now', ?)
+		  AND (status IS NOT NULL OR created_at > datetime('now', ?))
+	`, s.key, s.method, s.path, ttlModifier(), reservationTTLModifier()).Scan(&r.status, &r.headers, &r.body)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	return &r, nil
+}
+
+// reserveIdempotencyKey claims a key for a request about to be handled,
+// clearing out expired keys and abandoned reservations first. It reports
+// false if another request claimed the key first.
+func reserveIdempotencyKey(db *sql.DB, s idempotencyScope) (bool, error) {
+	_, err := db.Exec(`
+		DELETE FROM idempotency_keys
+		WHERE created_at <= datetime('now', ?)
+		   OR (status IS NULL AND created_at <= datetime('now', ?))
+	`, ttlModifier(), reservationTTLModifier())
+	if err != nil {
+		return false, fmt.Errorf("failed to clear expired idempotency keys: %w", err)
+	}
+
+	result, err := db.Exec(`
+		INSERT INTO idempotency_keys (key, method, path) VALUES (?, ?, ?)
+		ON CONFLICT (key, method, path) DO NOTHING
+	`, s.key, s.method, s.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+func saveIdempotentResponse(db *sql.DB, s idempotencyScope, c *fiber.Ctx) error {
+	headers := make(map[string]string)
+	for _, name := range replayedHeaders {
+		if v := c.Response().Header.Peek(name); len(v) > 0 {
+			headers[name] = string(v)
+		}
+	}
+	encoded, err := json.Marshal(headers)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		UPDATE idempotency_keys SET status = ?, headers = ?, body = ?
+		WHERE key = ? AND method = ? AND path = ?
+	`, c.Response().StatusCode(), string(encoded), c.Response().Body(), s.key, s.method, s.path)
+	if err != nil {
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+	return nil
+}
+
+func releaseIdempotencyKey(db *sql.DB, s idempotencyScope) {
+	db.Exec(`DELETE FROM idempotency_keys WHERE key = ? AND method = ? AND path = ?`, s.key, s.method, s.path)
+}
+
+func replayResponse(c *fiber.Ctx, r *idempotentResponse) error {
+	if r.headers.Valid {
+		var headers map[string]string
+		if err := json.Unmarshal([]byte(r.headers.String), &headers); err == nil {
+			for name, v := range headers {
+				c.Set(name, v)
+			}
+		}
+	}
+	c.Set("Idempotent-Replayed", "true")
+	return c.Status(int(r.status.Int64)).Send(r.body)
+}
+
+// ttlModifier is the SQLite datetime modifier for the start of the TTL window
+func ttlModifier() string {
+	return fmt.Sprintf("-%d seconds", int(IdempotencyTTL.Seconds()))
+}
+
+// reservationTTLModifier is the SQLite datetime modifier for the oldest
+// reservation still honoured
+func reservationTTLModifier() string {
+	return fmt.Sprintf("-%d seconds", int(IdempotencyReservationTTL.Seconds()))
+}
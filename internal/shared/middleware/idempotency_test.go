@@ -0,0 +1,140 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"phobos/internal/shared/middleware"
+	"phobos/internal/testutil"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// countingApp is a test app with a POST /test route that counts its calls and
+// answers with the given status, or panics, for each call in turn
+func countingApp(t *testing.T, outcomes ...int) (*testutil.TestApp, *int) {
+	t.Helper()
+	app := testutil.NewTestApp(t)
+
+	calls := 0
+	app.App.Post("/test", func(c *fiber.Ctx) error {
+		calls++
+		status := fiber.StatusCreated
+		if calls <= len(outcomes) {
+			status = outcomes[calls-1]
+		}
+		if status == 0 {
+			panic("handler failed")
+		}
+		return c.Status(status).SendString("call " + strconv.Itoa(calls))
+	})
+	return app, &calls
+}
+
+func TestIdempotency_ReplaysResponse(t *testing.T) {
+	t.Parallel()
+	app, calls := countingApp(t)
+	defer app.Close()
+
+	first := app.Request(http.MethodPost, "/test", "idempotency_key=k1")
+	second := app.Request(http.MethodPost, "/test", "idempotency_key=k1")
+
+	if *calls != 1 {
+		t.Errorf("expected the handler to run once, ran %d times", *calls)
+	}
+	if second.StatusCode != http.StatusCreated || second.Header.Get("Idempotent-Replayed") != "true" {
+		t.Errorf("expected a replayed 201, got %d", second.StatusCode)
+	}
+	if a, b := testutil.ReadBody(t, first), testutil.ReadBody(t, second); a != b {
+		t.Errorf("expected the replay to match the original, got %q and %q", a, b)
+	}
+
+	// Requests without a key are never replayed
+	app.Request(http.MethodPost, "/test", "")
+	if *calls != 2 {
+		t.Errorf("expected a request without a key to run, ran %d times", *calls)
+	}
+}
+
+func TestIdempotency_InFlight(t *testing.T) {
+	t.Parallel()
+	app, calls := countingApp(t)
+	defer app.Close()
+
+	app.DB.Exec(`INSERT INTO idempotency_keys (key, method, path) VALUES ('k1', 'POST', '/test')`)
+
+	resp := app.Request(http.MethodPost, "/test", "idempotency_key=k1")
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected status 409 while the key is reserved, got %d", resp.StatusCode)
+	}
+	if *calls != 0 {
+		t.Errorf("expected the handler not to run, ran %d times", *calls)
+	}
+
+	// A reservation that never got a response is abandoned after a while
+	app.DB.Exec(`UPDATE idempotency_keys SET created_at = datetime('now', '-2 minutes')`)
+	resp = app.Request(http.MethodPost, "/test", "idempotency_key=k1")
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("expected status 201 after the reservation expired, got %d", resp.StatusCode)
+	}
+}
+
+func TestIdempotency_ServerErrorReleasesKey(t *testing.T) {
+	t.Parallel()
+	app, calls := countingApp(t, fiber.StatusInternalServerError)
+	defer app.Close()
+
+	resp := app.Request(http.MethodPost, "/test", "idempotency_key=k1")
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected status 500, got %d", resp.StatusCode)
+	}
+
+	resp = app.Request(http.MethodPost, "/test", "idempotency_key=k1")
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get("Idempotent-Replayed") != "" {
+		t.Errorf("expected the retry to run, got %d", resp.StatusCode)
+	}
+	if *calls != 2 {
+		t.Errorf("expected the handler to run twice, ran %d times", *calls)
+	}
+}
+
+func TestIdempotency_PanicReleasesKey(t *testing.T) {
+	t.Parallel()
+	app, calls := countingApp(t, 0)
+	defer app.Close()
+
+	resp := app.Request(http.MethodPost, "/test", "idempotency_key=k1")
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected status 500, got %d", resp.StatusCode)
+	}
+
+	resp = app.Request(http.MethodPost, "/test", "idempotency_key=k1")
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("expected the retry to run, got %d", resp.StatusCode)
+	}
+	if *calls != 2 {
+		t.Errorf("expected the handler to run twice, ran %d times", *calls)
+	}
+}
+
+func TestIdempotency_KeyTooLong(t *testing.T) {
+	t.Parallel()
+	app, calls := countingApp(t)
+	defer app.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/test", nil)
+	req.Header.Set(middleware.IdempotencyHeader, strings.Repeat("k", 256))
+	resp, err := app.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", resp.StatusCode)
+	}
+	if *calls != 0 {
+		t.Errorf("expected the handler not to run, ran %d times", *calls)
+	}
+}
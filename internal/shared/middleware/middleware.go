@@ -3,6 +3,8 @@ package middleware
 import (
 	"database/sql"
 
+	"phobos/internal/shared/pubsub"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
@@ -14,8 +16,9 @@ type dbHolder struct {
 	db *sql.DB
 }
 
-// Setup configures all middleware for the application
-func Setup(app *fiber.App, db *sql.DB) {
+// Setup configures all middleware for the application. The broker carries
+// live updates to pages open on other devices.
+func Setup(app *fiber.App, db *sql.DB, broker *pubsub.Broker) {
 	holder := &dbHolder{db: db}
 
 	// Recovery middleware
@@ -29,11 +32,20 @@ func Setup(app *fiber.App, db *sql.DB) {
 	// Store database holder in context (not the db directly)
 	app.Use(func(c *fiber.Ctx) error {
 		c.Locals("dbHolder", holder)
+		c.Locals("broker", broker)
 		return c.Next()
 	})
+
+	// Replay responses to retried mutating requests
+	app.Use(idempotency(holder))
 }
 
 // GetDB retrieves the database from context
 func GetDB(c *fiber.Ctx) *sql.DB {
 	return c.Locals("dbHolder").(*dbHolder).db
 }
+
+// GetBroker retrieves the live update broker from context
+func GetBroker(c *fiber.Ctx) *pubsub.Broker {
+	return c.Locals("broker").(*pubsub.Broker)
+}
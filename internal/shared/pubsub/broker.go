@@ -0,0 +1,86 @@
+package pubsub
+
+import "sync"
+
+// subscriberBuffer is how many messages a subscriber can fall behind before
+// further messages to it are dropped
+const subscriberBuffer = 16
+
+// Message is an event pushed to subscribers of a topic
+type Message struct {
+	Event string // Event name, used by the client to pick where the data goes
+	Data  string // Usually an HTML fragment
+}
+
+// Broker fans messages out to the subscribers of a topic. It is in-process
+// only: subscribers connected to another server instance don't see messages.
+type Broker struct {
+	mu     sync.Mutex
+	topics map[string]map[*subscriber]struct{}
+}
+
+type subscriber struct {
+	id string
+	ch chan Message
+}
+
+// NewBroker returns an empty broker
+func NewBroker() *Broker {
+	return &Broker{topics: make(map[string]map[*subscriber]struct{})}
+}
+
+// Subscribe registers a subscriber to a topic. The id lets a publisher skip
+// the subscriber that caused a change; it may be empty. The returned function
+// unsubscribes and closes the channel.
+func (b *Broker) Subscribe(topic, id string) (<-chan Message, func()) {
+	s := &subscriber{id: id, ch: make(chan Message, subscriberBuffer)}
+
+	b.mu.Lock()
+	if b.topics[topic] == nil {
+		b.topics[topic] = make(map[*subscriber]struct{})
+	}
+	b.topics[topic][s] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return s.ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.topics[topic], s)
+			if len(b.topics[topic]) == 0 {
+				delete(b.topics, topic)
+			}
+			b.mu.Unlock()
+			close(s.ch)
+		})
+	}
+}
+
+// HasSubscribers reports whether anyone other than exclude is subscribed to a
+// topic, so publishers can skip rendering messages nobody will receive
+func (b *Broker) HasSubscribers(topic, exclude string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.topics[topic] {
+		if exclude == "" || s.id != exclude {
+			return true
+		}
+	}
+	return false
+}
+
+// Publish sends a message to every subscriber of a topic except the one with
+// the excluded id. It never blocks: subscribers too far behind miss the message.
+func (b *Broker) Publish(topic string, msg Message, exclude string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.topics[topic] {
+		if exclude != "" && s.id == exclude {
+			continue
+		}
+		select {
+		case s.ch <- msg:
+		default:
+		}
+	}
+}
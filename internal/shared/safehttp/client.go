@@ -0,0 +1,94 @@
+package safehttp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrNotPublic is returned when a request would connect to an address that
+// isn't on the public internet
+var ErrNotPublic = errors.New("address is not public")
+
+// nonPublic are ranges that aren't covered by the netip.Addr checks in
+// IsPublic but still must not be reached
+var nonPublic = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "This network"
+	netip.MustParsePrefix("100.64.0.0/10"),   // Carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // Documentation
+	netip.MustParsePrefix("198.18.0.0/15"),   // Benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // Documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // Documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // Reserved, and broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64, which can reach private IPv4
+	netip.MustParsePrefix("2001:db8::/32"),   // Documentation
+}
+
+// IsPublic reports whether addr is a unicast address on the public internet.
+// Loopback, private, link-local, multicast and reserved addresses are not.
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, p := range nonPublic {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewClient returns a client for requests to URLs given by users, such as
+// share links and webhooks. It only connects to public addresses, checked
+// after DNS resolution so a name can't point it at the local network, and
+// it doesn't follow redirects: the redirect response is returned instead.
+// Addresses in allow may be reached too, for hosts the server's operator
+// trusts, such as a receiver on their own network.
+func NewClient(timeout time.Duration, allow ...netip.Prefix) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: checkAddress(allow)}
+	return &http.Client{
+		Timeout: timeout,
+		// No proxy, since the proxy would be dialled instead of the target
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// checkAddress returns a dial control that refuses connections to addresses
+// that aren't public or in allow. It runs for every connection attempt, with
+// the address DNS resolved to.
+func checkAddress(allow []netip.Prefix) func(network, address string, _ syscall.RawConn) error {
+	return func(network, address string, _ syscall.RawConn) error {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		addr, err := netip.ParseAddr(host)
+		if err != nil {
+			return err
+		}
+		addr = addr.Unmap()
+		if IsPublic(addr) {
+			return nil
+		}
+		for _, p := range allow {
+			if p.Contains(addr) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s", ErrNotPublic, addr)
+	}
+}
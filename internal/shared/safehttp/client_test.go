@@ -0,0 +1,84 @@
+package safehttp_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"phobos/internal/shared/safehttp"
+)
+
+func TestIsPublic(t *testing.T) {
+	t.Parallel()
+
+	for addr, want := range map[string]bool{
+		"93.184.216.34":        true,
+		"2606:4700::1111":      true,
+		"127.0.0.1":            false,
+		"10.1.2.3":             false,
+		"172.16.0.1":           false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false,
+		"100.64.0.1":           false,
+		"0.0.0.0":              false,
+		"255.255.255.255":      false,
+		"224.0.0.1":            false,
+		"::1":                  false,
+		"fd00::1":              false,
+		"fe80::1":              false,
+		"::ffff:127.0.0.1":     false,
+		"::ffff:93.184.216.34": true,
+		"64:ff9b::a00:1":       false,
+	} {
+		if got := safehttp.IsPublic(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("%s: expected %v, got %v", addr, want, got)
+		}
+	}
+}
+
+func TestNewClient_RefusesLoopback(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("expected the request not to reach the server")
+	}))
+	defer server.Close()
+
+	_, err := safehttp.NewClient(time.Second).Get(server.URL)
+	if !errors.Is(err, safehttp.ErrNotPublic) {
+		t.Errorf("expected ErrNotPublic, got %v", err)
+	}
+}
+
+func TestNewClient_AllowedNetworks(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	resp, err := safehttp.NewClient(time.Second, netip.MustParsePrefix("127.0.0.0/8")).Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected an allowed network to be reached, got %v", err)
+	}
+	resp.Body.Close()
+
+	_, err = safehttp.NewClient(time.Second, netip.MustParsePrefix("10.0.0.0/8")).Get(server.URL)
+	if !errors.Is(err, safehttp.ErrNotPublic) {
+		t.Errorf("expected other private addresses to stay refused, got %v", err)
+	}
+}
+
+func TestNewClient_DoesNotFollowRedirects(t *testing.T) {
+	t.Parallel()
+
+	client := safehttp.NewClient(time.Second)
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/next", nil)
+	if err := client.CheckRedirect(req, []*http.Request{req}); err != http.ErrUseLastResponse {
+		t.Errorf("expected redirects to be returned rather than followed, got %v", err)
+	}
+}
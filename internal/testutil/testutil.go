@@ -8,12 +8,25 @@ import (
 	"strings"
 	"testing"
 
+	"phobos/internal/features/backups"
+	"phobos/internal/features/calendar"
+	"phobos/internal/features/coaching"
 	"phobos/internal/features/exercises"
 	"phobos/internal/features/home"
+	"phobos/internal/features/jobs"
+	"phobos/internal/features/metrics"
+	"phobos/internal/features/notifications"
+	"phobos/internal/features/plates"
+	"phobos/internal/features/reports"
 	"phobos/internal/features/routines"
+	"phobos/internal/features/search"
+	"phobos/internal/features/shares"
+	"phobos/internal/features/strength"
 	"phobos/internal/features/templates"
+	"phobos/internal/features/webhooks"
 	"phobos/internal/features/workouts"
 	"phobos/internal/shared/middleware"
+	"phobos/internal/shared/pubsub"
 
 	"github.com/gofiber/fiber/v2"
 	_ "modernc.org/sqlite"
@@ -21,8 +34,10 @@ import (
 
 // TestApp holds a test application with an in-memory database
 type TestApp struct {
-	App *fiber.App
-	DB  *sql.DB
+	App    *fiber.App
+	DB     *sql.DB
+	Broker *pubsub.Broker
+	Backup backups.Config // Backups go to a temporary directory
 }
 
 // NewTestApp creates a new test application with an in-memory database
@@ -32,7 +47,7 @@ func NewTestApp(t *testing.T) *TestApp {
 	// Create in-memory database
 	// Set max open connections to 1 to ensure all operations use the same connection
 	// (with :memory:, each connection gets a separate database)
-	db, err := sql.Open("sqlite", ":memory:?_foreign_keys=on")
+	db, err := sql.Open("sqlite", ":memory:?_pragma=foreign_keys(1)")
 	if err != nil {
 		t.Fatalf("failed to open database: %v", err)
 	}
@@ -55,7 +70,8 @@ func NewTestApp(t *testing.T) *TestApp {
 	})
 
 	// Setup middleware
-	middleware.Setup(app, db)
+	broker := pubsub.NewBroker()
+	middleware.Setup(app, db, broker)
 
 	// Register all routes
 	home.RegisterRoutes(app)
@@ -63,10 +79,25 @@ func NewTestApp(t *testing.T) *TestApp {
 	templates.RegisterRoutes(app)
 	workouts.RegisterRoutes(app)
 	routines.RegisterRoutes(app)
+	reports.RegisterRoutes(app)
+	calendar.RegisterRoutes(app)
+	search.RegisterRoutes(app)
+	metrics.RegisterRoutes(app)
+	strength.RegisterRoutes(app)
+	plates.RegisterRoutes(app)
+	notifications.RegisterRoutes(app)
+	coaching.RegisterRoutes(app)
+	shares.RegisterRoutes(app)
+	webhooks.RegisterRoutes(app)
+	jobs.RegisterRoutes(app)
+	backupConfig := backups.Config{Dir: t.TempDir(), Keep: 3}
+	backups.RegisterRoutes(app, backupConfig)
 
 	return &TestApp{
-		App: app,
-		DB:  db,
+		App:    app,
+		DB:     db,
+		Broker: broker,
+		Backup: backupConfig,
 	}
 }
 
@@ -182,6 +213,378 @@ func runMigrations(db *sql.DB) error {
 			position INTEGER NOT NULL,
 			UNIQUE(routine_id, position)
 		)`,
+		// 002_exercise_archiving
+		`ALTER TABLE exercises ADD COLUMN archived_at TIMESTAMP`,
+		// 003_exercise_details
+		`ALTER TABLE exercises ADD COLUMN notes TEXT`,
+		`ALTER TABLE exercises ADD COLUMN default_rest_seconds INTEGER CHECK (default_rest_seconds >= 0)`,
+		`ALTER TABLE exercises ADD COLUMN default_increment REAL CHECK (default_increment > 0)`,
+		`ALTER TABLE exercises ADD COLUMN media_url TEXT`,
+		// 004_exercise_tags
+		`CREATE TABLE exercise_muscle_groups (
+			exercise_id INTEGER NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+			muscle_group TEXT NOT NULL,
+			role TEXT NOT NULL CHECK (role IN ('primary', 'secondary')),
+			PRIMARY KEY (exercise_id, muscle_group)
+		)`,
+		`CREATE TABLE exercise_equipment (
+			exercise_id INTEGER NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+			equipment TEXT NOT NULL,
+			PRIMARY KEY (exercise_id, equipment)
+		)`,
+		// 005_volume_targets
+		`CREATE TABLE muscle_volume_targets (
+			muscle_group TEXT PRIMARY KEY,
+			mev INTEGER NOT NULL CHECK (mev >= 0),
+			mrv INTEGER NOT NULL CHECK (mrv >= mev)
+		)`,
+		`INSERT INTO muscle_volume_targets (muscle_group, mev, mrv) VALUES
+			('chest', 10, 22), ('back', 10, 25), ('traps', 4, 26), ('shoulders', 8, 26),
+			('biceps', 8, 26), ('triceps', 6, 18), ('forearms', 2, 25), ('abs', 0, 25),
+			('quads', 8, 20), ('hamstrings', 6, 20), ('glutes', 0, 16), ('calves', 8, 20)`,
+		// 006_search_index
+		`CREATE VIRTUAL TABLE search_index USING fts5(
+			kind UNINDEXED,
+			ref_id UNINDEXED,
+			title,
+			body,
+			tokenize = 'porter unicode61'
+		)`,
+		`CREATE TRIGGER workouts_search_insert AFTER INSERT ON workouts BEGIN
+			INSERT INTO search_index (kind, ref_id, title, body) VALUES ('workout', new.id, new.name, COALESCE(new.notes, ''));
+		END`,
+		`CREATE TRIGGER workouts_search_update AFTER UPDATE OF name, notes ON workouts BEGIN
+			DELETE FROM search_index WHERE kind = 'workout' AND ref_id = old.id;
+			INSERT INTO search_index (kind, ref_id, title, body) VALUES ('workout', new.id, new.name, COALESCE(new.notes, ''));
+		END`,
+		`CREATE TRIGGER workouts_search_delete AFTER DELETE ON workouts BEGIN
+			DELETE FROM search_index WHERE kind = 'workout' AND ref_id = old.id;
+		END`,
+		`CREATE TRIGGER workout_templates_search_insert AFTER INSERT ON workout_templates BEGIN
+			INSERT INTO search_index (kind, ref_id, title, body) VALUES ('template', new.id, new.name, '');
+		END`,
+		`CREATE TRIGGER workout_templates_search_update AFTER UPDATE OF name ON workout_templates BEGIN
+			DELETE FROM search_index WHERE kind = 'template' AND ref_id = old.id;
+			INSERT INTO search_index (kind, ref_id, title, body) VALUES ('template', new.id, new.name, '');
+		END`,
+		`CREATE TRIGGER workout_templates_search_delete AFTER DELETE ON workout_templates BEGIN
+			DELETE FROM search_index WHERE kind = 'template' AND ref_id = old.id;
+		END`,
+		`CREATE TRIGGER routines_search_insert AFTER INSERT ON routines BEGIN
+			INSERT INTO search_index (kind, ref_id, title, body) VALUES ('routine', new.id, new.name, '');
+		END`,
+		`CREATE TRIGGER routines_search_update AFTER UPDATE OF name ON routines BEGIN
+			DELETE FROM search_index WHERE kind = 'routine' AND ref_id = old.id;
+			INSERT INTO search_index (kind, ref_id, title, body) VALUES ('routine', new.id, new.name, '');
+		END`,
+		`CREATE TRIGGER routines_search_delete AFTER DELETE ON routines BEGIN
+			DELETE FROM search_index WHERE kind = 'routine' AND ref_id = old.id;
+		END`,
+		`CREATE TRIGGER exercises_search_insert AFTER INSERT ON exercises BEGIN
+			INSERT INTO search_index (kind, ref_id, title, body) VALUES ('exercise', new.id, new.name, COALESCE(new.notes, ''));
+		END`,
+		`CREATE TRIGGER exercises_search_update AFTER UPDATE OF name, notes ON exercises BEGIN
+			DELETE FROM search_index WHERE kind = 'exercise' AND ref_id = old.id;
+			INSERT INTO search_index (kind, ref_id, title, body) VALUES ('exercise', new.id, new.name, COALESCE(new.notes, ''));
+		END`,
+		`CREATE TRIGGER exercises_search_delete AFTER DELETE ON exercises BEGIN
+			DELETE FROM search_index WHERE kind = 'exercise' AND ref_id = old.id;
+		END`,
+		// 007_body_metrics
+		`CREATE TABLE body_metrics (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			date DATE NOT NULL,
+			metric TEXT NOT NULL,
+			value REAL NOT NULL CHECK (value > 0),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(date, metric)
+		)`,
+		`CREATE INDEX idx_body_metrics_metric_date ON body_metrics(metric, date)`,
+		// 008_strength
+		`CREATE TABLE strength_settings (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			sex TEXT NOT NULL CHECK (sex IN ('male', 'female')) DEFAULT 'male',
+			standards TEXT NOT NULL DEFAULT 'general'
+		)`,
+		`INSERT INTO strength_settings (id) VALUES (1)`,
+		`CREATE TABLE strength_lifts (
+			lift TEXT PRIMARY KEY,
+			exercise_id INTEGER REFERENCES exercises(id) ON DELETE SET NULL,
+			e1rm REAL,
+			weight REAL,
+			reps INTEGER,
+			date DATE,
+			workout_id INTEGER REFERENCES workouts(id) ON DELETE SET NULL
+		)`,
+		`INSERT INTO strength_lifts (lift) VALUES ('squat'), ('bench'), ('deadlift'), ('press')`,
+		// 009_workout_summaries
+		`CREATE TABLE workout_summaries (
+			workout_id INTEGER PRIMARY KEY REFERENCES workouts(id) ON DELETE CASCADE,
+			duration_seconds INTEGER NOT NULL,
+			total_volume REAL NOT NULL,
+			set_count INTEGER NOT NULL,
+			rep_count INTEGER NOT NULL,
+			pr_count INTEGER NOT NULL,
+			targets_met INTEGER NOT NULL,
+			targets_total INTEGER NOT NULL
+		)`,
+		// 010_workout_timing
+		`ALTER TABLE workouts ADD COLUMN started_at TIMESTAMP`,
+		`ALTER TABLE workouts ADD COLUMN ended_at TIMESTAMP`,
+		`ALTER TABLE workouts ADD COLUMN paused_at TIMESTAMP`,
+		`ALTER TABLE workouts ADD COLUMN paused_seconds INTEGER NOT NULL DEFAULT 0`,
+		// 011_plates_warmups
+		`ALTER TABLE logged_sets ADD COLUMN set_type TEXT NOT NULL DEFAULT 'working'
+			CHECK (set_type IN ('working', 'warmup'))`,
+		`CREATE TABLE plate_settings (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			bar_weight REAL NOT NULL CHECK (bar_weight >= 0) DEFAULT 45
+		)`,
+		`INSERT INTO plate_settings (id) VALUES (1)`,
+		`CREATE TABLE plate_inventory (
+			weight REAL PRIMARY KEY CHECK (weight > 0),
+			pairs INTEGER NOT NULL CHECK (pairs > 0)
+		)`,
+		`INSERT INTO plate_inventory (weight, pairs) VALUES
+			(45, 4), (35, 1), (25, 1), (10, 2), (5, 1), (2.5, 1)`,
+		// 012_set_sync
+		`ALTER TABLE logged_sets ADD COLUMN client_id TEXT`,
+		`ALTER TABLE logged_sets ADD COLUMN updated_at TIMESTAMP`,
+		`CREATE UNIQUE INDEX idx_logged_sets_client_id ON logged_sets(client_id)`,
+		`CREATE TABLE deleted_sets (
+			client_id TEXT PRIMARY KEY,
+			deleted_at TIMESTAMP NOT NULL
+		)`,
+		// 013_idempotency_keys
+		`CREATE TABLE idempotency_keys (
+			key TEXT PRIMARY KEY,
+			method TEXT NOT NULL,
+			path TEXT NOT NULL,
+			status INTEGER,
+			headers TEXT,
+			body BLOB,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX idx_idempotency_keys_created_at ON idempotency_keys(created_at)`,
+		// 014_workout_exercise_targets
+		`ALTER TABLE workout_exercises ADD COLUMN template_exercise_id INTEGER
+			REFERENCES template_exercises(id) ON DELETE SET NULL`,
+		`ALTER TABLE workout_exercises ADD COLUMN target_sets INTEGER`,
+		`ALTER TABLE workout_exercises ADD COLUMN target_reps INTEGER`,
+		// 015_template_versions
+		`CREATE TABLE template_versions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			template_id INTEGER NOT NULL,
+			version INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			change TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(template_id, version)
+		)`,
+		`CREATE TABLE template_version_exercises (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			version_id INTEGER NOT NULL REFERENCES template_versions(id) ON DELETE CASCADE,
+			exercise_id INTEGER REFERENCES exercises(id) ON DELETE SET NULL,
+			exercise_name TEXT NOT NULL,
+			target_sets INTEGER NOT NULL,
+			target_reps INTEGER NOT NULL,
+			position INTEGER NOT NULL,
+			UNIQUE(version_id, position)
+		)`,
+		`ALTER TABLE workouts ADD COLUMN template_version_id INTEGER REFERENCES template_versions(id)`,
+		// 016_coaching
+		`CREATE TABLE coaches (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			token_hash TEXT NOT NULL UNIQUE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			revoked_at TIMESTAMP
+		)`,
+		`CREATE TABLE coach_assignments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			coach_id INTEGER NOT NULL REFERENCES coaches(id) ON DELETE CASCADE,
+			template_id INTEGER REFERENCES workout_templates(id) ON DELETE CASCADE,
+			routine_id INTEGER REFERENCES routines(id) ON DELETE CASCADE,
+			note TEXT,
+			assigned_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			CHECK ((template_id IS NULL) != (routine_id IS NULL))
+		)`,
+		`CREATE TABLE workout_comments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			workout_id INTEGER NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+			coach_id INTEGER NOT NULL REFERENCES coaches(id) ON DELETE CASCADE,
+			body TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX idx_workout_comments_workout ON workout_comments(workout_id)`,
+		`CREATE TABLE notifications (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			message TEXT NOT NULL,
+			link TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			read_at TIMESTAMP
+		)`,
+		// 017_share_links
+		`CREATE TABLE share_links (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			token TEXT NOT NULL UNIQUE,
+			kind TEXT NOT NULL CHECK (kind IN ('workout', 'template', 'routine')),
+			target_id INTEGER NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP,
+			revoked_at TIMESTAMP
+		)`,
+		`CREATE INDEX idx_share_links_target ON share_links(kind, target_id)`,
+		// 018_calendar_feed
+		`CREATE TABLE routine_schedules (
+			routine_id INTEGER PRIMARY KEY REFERENCES routines(id) ON DELETE CASCADE,
+			start_date DATE NOT NULL,
+			weekdays TEXT NOT NULL,
+			time TEXT,
+			duration_minutes INTEGER NOT NULL DEFAULT 60
+		)`,
+		`CREATE TABLE calendar_settings (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			feed_token TEXT NOT NULL
+		)`,
+		`INSERT INTO calendar_settings (id, feed_token) VALUES (1, lower(hex(randomblob(16))))`,
+		// 019_webhooks
+		`CREATE TABLE webhooks (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			url TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			events TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE webhook_deliveries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+			event TEXT NOT NULL,
+			payload TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'failed')),
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			response_status INTEGER,
+			last_error TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			delivered_at TIMESTAMP
+		)`,
+		`CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at)`,
+		// 020_jobs
+		`CREATE TABLE jobs (
+			name TEXT PRIMARY KEY,
+			schedule TEXT NOT NULL DEFAULT '',
+			next_run_at TIMESTAMP,
+			failures INTEGER NOT NULL DEFAULT 0,
+			last_run_at TIMESTAMP,
+			last_status TEXT
+		)`,
+		`CREATE TABLE job_runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			job_name TEXT NOT NULL,
+			attempt INTEGER NOT NULL DEFAULT 1,
+			status TEXT NOT NULL CHECK (status IN ('running', 'succeeded', 'failed')),
+			started_at TIMESTAMP NOT NULL,
+			finished_at TIMESTAMP,
+			error TEXT
+		)`,
+		`CREATE INDEX idx_job_runs_job ON job_runs(job_name, id)`,
+		`CREATE TABLE job_lock (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			owner TEXT NOT NULL,
+			expires_at TIMESTAMP NOT NULL
+		)`,
+		// 021_search_index_rowids
+		`DROP TRIGGER exercises_search_delete`,
+		`DROP TRIGGER exercises_search_update`,
+		`DROP TRIGGER exercises_search_insert`,
+		`DROP TRIGGER routines_search_delete`,
+		`DROP TRIGGER routines_search_update`,
+		`DROP TRIGGER routines_search_insert`,
+		`DROP TRIGGER workout_templates_search_delete`,
+		`DROP TRIGGER workout_templates_search_update`,
+		`DROP TRIGGER workout_templates_search_insert`,
+		`DROP TRIGGER workouts_search_delete`,
+		`DROP TRIGGER workouts_search_update`,
+		`DROP TRIGGER workouts_search_insert`,
+		`DROP TABLE search_index`,
+		`CREATE VIRTUAL TABLE search_index USING fts5(
+			kind UNINDEXED,
+			ref_id UNINDEXED,
+			title,
+			body,
+			tokenize = 'porter unicode61'
+		)`,
+		`CREATE TRIGGER workouts_search_insert AFTER INSERT ON workouts BEGIN
+			INSERT INTO search_index (rowid, kind, ref_id, title, body)
+				VALUES (new.id * 4 + 0, 'workout', new.id, replace(replace(new.name, char(2), ''), char(3), ''), replace(replace(COALESCE(new.notes, ''), char(2), ''), char(3), ''));
+		END`,
+		`CREATE TRIGGER workouts_search_update AFTER UPDATE OF name, notes ON workouts BEGIN
+			UPDATE search_index SET title = replace(replace(new.name, char(2), ''), char(3), ''), body = replace(replace(COALESCE(new.notes, ''), char(2), ''), char(3), '')
+				WHERE rowid = new.id * 4 + 0;
+		END`,
+		`CREATE TRIGGER workouts_search_delete AFTER DELETE ON workouts BEGIN
+			DELETE FROM search_index WHERE rowid = old.id * 4 + 0;
+		END`,
+		`CREATE TRIGGER workout_templates_search_insert AFTER INSERT ON workout_templates BEGIN
+			INSERT INTO search_index (rowid, kind, ref_id, title, body)
+				VALUES (new.id * 4 + 1, 'template', new.id, replace(replace(new.name, char(2), ''), char(3), ''), '');
+		END`,
+		`CREATE TRIGGER workout_templates_search_update AFTER UPDATE OF name ON workout_templates BEGIN
+			UPDATE search_index SET title = replace(replace(new.name, char(2), ''), char(3), ''), body = ''
+				WHERE rowid = new.id * 4 + 1;
+		END`,
+		`CREATE TRIGGER workout_templates_search_delete AFTER DELETE ON workout_templates BEGIN
+			DELETE FROM search_index WHERE rowid = old.id * 4 + 1;
+		END`,
+		`CREATE TRIGGER routines_search_insert AFTER INSERT ON routines BEGIN
+			INSERT INTO search_index (rowid, kind, ref_id, title, body)
+				VALUES (new.id * 4 + 2, 'routine', new.id, replace(replace(new.name, char(2), ''), char(3), ''), '');
+		END`,
+		`CREATE TRIGGER routines_search_update AFTER UPDATE OF name ON routines BEGIN
+			UPDATE search_index SET title = replace(replace(new.name, char(2), ''), char(3), ''), body = ''
+				WHERE rowid = new.id * 4 + 2;
+		END`,
+		`CREATE TRIGGER routines_search_delete AFTER DELETE ON routines BEGIN
+			DELETE FROM search_index WHERE rowid = old.id * 4 + 2;
+		END`,
+		`CREATE TRIGGER exercises_search_insert AFTER INSERT ON exercises BEGIN
+			INSERT INTO search_index (rowid, kind, ref_id, title, body)
+				VALUES (new.id * 4 + 3, 'exercise', new.id, replace(replace(new.name, char(2), ''), char(3), ''), replace(replace(COALESCE(new.notes, ''), char(2), ''), char(3), ''));
+		END`,
+		`CREATE TRIGGER exercises_search_update AFTER UPDATE OF name, notes ON exercises BEGIN
+			UPDATE search_index SET title = replace(replace(new.name, char(2), ''), char(3), ''), body = replace(replace(COALESCE(new.notes, ''), char(2), ''), char(3), '')
+				WHERE rowid = new.id * 4 + 3;
+		END`,
+		`CREATE TRIGGER exercises_search_delete AFTER DELETE ON exercises BEGIN
+			DELETE FROM search_index WHERE rowid = old.id * 4 + 3;
+		END`,
+		// 022_idempotency_key_scope
+		`DROP TABLE idempotency_keys`,
+		`CREATE TABLE idempotency_keys (
+			key TEXT NOT NULL,
+			method TEXT NOT NULL,
+			path TEXT NOT NULL,
+			status INTEGER,
+			headers TEXT,
+			body BLOB,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (key, method, path)
+		)`,
+		`CREATE INDEX idx_idempotency_keys_created_at ON idempotency_keys(created_at)`,
+		// 023_foreign_key_orphans only removes rows, and a new database has none
+		// 024_share_link_token_hash
+		`DROP TABLE share_links`,
+		`CREATE TABLE share_links (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			token_hash TEXT NOT NULL UNIQUE,
+			kind TEXT NOT NULL CHECK (kind IN ('workout', 'template', 'routine')),
+			target_id INTEGER NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP,
+			revoked_at TIMESTAMP
+		)`,
+		`CREATE INDEX idx_share_links_target ON share_links(kind, target_id)`,
 	}
 
 	for _, stmt := range statements {
@@ -0,0 +1,17 @@
+package layouts
+
+import (
+	"context"
+	"slices"
+)
+
+// HiddenLinksKey is the fiber.Locals key holding the paths of navigation
+// links to leave out, for parts of the app that are turned off
+const HiddenLinksKey = "hiddenNavLinks"
+
+// showLink reports whether the navigation link to path is shown. Fiber
+// locals reach templates through the request context.
+func showLink(ctx context.Context, path string) bool {
+	hidden, _ := ctx.Value(HiddenLinksKey).([]string)
+	return !slices.Contains(hidden, path)
+}
@@ -0,0 +1,6 @@
+-- +goose Up
+-- exercises: Archived exercises are hidden from pickers but keep their history
+ALTER TABLE exercises ADD COLUMN archived_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE exercises DROP COLUMN archived_at;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- exercises: Per-exercise cues and defaults shown on the exercise detail page
+ALTER TABLE exercises ADD COLUMN notes TEXT;
+ALTER TABLE exercises ADD COLUMN default_rest_seconds INTEGER CHECK (default_rest_seconds >= 0);
+ALTER TABLE exercises ADD COLUMN default_increment REAL CHECK (default_increment > 0);
+ALTER TABLE exercises ADD COLUMN media_url TEXT;
+
+-- +goose Down
+ALTER TABLE exercises DROP COLUMN media_url;
+ALTER TABLE exercises DROP COLUMN default_increment;
+ALTER TABLE exercises DROP COLUMN default_rest_seconds;
+ALTER TABLE exercises DROP COLUMN notes;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- exercise_muscle_groups: Muscles an exercise trains, as primary or secondary movers
+CREATE TABLE exercise_muscle_groups (
+    exercise_id INTEGER NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+    muscle_group TEXT NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('primary', 'secondary')),
+    PRIMARY KEY (exercise_id, muscle_group)
+);
+
+-- exercise_equipment: Equipment an exercise needs
+CREATE TABLE exercise_equipment (
+    exercise_id INTEGER NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+    equipment TEXT NOT NULL,
+    PRIMARY KEY (exercise_id, equipment)
+);
+
+CREATE INDEX idx_exercise_muscle_groups_muscle_group ON exercise_muscle_groups(muscle_group);
+CREATE INDEX idx_exercise_equipment_equipment ON exercise_equipment(equipment);
+
+-- +goose Down
+DROP TABLE IF EXISTS exercise_equipment;
+DROP TABLE IF EXISTS exercise_muscle_groups;
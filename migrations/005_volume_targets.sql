@@ -0,0 +1,25 @@
+-- +goose Up
+-- muscle_volume_targets: Weekly hard-set ranges per muscle group (minimum
+-- effective volume to maximum recoverable volume)
+CREATE TABLE muscle_volume_targets (
+    muscle_group TEXT PRIMARY KEY,
+    mev INTEGER NOT NULL CHECK (mev >= 0),
+    mrv INTEGER NOT NULL CHECK (mrv >= mev)
+);
+
+INSERT INTO muscle_volume_targets (muscle_group, mev, mrv) VALUES
+    ('chest', 10, 22),
+    ('back', 10, 25),
+    ('traps', 4, 26),
+    ('shoulders', 8, 26),
+    ('biceps', 8, 26),
+    ('triceps', 6, 18),
+    ('forearms', 2, 25),
+    ('abs', 0, 25),
+    ('quads', 8, 20),
+    ('hamstrings', 6, 20),
+    ('glutes', 0, 16),
+    ('calves', 8, 20);
+
+-- +goose Down
+DROP TABLE IF EXISTS muscle_volume_targets;
@@ -0,0 +1,114 @@
+-- +goose Up
+-- search_index: Full-text index over workouts, templates, routines and exercises,
+-- kept in sync with the source tables by triggers
+CREATE VIRTUAL TABLE search_index USING fts5(
+    kind UNINDEXED,
+    ref_id UNINDEXED,
+    title,
+    body,
+    tokenize = 'porter unicode61'
+);
+
+-- +goose StatementBegin
+CREATE TRIGGER workouts_search_insert AFTER INSERT ON workouts BEGIN
+    INSERT INTO search_index (kind, ref_id, title, body) VALUES ('workout', new.id, new.name, COALESCE(new.notes, ''));
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER workouts_search_update AFTER UPDATE OF name, notes ON workouts BEGIN
+    DELETE FROM search_index WHERE kind = 'workout' AND ref_id = old.id;
+    INSERT INTO search_index (kind, ref_id, title, body) VALUES ('workout', new.id, new.name, COALESCE(new.notes, ''));
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER workouts_search_delete AFTER DELETE ON workouts BEGIN
+    DELETE FROM search_index WHERE kind = 'workout' AND ref_id = old.id;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER workout_templates_search_insert AFTER INSERT ON workout_templates BEGIN
+    INSERT INTO search_index (kind, ref_id, title, body) VALUES ('template', new.id, new.name, '');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER workout_templates_search_update AFTER UPDATE OF name ON workout_templates BEGIN
+    DELETE FROM search_index WHERE kind = 'template' AND ref_id = old.id;
+    INSERT INTO search_index (kind, ref_id, title, body) VALUES ('template', new.id, new.name, '');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER workout_templates_search_delete AFTER DELETE ON workout_templates BEGIN
+    DELETE FROM search_index WHERE kind = 'template' AND ref_id = old.id;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER routines_search_insert AFTER INSERT ON routines BEGIN
+    INSERT INTO search_index (kind, ref_id, title, body) VALUES ('routine', new.id, new.name, '');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER routines_search_update AFTER UPDATE OF name ON routines BEGIN
+    DELETE FROM search_index WHERE kind = 'routine' AND ref_id = old.id;
+    INSERT INTO search_index (kind, ref_id, title, body) VALUES ('routine', new.id, new.name, '');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER routines_search_delete AFTER DELETE ON routines BEGIN
+    DELETE FROM search_index WHERE kind = 'routine' AND ref_id = old.id;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER exercises_search_insert AFTER INSERT ON exercises BEGIN
+    INSERT INTO search_index (kind, ref_id, title, body) VALUES ('exercise', new.id, new.name, COALESCE(new.notes, ''));
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER exercises_search_update AFTER UPDATE OF name, notes ON exercises BEGIN
+    DELETE FROM search_index WHERE kind = 'exercise' AND ref_id = old.id;
+    INSERT INTO search_index (kind, ref_id, title, body) VALUES ('exercise', new.id, new.name, COALESCE(new.notes, ''));
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER exercises_search_delete AFTER DELETE ON exercises BEGIN
+    DELETE FROM search_index WHERE kind = 'exercise' AND ref_id = old.id;
+END;
+-- +goose StatementEnd
+
+-- Index rows that existed before this migration
+INSERT INTO search_index (kind, ref_id, title, body)
+SELECT 'workout', id, name, COALESCE(notes, '') FROM workouts;
+
+INSERT INTO search_index (kind, ref_id, title, body)
+SELECT 'template', id, name, '' FROM workout_templates;
+
+INSERT INTO search_index (kind, ref_id, title, body)
+SELECT 'routine', id, name, '' FROM routines;
+
+INSERT INTO search_index (kind, ref_id, title, body)
+SELECT 'exercise', id, name, COALESCE(notes, '') FROM exercises;
+
+-- +goose Down
+DROP TRIGGER IF EXISTS exercises_search_delete;
+DROP TRIGGER IF EXISTS exercises_search_update;
+DROP TRIGGER IF EXISTS exercises_search_insert;
+DROP TRIGGER IF EXISTS routines_search_delete;
+DROP TRIGGER IF EXISTS routines_search_update;
+DROP TRIGGER IF EXISTS routines_search_insert;
+DROP TRIGGER IF EXISTS workout_templates_search_delete;
+DROP TRIGGER IF EXISTS workout_templates_search_update;
+DROP TRIGGER IF EXISTS workout_templates_search_insert;
+DROP TRIGGER IF EXISTS workouts_search_delete;
+DROP TRIGGER IF EXISTS workouts_search_update;
+DROP TRIGGER IF EXISTS workouts_search_insert;
+DROP TABLE IF EXISTS search_index;
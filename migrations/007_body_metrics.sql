@@ -0,0 +1,16 @@
+-- +goose Up
+-- body_metrics: Dated body measurements, one value per metric per day
+CREATE TABLE body_metrics (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    date DATE NOT NULL,
+    metric TEXT NOT NULL,
+    value REAL NOT NULL CHECK (value > 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(date, metric)
+);
+
+CREATE INDEX idx_body_metrics_metric_date ON body_metrics(metric, date);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_body_metrics_metric_date;
+DROP TABLE IF EXISTS body_metrics;
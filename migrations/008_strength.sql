@@ -0,0 +1,27 @@
+-- +goose Up
+-- strength_settings: Single-row profile used for strength scoring
+CREATE TABLE strength_settings (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    sex TEXT NOT NULL CHECK (sex IN ('male', 'female')) DEFAULT 'male',
+    standards TEXT NOT NULL DEFAULT 'general'
+);
+
+INSERT INTO strength_settings (id) VALUES (1);
+
+-- strength_lifts: Which exercise counts as each big lift, with its best
+-- estimated one-rep max cached from finished workouts
+CREATE TABLE strength_lifts (
+    lift TEXT PRIMARY KEY,
+    exercise_id INTEGER REFERENCES exercises(id) ON DELETE SET NULL,
+    e1rm REAL,
+    weight REAL,
+    reps INTEGER,
+    date DATE,
+    workout_id INTEGER REFERENCES workouts(id) ON DELETE SET NULL
+);
+
+INSERT INTO strength_lifts (lift) VALUES ('squat'), ('bench'), ('deadlift'), ('press');
+
+-- +goose Down
+DROP TABLE IF EXISTS strength_lifts;
+DROP TABLE IF EXISTS strength_settings;
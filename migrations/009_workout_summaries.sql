@@ -0,0 +1,16 @@
+-- +goose Up
+-- workout_summaries: Totals computed when a workout is finished, so the
+-- history list does not have to aggregate every set
+CREATE TABLE workout_summaries (
+    workout_id INTEGER PRIMARY KEY REFERENCES workouts(id) ON DELETE CASCADE,
+    duration_seconds INTEGER NOT NULL,
+    total_volume REAL NOT NULL,
+    set_count INTEGER NOT NULL,
+    rep_count INTEGER NOT NULL,
+    pr_count INTEGER NOT NULL,
+    targets_met INTEGER NOT NULL,
+    targets_total INTEGER NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS workout_summaries;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Explicit session start and end times, independent of when the workout row
+-- was created, plus pause tracking for in-progress sessions
+ALTER TABLE workouts ADD COLUMN started_at TIMESTAMP;
+ALTER TABLE workouts ADD COLUMN ended_at TIMESTAMP;
+ALTER TABLE workouts ADD COLUMN paused_at TIMESTAMP;
+ALTER TABLE workouts ADD COLUMN paused_seconds INTEGER NOT NULL DEFAULT 0;
+
+UPDATE workouts SET started_at = created_at, ended_at = finished_at;
+
+-- +goose Down
+ALTER TABLE workouts DROP COLUMN paused_seconds;
+ALTER TABLE workouts DROP COLUMN paused_at;
+ALTER TABLE workouts DROP COLUMN ended_at;
+ALTER TABLE workouts DROP COLUMN started_at;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- Sets are either working sets or warm-ups; warm-ups are left out of volume,
+-- records and reports
+ALTER TABLE logged_sets ADD COLUMN set_type TEXT NOT NULL DEFAULT 'working'
+    CHECK (set_type IN ('working', 'warmup'));
+
+-- plate_settings: Single-row barbell setup used by the plate calculator
+CREATE TABLE plate_settings (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    bar_weight REAL NOT NULL CHECK (bar_weight >= 0) DEFAULT 45
+);
+
+INSERT INTO plate_settings (id) VALUES (1);
+
+-- plate_inventory: Plate sizes on hand, counted in pairs (one per side)
+CREATE TABLE plate_inventory (
+    weight REAL PRIMARY KEY CHECK (weight > 0),
+    pairs INTEGER NOT NULL CHECK (pairs > 0)
+);
+
+INSERT INTO plate_inventory (weight, pairs) VALUES
+    (45, 4), (35, 1), (25, 1), (10, 2), (5, 1), (2.5, 1);
+
+-- +goose Down
+DROP TABLE IF EXISTS plate_inventory;
+DROP TABLE IF EXISTS plate_settings;
+ALTER TABLE logged_sets DROP COLUMN set_type;
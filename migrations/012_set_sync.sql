@@ -0,0 +1,21 @@
+-- +goose Up
+-- Client-generated IDs let sets logged offline be replayed without creating
+-- duplicates; updated_at orders competing edits to the same set
+ALTER TABLE logged_sets ADD COLUMN client_id TEXT;
+ALTER TABLE logged_sets ADD COLUMN updated_at TIMESTAMP;
+
+UPDATE logged_sets SET client_id = lower(hex(randomblob(16))), updated_at = created_at;
+
+CREATE UNIQUE INDEX idx_logged_sets_client_id ON logged_sets(client_id);
+
+-- deleted_sets: Tombstones so a replayed change can't resurrect a deleted set
+CREATE TABLE deleted_sets (
+    client_id TEXT PRIMARY KEY,
+    deleted_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS deleted_sets;
+DROP INDEX IF EXISTS idx_logged_sets_client_id;
+ALTER TABLE logged_sets DROP COLUMN updated_at;
+ALTER TABLE logged_sets DROP COLUMN client_id;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- idempotency_keys: Responses to mutating requests, replayed when a request
+-- is retried with the same Idempotency-Key
+CREATE TABLE idempotency_keys (
+    key TEXT PRIMARY KEY,
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,
+    status INTEGER, -- NULL while the first request is still being handled
+    headers TEXT,
+    body BLOB,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_idempotency_keys_created_at ON idempotency_keys(created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS idempotency_keys;
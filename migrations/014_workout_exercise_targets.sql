@@ -0,0 +1,32 @@
+-- +goose Up
+-- Workout exercises remember the template exercise they came from and a copy
+-- of its targets, so a template using the same exercise twice keeps each
+-- entry's targets and later template edits don't rewrite old workouts
+ALTER TABLE workout_exercises ADD COLUMN template_exercise_id INTEGER
+    REFERENCES template_exercises(id) ON DELETE SET NULL;
+ALTER TABLE workout_exercises ADD COLUMN target_sets INTEGER;
+ALTER TABLE workout_exercises ADD COLUMN target_reps INTEGER;
+
+-- Existing workouts are matched to their template by exercise, preferring the
+-- template entry at the same position
+UPDATE workout_exercises SET template_exercise_id = (
+    SELECT id FROM (
+        SELECT te.id, te.position, te.position = workout_exercises.position AS same_position
+        FROM template_exercises te
+        JOIN workouts w ON w.template_id = te.template_id
+        WHERE w.id = workout_exercises.workout_id
+          AND te.exercise_id = workout_exercises.exercise_id
+    )
+    ORDER BY same_position DESC, position ASC
+    LIMIT 1
+);
+
+UPDATE workout_exercises SET
+    target_sets = (SELECT target_sets FROM template_exercises WHERE id = workout_exercises.template_exercise_id),
+    target_reps = (SELECT target_reps FROM template_exercises WHERE id = workout_exercises.template_exercise_id)
+WHERE template_exercise_id IS NOT NULL;
+
+-- +goose Down
+ALTER TABLE workout_exercises DROP COLUMN target_reps;
+ALTER TABLE workout_exercises DROP COLUMN target_sets;
+ALTER TABLE workout_exercises DROP COLUMN template_exercise_id;
@@ -0,0 +1,44 @@
+-- +goose Up
+-- template_versions: Immutable snapshots of a template, one per edit. Versions
+-- outlive their template so workouts keep the version they were started from
+CREATE TABLE template_versions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    template_id INTEGER NOT NULL,
+    version INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    change TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(template_id, version)
+);
+
+-- template_version_exercises: Exercises and targets as they were in a version.
+-- The name is kept so history still reads after an exercise is deleted.
+CREATE TABLE template_version_exercises (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    version_id INTEGER NOT NULL REFERENCES template_versions(id) ON DELETE CASCADE,
+    exercise_id INTEGER REFERENCES exercises(id) ON DELETE SET NULL,
+    exercise_name TEXT NOT NULL,
+    target_sets INTEGER NOT NULL,
+    target_reps INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    UNIQUE(version_id, position)
+);
+
+ALTER TABLE workouts ADD COLUMN template_version_id INTEGER REFERENCES template_versions(id);
+
+-- Existing templates start at version 1 as they are now. Earlier workouts
+-- can't be matched to a version and are left without one.
+INSERT INTO template_versions (template_id, version, name, change, created_at)
+SELECT id, 1, name, 'Created', COALESCE(updated_at, created_at)
+FROM workout_templates;
+
+INSERT INTO template_version_exercises (version_id, exercise_id, exercise_name, target_sets, target_reps, position)
+SELECT tv.id, te.exercise_id, e.name, te.target_sets, te.target_reps, te.position
+FROM template_exercises te
+JOIN exercises e ON te.exercise_id = e.id
+JOIN template_versions tv ON tv.template_id = te.template_id;
+
+-- +goose Down
+ALTER TABLE workouts DROP COLUMN template_version_id;
+DROP TABLE IF EXISTS template_version_exercises;
+DROP TABLE IF EXISTS template_versions;
@@ -0,0 +1,48 @@
+-- +goose Up
+-- coaches: People given access to this account through a private link. Only
+-- a hash of the link's token is stored; revoked coaches keep their comments.
+CREATE TABLE coaches (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+-- coach_assignments: Templates and routines a coach wrote for the athlete
+CREATE TABLE coach_assignments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    coach_id INTEGER NOT NULL REFERENCES coaches(id) ON DELETE CASCADE,
+    template_id INTEGER REFERENCES workout_templates(id) ON DELETE CASCADE,
+    routine_id INTEGER REFERENCES routines(id) ON DELETE CASCADE,
+    note TEXT,
+    assigned_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((template_id IS NULL) != (routine_id IS NULL))
+);
+
+-- workout_comments: Coach feedback on finished workouts
+CREATE TABLE workout_comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workout_id INTEGER NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+    coach_id INTEGER NOT NULL REFERENCES coaches(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_workout_comments_workout ON workout_comments(workout_id);
+
+-- notifications: Messages for the athlete, highlighted until read
+CREATE TABLE notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    message TEXT NOT NULL,
+    link TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    read_at TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS notifications;
+DROP INDEX IF EXISTS idx_workout_comments_workout;
+DROP TABLE IF EXISTS workout_comments;
+DROP TABLE IF EXISTS coach_assignments;
+DROP TABLE IF EXISTS coaches;
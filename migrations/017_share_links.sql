@@ -0,0 +1,19 @@
+-- +goose Up
+-- share_links: Public read-only links to a finished workout, a template or a
+-- routine. target_id isn't a foreign key because it points into a different
+-- table per kind; links to deleted targets simply stop resolving.
+CREATE TABLE share_links (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token TEXT NOT NULL UNIQUE,
+    kind TEXT NOT NULL CHECK (kind IN ('workout', 'template', 'routine')),
+    target_id INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX idx_share_links_target ON share_links(kind, target_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_share_links_target;
+DROP TABLE IF EXISTS share_links;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- routine_schedules: When a routine is trained. Sessions fall on the given
+-- weekdays (0 = Sunday) from start_date and rotate through the routine's
+-- templates in order. A NULL time means the sessions are all-day.
+CREATE TABLE routine_schedules (
+    routine_id INTEGER PRIMARY KEY REFERENCES routines(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    weekdays TEXT NOT NULL,
+    time TEXT,
+    duration_minutes INTEGER NOT NULL DEFAULT 60
+);
+
+-- calendar_settings: Single row holding the secret token of the iCal feed
+CREATE TABLE calendar_settings (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    feed_token TEXT NOT NULL
+);
+
+INSERT INTO calendar_settings (id, feed_token) VALUES (1, lower(hex(randomblob(16))));
+
+-- +goose Down
+DROP TABLE IF EXISTS calendar_settings;
+DROP TABLE IF EXISTS routine_schedules;
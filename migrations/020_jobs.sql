@@ -0,0 +1,39 @@
+-- +goose Up
+-- jobs: Background jobs registered by the server. schedule is a cron
+-- expression or "@every <duration>"; empty for jobs that only run when
+-- triggered. failures counts consecutive failed runs, which are retried with
+-- backoff before the job waits for its next scheduled time.
+CREATE TABLE jobs (
+    name TEXT PRIMARY KEY,
+    schedule TEXT NOT NULL DEFAULT '',
+    next_run_at TIMESTAMP,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_run_at TIMESTAMP,
+    last_status TEXT
+);
+
+-- job_runs: History of job runs, pruned after a while
+CREATE TABLE job_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    job_name TEXT NOT NULL,
+    attempt INTEGER NOT NULL DEFAULT 1,
+    status TEXT NOT NULL CHECK (status IN ('running', 'succeeded', 'failed')),
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP,
+    error TEXT
+);
+
+CREATE INDEX idx_job_runs_job ON job_runs(job_name, id);
+
+-- job_lock: Lease held by the one server process allowed to run jobs
+CREATE TABLE job_lock (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    owner TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS job_lock;
+DROP INDEX IF EXISTS idx_job_runs_job;
+DROP TABLE IF EXISTS job_runs;
+DROP TABLE IF EXISTS jobs;
@@ -0,0 +1,232 @@
+-- +goose Up
+-- search_index rows were found by their UNINDEXED kind and ref_id columns, a
+-- full scan of the index on every write. Rows are now keyed by rowid, which
+-- is ref_id * 4 plus 0 for workouts, 1 for templates, 2 for routines and 3
+-- for exercises. The \x02 and \x03 bytes mark highlighted matches in search
+-- results, so they're stripped from indexed text.
+DROP TRIGGER exercises_search_delete;
+DROP TRIGGER exercises_search_update;
+DROP TRIGGER exercises_search_insert;
+DROP TRIGGER routines_search_delete;
+DROP TRIGGER routines_search_update;
+DROP TRIGGER routines_search_insert;
+DROP TRIGGER workout_templates_search_delete;
+DROP TRIGGER workout_templates_search_update;
+DROP TRIGGER workout_templates_search_insert;
+DROP TRIGGER workouts_search_delete;
+DROP TRIGGER workouts_search_update;
+DROP TRIGGER workouts_search_insert;
+DROP TABLE search_index;
+
+CREATE VIRTUAL TABLE search_index USING fts5(
+    kind UNINDEXED,
+    ref_id UNINDEXED,
+    title,
+    body,
+    tokenize = 'porter unicode61'
+);
+
+-- +goose StatementBegin
+CREATE TRIGGER workouts_search_insert AFTER INSERT ON workouts BEGIN
+    INSERT INTO search_index (rowid, kind, ref_id, title, body)
+        VALUES (new.id * 4 + 0, 'workout', new.id, replace(replace(new.name, char(2), ''), char(3), ''), replace(replace(COALESCE(new.notes, ''), char(2), ''), char(3), ''));
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER workouts_search_update AFTER UPDATE OF name, notes ON workouts BEGIN
+    UPDATE search_index SET title = replace(replace(new.name, char(2), ''), char(3), ''), body = replace(replace(COALESCE(new.notes, ''), char(2), ''), char(3), '')
+        WHERE rowid = new.id * 4 + 0;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER workouts_search_delete AFTER DELETE ON workouts BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 0;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER workout_templates_search_insert AFTER INSERT ON workout_templates BEGIN
+    INSERT INTO search_index (rowid, kind, ref_id, title, body)
+        VALUES (new.id * 4 + 1, 'template', new.id, replace(replace(new.name, char(2), ''), char(3), ''), '');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER workout_templates_search_update AFTER UPDATE OF name ON workout_templates BEGIN
+    UPDATE search_index SET title = replace(replace(new.name, char(2), ''), char(3), ''), body = ''
+        WHERE rowid = new.id * 4 + 1;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER workout_templates_search_delete AFTER DELETE ON workout_templates BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 1;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER routines_search_insert AFTER INSERT ON routines BEGIN
+    INSERT INTO search_index (rowid, kind, ref_id, title, body)
+        VALUES (new.id * 4 + 2, 'routine', new.id, replace(replace(new.name, char(2), ''), char(3), ''), '');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER routines_search_update AFTER UPDATE OF name ON routines BEGIN
+    UPDATE search_index SET title = replace(replace(new.name, char(2), ''), char(3), ''), body = ''
+        WHERE rowid = new.id * 4 + 2;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER routines_search_delete AFTER DELETE ON routines BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 2;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER exercises_search_insert AFTER INSERT ON exercises BEGIN
+    INSERT INTO search_index (rowid, kind, ref_id, title, body)
+        VALUES (new.id * 4 + 3, 'exercise', new.id, replace(replace(new.name, char(2), ''), char(3), ''), replace(replace(COALESCE(new.notes, ''), char(2), ''), char(3), ''));
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER exercises_search_update AFTER UPDATE OF name, notes ON exercises BEGIN
+    UPDATE search_index SET title = replace(replace(new.name, char(2), ''), char(3), ''), body = replace(replace(COALESCE(new.notes, ''), char(2), ''), char(3), '')
+        WHERE rowid = new.id * 4 + 3;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER exercises_search_delete AFTER DELETE ON exercises BEGIN
+    DELETE FROM search_index WHERE rowid = old.id * 4 + 3;
+END;
+-- +goose StatementEnd
+
+-- Index the existing rows
+INSERT INTO search_index (rowid, kind, ref_id, title, body)
+SELECT id * 4 + 0, 'workout', id, replace(replace(name, char(2), ''), char(3), ''), replace(replace(COALESCE(notes, ''), char(2), ''), char(3), '') FROM workouts;
+
+INSERT INTO search_index (rowid, kind, ref_id, title, body)
+SELECT id * 4 + 1, 'template', id, replace(replace(name, char(2), ''), char(3), ''), '' FROM workout_templates;
+
+INSERT INTO search_index (rowid, kind, ref_id, title, body)
+SELECT id * 4 + 2, 'routine', id, replace(replace(name, char(2), ''), char(3), ''), '' FROM routines;
+
+INSERT INTO search_index (rowid, kind, ref_id, title, body)
+SELECT id * 4 + 3, 'exercise', id, replace(replace(name, char(2), ''), char(3), ''), replace(replace(COALESCE(notes, ''), char(2), ''), char(3), '') FROM exercises;
+
+-- +goose Down
+DROP TRIGGER exercises_search_delete;
+DROP TRIGGER exercises_search_update;
+DROP TRIGGER exercises_search_insert;
+DROP TRIGGER routines_search_delete;
+DROP TRIGGER routines_search_update;
+DROP TRIGGER routines_search_insert;
+DROP TRIGGER workout_templates_search_delete;
+DROP TRIGGER workout_templates_search_update;
+DROP TRIGGER workout_templates_search_insert;
+DROP TRIGGER workouts_search_delete;
+DROP TRIGGER workouts_search_update;
+DROP TRIGGER workouts_search_insert;
+DROP TABLE search_index;
+
+CREATE VIRTUAL TABLE search_index USING fts5(
+    kind UNINDEXED,
+    ref_id UNINDEXED,
+    title,
+    body,
+    tokenize = 'porter unicode61'
+);
+
+-- +goose StatementBegin
+CREATE TRIGGER workouts_search_insert AFTER INSERT ON workouts BEGIN
+    INSERT INTO search_index (kind, ref_id, title, body) VALUES ('workout', new.id, new.name, COALESCE(new.notes, ''));
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER workouts_search_update AFTER UPDATE OF name, notes ON workouts BEGIN
+    DELETE FROM search_index WHERE kind = 'workout' AND ref_id = old.id;
+    INSERT INTO search_index (kind, ref_id, title, body) VALUES ('workout', new.id, new.name, COALESCE(new.notes, ''));
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER workouts_search_delete AFTER DELETE ON workouts BEGIN
+    DELETE FROM search_index WHERE kind = 'workout' AND ref_id = old.id;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER workout_templates_search_insert AFTER INSERT ON workout_templates BEGIN
+    INSERT INTO search_index (kind, ref_id, title, body) VALUES ('template', new.id, new.name, '');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER workout_templates_search_update AFTER UPDATE OF name ON workout_templates BEGIN
+    DELETE FROM search_index WHERE kind = 'template' AND ref_id = old.id;
+    INSERT INTO search_index (kind, ref_id, title, body) VALUES ('template', new.id, new.name, '');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER workout_templates_search_delete AFTER DELETE ON workout_templates BEGIN
+    DELETE FROM search_index WHERE kind = 'template' AND ref_id = old.id;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER routines_search_insert AFTER INSERT ON routines BEGIN
+    INSERT INTO search_index (kind, ref_id, title, body) VALUES ('routine', new.id, new.name, '');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER routines_search_update AFTER UPDATE OF name ON routines BEGIN
+    DELETE FROM search_index WHERE kind = 'routine' AND ref_id = old.id;
+    INSERT INTO search_index (kind, ref_id, title, body) VALUES ('routine', new.id, new.name, '');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER routines_search_delete AFTER DELETE ON routines BEGIN
+    DELETE FROM search_index WHERE kind = 'routine' AND ref_id = old.id;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER exercises_search_insert AFTER INSERT ON exercises BEGIN
+    INSERT INTO search_index (kind, ref_id, title, body) VALUES ('exercise', new.id, new.name, COALESCE(new.notes, ''));
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER exercises_search_update AFTER UPDATE OF name, notes ON exercises BEGIN
+    DELETE FROM search_index WHERE kind = 'exercise' AND ref_id = old.id;
+    INSERT INTO search_index (kind, ref_id, title, body) VALUES ('exercise', new.id, new.name, COALESCE(new.notes, ''));
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER exercises_search_delete AFTER DELETE ON exercises BEGIN
+    DELETE FROM search_index WHERE kind = 'exercise' AND ref_id = old.id;
+END;
+-- +goose StatementEnd
+
+-- Index rows that existed before this migration
+INSERT INTO search_index (kind, ref_id, title, body)
+SELECT 'workout', id, name, COALESCE(notes, '') FROM workouts;
+
+INSERT INTO search_index (kind, ref_id, title, body)
+SELECT 'template', id, name, '' FROM workout_templates;
+
+INSERT INTO search_index (kind, ref_id, title, body)
+SELECT 'routine', id, name, '' FROM routines;
+
+INSERT INTO search_index (kind, ref_id, title, body)
+SELECT 'exercise', id, name, COALESCE(notes, '') FROM exercises;
@@ -0,0 +1,38 @@
+-- +goose Up
+-- Idempotency keys are scoped to the request they came with, so two clients
+-- picking the same key for different endpoints don't collide
+CREATE TABLE idempotency_keys_scoped (
+    key TEXT NOT NULL,
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,
+    status INTEGER, -- NULL while the first request is still being handled
+    headers TEXT,
+    body BLOB,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (key, method, path)
+);
+
+INSERT INTO idempotency_keys_scoped (key, method, path, status, headers, body, created_at)
+SELECT key, method, path, status, headers, body, created_at FROM idempotency_keys;
+
+DROP TABLE idempotency_keys;
+ALTER TABLE idempotency_keys_scoped RENAME TO idempotency_keys;
+CREATE INDEX idx_idempotency_keys_created_at ON idempotency_keys(created_at);
+
+-- +goose Down
+CREATE TABLE idempotency_keys_unscoped (
+    key TEXT PRIMARY KEY,
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,
+    status INTEGER,
+    headers TEXT,
+    body BLOB,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT OR IGNORE INTO idempotency_keys_unscoped (key, method, path, status, headers, body, created_at)
+SELECT key, method, path, status, headers, body, created_at FROM idempotency_keys;
+
+DROP TABLE idempotency_keys;
+ALTER TABLE idempotency_keys_unscoped RENAME TO idempotency_keys;
+CREATE INDEX idx_idempotency_keys_created_at ON idempotency_keys(created_at);
@@ -0,0 +1,36 @@
+-- +goose Up
+-- Foreign keys used to be off, so ON DELETE actions never ran. Clean up the
+-- rows they should have removed or unlinked, parents before children.
+DELETE FROM template_exercises WHERE template_id NOT IN (SELECT id FROM workout_templates);
+DELETE FROM workout_exercises WHERE workout_id NOT IN (SELECT id FROM workouts);
+DELETE FROM logged_sets WHERE workout_exercise_id NOT IN (SELECT id FROM workout_exercises);
+DELETE FROM routine_templates
+WHERE routine_id NOT IN (SELECT id FROM routines)
+   OR template_id NOT IN (SELECT id FROM workout_templates);
+DELETE FROM exercise_muscle_groups WHERE exercise_id NOT IN (SELECT id FROM exercises);
+DELETE FROM exercise_equipment WHERE exercise_id NOT IN (SELECT id FROM exercises);
+DELETE FROM workout_summaries WHERE workout_id NOT IN (SELECT id FROM workouts);
+DELETE FROM template_version_exercises WHERE version_id NOT IN (SELECT id FROM template_versions);
+DELETE FROM coach_assignments
+WHERE coach_id NOT IN (SELECT id FROM coaches)
+   OR template_id NOT IN (SELECT id FROM workout_templates)
+   OR routine_id NOT IN (SELECT id FROM routines);
+DELETE FROM workout_comments
+WHERE workout_id NOT IN (SELECT id FROM workouts)
+   OR coach_id NOT IN (SELECT id FROM coaches);
+DELETE FROM routine_schedules WHERE routine_id NOT IN (SELECT id FROM routines);
+DELETE FROM webhook_deliveries WHERE webhook_id NOT IN (SELECT id FROM webhooks);
+
+UPDATE workouts SET template_id = NULL
+WHERE template_id NOT IN (SELECT id FROM workout_templates);
+UPDATE workout_exercises SET template_exercise_id = NULL
+WHERE template_exercise_id NOT IN (SELECT id FROM template_exercises);
+UPDATE template_version_exercises SET exercise_id = NULL
+WHERE exercise_id NOT IN (SELECT id FROM exercises);
+UPDATE strength_lifts SET exercise_id = NULL
+WHERE exercise_id NOT IN (SELECT id FROM exercises);
+UPDATE strength_lifts SET workout_id = NULL
+WHERE workout_id NOT IN (SELECT id FROM workouts);
+
+-- +goose Down
+-- Removed orphans can't be brought back, and there is no schema to undo
@@ -0,0 +1,41 @@
+-- +goose Up
+-- Share links store a hash of their token, like coach links, so a leaked
+-- database or backup doesn't leak working links. sha256_hex is registered by
+-- internal/shared/db, so existing links keep working.
+CREATE TABLE share_links_hashed (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token_hash TEXT NOT NULL UNIQUE,
+    kind TEXT NOT NULL CHECK (kind IN ('workout', 'template', 'routine')),
+    target_id INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+INSERT INTO share_links_hashed (id, token_hash, kind, target_id, created_at, expires_at, revoked_at)
+SELECT id, sha256_hex(token), kind, target_id, created_at, expires_at, revoked_at
+FROM share_links;
+
+DROP TABLE share_links;
+ALTER TABLE share_links_hashed RENAME TO share_links;
+CREATE INDEX idx_share_links_target ON share_links(kind, target_id);
+
+-- +goose Down
+-- Tokens can't be recovered from their hashes, so every link stops working
+CREATE TABLE share_links_plain (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token TEXT NOT NULL UNIQUE,
+    kind TEXT NOT NULL CHECK (kind IN ('workout', 'template', 'routine')),
+    target_id INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+INSERT INTO share_links_plain (id, token, kind, target_id, created_at, expires_at, revoked_at)
+SELECT id, token_hash, kind, target_id, created_at, expires_at, COALESCE(revoked_at, CURRENT_TIMESTAMP)
+FROM share_links;
+
+DROP TABLE share_links;
+ALTER TABLE share_links_plain RENAME TO share_links;
+CREATE INDEX idx_share_links_target ON share_links(kind, target_id);
@@ -0,0 +1,34 @@
+// Sends an Idempotency-Key header with every mutating HTMX request.
+//
+// The key belongs to the element that issued the request and is kept until a
+// response arrives, so a retry after a dropped connection or a repeated tap
+// while the first request is in flight is recognised by the server and gets
+// the original response instead of being applied twice. Server errors are not
+// stored, so their key is dropped too and the next attempt starts fresh.
+(function () {
+  'use strict';
+
+  function newKey() {
+    if (window.crypto && crypto.randomUUID) {
+      return crypto.randomUUID();
+    }
+    return Date.now().toString(36) + '-' + Math.random().toString(36).slice(2);
+  }
+
+  document.addEventListener('htmx:configRequest', function (evt) {
+    if (evt.detail.verb === 'get') {
+      return;
+    }
+    var elt = evt.detail.elt;
+    if (!elt.dataset.idempotencyKey) {
+      elt.dataset.idempotencyKey = newKey();
+    }
+    evt.detail.headers['Idempotency-Key'] = elt.dataset.idempotencyKey;
+  });
+
+  document.addEventListener('htmx:afterRequest', function (evt) {
+    if (evt.detail.xhr && evt.detail.xhr.status > 0) {
+      delete evt.detail.elt.dataset.idempotencyKey;
+    }
+  });
+})();
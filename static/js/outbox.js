@@ -0,0 +1,225 @@
+// Offline outbox for set logging.
+//
+// Set additions, edits and deletions that can't reach the server are queued in
+// localStorage and replayed against the idempotent /workouts/sync/sets
+// endpoints when the connection returns. Every set carries a client-generated
+// ID, so replaying a change twice never creates a duplicate. The server
+// resolves conflicts (newest edit wins, deletions and finished workouts win
+// over stale changes) and answers 409 for changes it did not apply; those are
+// dropped like applied ones. Only network errors and 5xx responses are retried.
+(function () {
+  'use strict';
+
+  var STORAGE_KEY = 'phobos-outbox';
+  var RETRY_INTERVAL = 30000;
+  var flushing = false;
+
+  function newClientID() {
+    if (window.crypto && crypto.randomUUID) {
+      return crypto.randomUUID();
+    }
+    var bytes = new Uint8Array(16);
+    crypto.getRandomValues(bytes);
+    return Array.prototype.map.call(bytes, function (b) {
+      return ('0' + b.toString(16)).slice(-2);
+    }).join('');
+  }
+
+  function load() {
+    try {
+      return JSON.parse(localStorage.getItem(STORAGE_KEY)) || [];
+    } catch (e) {
+      return [];
+    }
+  }
+
+  function save(queue) {
+    localStorage.setItem(STORAGE_KEY, JSON.stringify(queue));
+    renderStatus(queue.length);
+  }
+
+  // enqueue adds a change, replacing any queued change to the same set since
+  // only the latest state needs to reach the server
+  function enqueue(op) {
+    var queue = load().filter(function (o) {
+      return o.clientID !== op.clientID;
+    });
+    queue.push(op);
+    save(queue);
+  }
+
+  function renderStatus(count) {
+    var el = document.getElementById('outbox-status');
+    if (!el) {
+      el = document.createElement('div');
+      el.id = 'outbox-status';
+      el.className = 'fixed bottom-4 left-4 z-50 px-3 py-2 rounded-lg bg-amber-100 text-amber-800 text-sm shadow';
+      document.body.appendChild(el);
+    }
+    el.textContent = count === 1 ? '1 change waiting to sync' : count + ' changes waiting to sync';
+    el.hidden = count === 0;
+  }
+
+  function request(op) {
+    var body = new URLSearchParams(op.body || {});
+    var url = '/workouts/sync/sets/' + encodeURIComponent(op.clientID);
+    if (op.method === 'DELETE') {
+      url += '?' + body.toString();
+      return fetch(url, { method: 'DELETE', credentials: 'same-origin' });
+    }
+    return fetch(url, {
+      method: op.method,
+      credentials: 'same-origin',
+      headers: { 'Content-Type': 'application/x-www-form-urlencoded' },
+      body: body,
+    });
+  }
+
+  // flush replays queued changes in order, stopping at the first one that
+  // can't be delivered so later edits never overtake earlier ones
+  function flush() {
+    if (flushing || !navigator.onLine || load().length === 0) {
+      return;
+    }
+    flushing = true;
+    var delivered = 0;
+
+    (function next() {
+      var op = load()[0];
+      if (!op) {
+        flushing = false;
+        if (delivered > 0 && document.querySelector('[data-outbox]')) {
+          window.location.reload();
+        }
+        return;
+      }
+      request(op).then(function (resp) {
+        if (resp.status >= 500) {
+          throw new Error('server error ' + resp.status);
+        }
+        delivered++;
+        // A newer change to the same set queued meanwhile stays in the outbox
+        save(load().filter(function (o) {
+          return o.clientID !== op.clientID || o.queuedAt !== op.queuedAt;
+        }));
+        next();
+      }).catch(function () {
+        flushing = false;
+      });
+    })();
+  }
+
+  function formValue(config, name) {
+    var value = config.formData && config.formData.get(name);
+    return value === null || value === undefined ? '' : String(value);
+  }
+
+  function setsContainer(workoutExerciseID) {
+    return document.getElementById('sets-' + workoutExerciseID);
+  }
+
+  function renderPendingSet(op) {
+    var container = setsContainer(op.body.workout_exercise_id);
+    if (!container) {
+      return;
+    }
+    var row = document.createElement('div');
+    row.className = 'flex items-center gap-2 text-gray-500';
+    row.dataset.clientId = op.clientID;
+    row.textContent = op.body.reps + ' reps x ' + op.body.weight + ' lbs (waiting to sync)';
+    container.appendChild(row);
+  }
+
+  // Give each new set a client ID. The ID is kept until the server confirms
+  // the set, so a double-tapped or retried submission reuses it.
+  document.addEventListener('htmx:configRequest', function (evt) {
+    var elt = evt.detail.elt;
+    if (!elt.dataset || !elt.dataset.outbox) {
+      return;
+    }
+    if (elt.dataset.outbox === 'add') {
+      if (!elt.dataset.pendingClientId) {
+        elt.dataset.pendingClientId = newClientID();
+      }
+      evt.detail.parameters.client_id = elt.dataset.pendingClientId;
+    }
+  });
+
+  document.addEventListener('htmx:afterRequest', function (evt) {
+    var elt = evt.detail.elt;
+    if (elt.dataset && elt.dataset.outbox === 'add' && evt.detail.successful) {
+      delete elt.dataset.pendingClientId;
+    }
+  });
+
+  document.addEventListener('htmx:sendError', function (evt) {
+    var elt = evt.detail.elt;
+    var kind = elt.dataset && elt.dataset.outbox;
+    if (!kind) {
+      return;
+    }
+    var config = evt.detail.requestConfig || {};
+    var now = Date.now();
+    // The outbox takes over this change, so the next request from the element
+    // must not reuse its idempotency key
+    delete elt.dataset.idempotencyKey;
+
+    if (kind === 'add') {
+      var op = {
+        clientID: elt.dataset.pendingClientId,
+        method: 'PUT',
+        queuedAt: now,
+        body: {
+          workout_exercise_id: elt.dataset.workoutExerciseId,
+          reps: formValue(config, 'reps'),
+          weight: formValue(config, 'weight'),
+          set_type: formValue(config, 'set_type') || 'working',
+          updated_at: String(now),
+        },
+      };
+      delete elt.dataset.pendingClientId;
+      enqueue(op);
+      renderPendingSet(op);
+      return;
+    }
+
+    var row = elt.closest('[data-client-id]');
+    if (!row) {
+      return;
+    }
+    if (kind === 'update') {
+      enqueue({
+        clientID: row.dataset.clientId,
+        method: 'PUT',
+        queuedAt: now,
+        body: {
+          workout_exercise_id: row.dataset.workoutExerciseId,
+          reps: formValue(config, 'reps'),
+          weight: formValue(config, 'weight'),
+          set_type: row.dataset.setType || 'working',
+          updated_at: String(now),
+        },
+      });
+    } else if (kind === 'delete') {
+      enqueue({
+        clientID: row.dataset.clientId,
+        method: 'DELETE',
+        queuedAt: now,
+        body: { updated_at: String(now) },
+      });
+      row.remove();
+    }
+  });
+
+  window.addEventListener('online', flush);
+  setInterval(flush, RETRY_INTERVAL);
+
+  document.addEventListener('DOMContentLoaded', function () {
+    renderStatus(load().length);
+    flush();
+  });
+
+  if ('serviceWorker' in navigator) {
+    navigator.serviceWorker.register('/sw.js');
+  }
+})();
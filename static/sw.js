@@ -0,0 +1,76 @@
+// Service worker that keeps the app usable without a connection.
+//
+// Pages are fetched network-first and cached as they are visited, so a
+// workout opened before losing signal can still be shown. Scripts and other
+// static files are served cache-first. Requests other than GET, and HTMX
+// fragment requests, always go to the network; failed set changes are queued
+// by the outbox in outbox.js instead.
+const CACHE = 'phobos-v3';
+
+const PRECACHE = [
+  '/static/vendor/htmx.min.js',
+  '/static/vendor/sse.js',
+  '/static/js/idempotency.js',
+  '/static/js/outbox.js',
+  'https://cdn.tailwindcss.com',
+];
+
+self.addEventListener('install', (event) => {
+  event.waitUntil(
+    caches.open(CACHE)
+      .then((cache) => Promise.all(PRECACHE.map((url) =>
+        fetch(url, { mode: url.startsWith('http') ? 'no-cors' : 'same-origin' })
+          .then((resp) => cache.put(url, resp))
+          .catch(() => {})
+      )))
+      .then(() => self.skipWaiting())
+  );
+});
+
+self.addEventListener('activate', (event) => {
+  event.waitUntil(
+    caches.keys()
+      .then((keys) => Promise.all(keys.filter((k) => k !== CACHE).map((k) => caches.delete(k))))
+      .then(() => self.clients.claim())
+  );
+});
+
+self.addEventListener('fetch', (event) => {
+  const req = event.request;
+  if (req.method !== 'GET' || req.headers.get('HX-Request') === 'true') {
+    return;
+  }
+
+  const url = new URL(req.url);
+  const isStatic = url.origin !== self.location.origin ||
+    url.pathname.startsWith('/static/') || url.pathname.startsWith('/assets/');
+
+  if (isStatic) {
+    event.respondWith(
+      caches.match(req).then((cached) => cached || fetch(req).then((resp) => {
+        const copy = resp.clone();
+        caches.open(CACHE).then((cache) => cache.put(req, copy));
+        return resp;
+      }))
+    );
+    return;
+  }
+
+  if (req.mode === 'navigate') {
+    event.respondWith(
+      fetch(req)
+        .then((resp) => {
+          if (resp.ok) {
+            const copy = resp.clone();
+            caches.open(CACHE).then((cache) => cache.put(req, copy));
+          }
+          return resp;
+        })
+        .catch(() => caches.match(req).then((cached) => cached ||
+          new Response('You are offline and this page has not been visited yet.', {
+            status: 503,
+            headers: { 'Content-Type': 'text/plain; charset=utf-8' },
+          })))
+    );
+  }
+});